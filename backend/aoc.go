@@ -0,0 +1,259 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Advent of Code ran 25 puzzles a year from 2015 and 12 from 2025 on
+const (
+	aocFirstYear     = 2015
+	aocShortYear     = 2025
+	aocDaysPerYear   = 25
+	aocDaysShortYear = 12
+)
+
+// AoCReference points an algorithm at an Advent of Code puzzle
+type AoCReference struct {
+	Year  int    `json:"year"`
+	Day   int    `json:"day"`
+	Part  int    `json:"part,omitempty"` // 1 or 2; 0 when the whole puzzle applies
+	Title string `json:"title,omitempty"`
+	Notes string `json:"notes,omitempty"`
+
+	// legacy holds a free-text entry from before references were
+	// structured that doesn't name a puzzle; see normalizeAoC
+	legacy string
+}
+
+// URL links to the puzzle on adventofcode.com
+func (ref AoCReference) URL() string {
+	return fmt.Sprintf("https://adventofcode.com/%d/day/%d", ref.Year, ref.Day)
+}
+
+// String renders the reference the way the old free-text entries read
+func (ref AoCReference) String() string {
+	s := fmt.Sprintf("%d Day %d", ref.Year, ref.Day)
+	if ref.Title != "" {
+		s += " - " + ref.Title
+	}
+	if ref.Part != 0 {
+		s += fmt.Sprintf(" (part %d)", ref.Part)
+	}
+	if ref.Notes != "" {
+		s += " (" + ref.Notes + ")"
+	}
+	return s
+}
+
+// UnmarshalJSON also accepts the old free-text form, "2022 Day 12 - Hill
+// Climbing", which submissions still send
+func (ref *AoCReference) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		if parsed, ok := parseAoCReference(text); ok {
+			*ref = parsed
+		} else {
+			*ref = AoCReference{legacy: strings.TrimSpace(text)}
+		}
+		return nil
+	}
+
+	type plain AoCReference // drops this method to avoid recursion
+	return json.Unmarshal(data, (*plain)(ref))
+}
+
+var (
+	aocEntryPattern = regexp.MustCompile(`^(\d{4})\s+Day\s+(\d{1,2})\b\s*(?:[-–:]\s*(.*))?$`)
+	aocNotePattern  = regexp.MustCompile(`\s*\(([^()]*)\)\s*$`)
+	aocPartPattern  = regexp.MustCompile(`(?i)^part\s*([12])$`)
+)
+
+// parseAoCReference reads "2023 Day 10 - Pipe Maze (part 2)". A trailing
+// parenthetical becomes the part when it names one and a note otherwise.
+func parseAoCReference(text string) (AoCReference, bool) {
+	m := aocEntryPattern.FindStringSubmatch(strings.TrimSpace(text))
+	if m == nil {
+		return AoCReference{}, false
+	}
+	year, _ := strconv.Atoi(m[1])
+	day, _ := strconv.Atoi(m[2])
+	ref := AoCReference{Year: year, Day: day}
+
+	title := strings.TrimSpace(m[3])
+	if n := aocNotePattern.FindStringSubmatchIndex(title); n != nil {
+		note := strings.TrimSpace(title[n[2]:n[3]])
+		if p := aocPartPattern.FindStringSubmatch(note); p != nil {
+			ref.Part, _ = strconv.Atoi(p[1])
+		} else {
+			ref.Notes = note
+		}
+		title = strings.TrimSpace(title[:n[0]])
+	}
+	ref.Title = title
+	return ref, true
+}
+
+// aocDays returns how many puzzles a year has
+func aocDays(year int) int {
+	if year >= aocShortYear {
+		return aocDaysShortYear
+	}
+	return aocDaysPerYear
+}
+
+// aocLatestYear is the newest event that has started; each starts on
+// December 1st
+func aocLatestYear(now time.Time) int {
+	now = now.UTC()
+	if now.Month() == time.December {
+		return now.Year()
+	}
+	return now.Year() - 1
+}
+
+// validateAoCYearDay checks that a puzzle exists. Day 0 checks the year only.
+func validateAoCYearDay(year, day int) error {
+	if latest := aocLatestYear(time.Now()); year < aocFirstYear || year > latest {
+		return fmt.Errorf("year must be between %d and %d", aocFirstYear, latest)
+	}
+	if days := aocDays(year); day < 0 || day > days {
+		return fmt.Errorf("day must be between 1 and %d for %d", days, year)
+	}
+	return nil
+}
+
+func validateAoCReference(ref AoCReference) error {
+	if ref.Day == 0 {
+		return fmt.Errorf("%d: day is required", ref.Year)
+	}
+	if err := validateAoCYearDay(ref.Year, ref.Day); err != nil {
+		return fmt.Errorf("%d day %d: %w", ref.Year, ref.Day, err)
+	}
+	if ref.Part < 0 || ref.Part > 2 {
+		return fmt.Errorf("%d day %d: part must be 1 or 2", ref.Year, ref.Day)
+	}
+	return nil
+}
+
+// normalizeAoC moves free-text entries that don't name a puzzle, such as
+// "Any grid pathfinding", from AoCExamples to AoCNotes
+func normalizeAoC(algo *Algorithm) {
+	refs := make([]AoCReference, 0, len(algo.AoCExamples))
+	for _, ref := range algo.AoCExamples {
+		if ref.legacy != "" {
+			algo.AoCNotes = append(algo.AoCNotes, ref.legacy)
+			continue
+		}
+		if ref.Year != 0 || ref.Day != 0 {
+			refs = append(refs, ref)
+		}
+	}
+	algo.AoCExamples = refs
+}
+
+// AoCMatch is an algorithm that applies to a puzzle
+type AoCMatch struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Category   string `json:"category"`
+	Difficulty string `json:"difficulty"`
+	Part       int    `json:"part,omitempty"`
+	Notes      string `json:"notes,omitempty"`
+}
+
+// AoCPuzzle lists the algorithms referenced by one puzzle
+type AoCPuzzle struct {
+	Year       int        `json:"year"`
+	Day        int        `json:"day"`
+	Title      string     `json:"title,omitempty"`
+	URL        string     `json:"url"`
+	Algorithms []AoCMatch `json:"algorithms"`
+}
+
+// aocPuzzles groups the references to year (and day, unless it is 0) by
+// puzzle, ordered by day
+func aocPuzzles(algos []Algorithm, year, day int) []AoCPuzzle {
+	byDay := make(map[int]*AoCPuzzle)
+	for _, algo := range algos {
+		for _, ref := range algo.AoCExamples {
+			if ref.Year != year || (day != 0 && ref.Day != day) {
+				continue
+			}
+			puzzle, ok := byDay[ref.Day]
+			if !ok {
+				puzzle = &AoCPuzzle{Year: ref.Year, Day: ref.Day, URL: ref.URL(), Algorithms: []AoCMatch{}}
+				byDay[ref.Day] = puzzle
+			}
+			if puzzle.Title == "" {
+				puzzle.Title = ref.Title
+			}
+			puzzle.Algorithms = append(puzzle.Algorithms, AoCMatch{
+				ID:         algo.ID,
+				Name:       algo.Name,
+				Category:   algo.Category,
+				Difficulty: algo.Difficulty,
+				Part:       ref.Part,
+				Notes:      ref.Notes,
+			})
+		}
+	}
+
+	puzzles := make([]AoCPuzzle, 0, len(byDay))
+	for _, puzzle := range byDay {
+		puzzles = append(puzzles, *puzzle)
+	}
+	sort.Slice(puzzles, func(i, j int) bool { return puzzles[i].Day < puzzles[j].Day })
+	return puzzles
+}
+
+// handleAoC serves /api/aoc/{year} and /api/aoc/{year}/{day}
+func handleAoC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/aoc/"), "/"), "/")
+	if len(parts) > 2 || parts[0] == "" {
+		http.Error(w, "Expected /api/aoc/{year} or /api/aoc/{year}/{day}", http.StatusNotFound)
+		return
+	}
+	year, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "year must be a number", http.StatusBadRequest)
+		return
+	}
+	day := 0
+	if len(parts) == 2 {
+		if day, err = strconv.Atoi(parts[1]); err != nil || day == 0 {
+			http.Error(w, "day must be a positive number", http.StatusBadRequest)
+			return
+		}
+	}
+	if err := validateAoCYearDay(year, day); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	puzzles := aocPuzzles(db.GetApprovedAlgorithms(), year, day)
+	if day == 0 {
+		respondJSON(w, map[string]interface{}{
+			"year":    year,
+			"puzzles": puzzles,
+		})
+		return
+	}
+
+	puzzle := AoCPuzzle{Year: year, Day: day, URL: AoCReference{Year: year, Day: day}.URL(), Algorithms: []AoCMatch{}}
+	if len(puzzles) > 0 {
+		puzzle = puzzles[0]
+	}
+	respondJSON(w, puzzle)
+}
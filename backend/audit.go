@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Audit actions recorded for administrative activity
+const (
+	auditApprove       = "approve"
+	auditReject        = "reject"
+	auditLoginSuccess  = "login_success"
+	auditLoginFailure  = "login_failure"
+	auditSpamSettings  = "update_spam_settings"
+	auditReload        = "reload"
+	auditDailyOverride = "set_daily_override"
+
+	auditComparisonCreate = "create_comparison"
+	auditComparisonUpdate = "update_comparison"
+	auditComparisonDelete = "delete_comparison"
+)
+
+// loginAuditInterval controls how often a successful Basic Auth login is
+// recorded for the same actor and IP. Every admin request re-authenticates,
+// so without this each page load would add an entry.
+const loginAuditInterval = 30 * time.Minute
+
+// AuditEntry is a single record in the audit log
+type AuditEntry struct {
+	Time     time.Time `json:"time"`
+	Actor    string    `json:"actor"`
+	IP       string    `json:"ip"`
+	Action   string    `json:"action"`
+	BeforeID string    `json:"beforeId,omitempty"` // e.g. submission ID
+	AfterID  string    `json:"afterId,omitempty"`  // e.g. resulting algorithm ID
+}
+
+// AuditLog appends entries as JSON lines to a file that is never rewritten
+type AuditLog struct {
+	mu         sync.Mutex
+	path       string
+	lastLogins map[string]time.Time
+}
+
+func NewAuditLog(path string) *AuditLog {
+	return &AuditLog{
+		path:       path,
+		lastLogins: make(map[string]time.Time),
+	}
+}
+
+// Record appends an entry to the log
+func (a *AuditLog) Record(entry AuditEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	f, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// RecordLogin records a successful login, at most once per loginAuditInterval
+// for each actor/IP pair
+func (a *AuditLog) RecordLogin(actor, ip string) error {
+	key := actor + "|" + ip
+	now := time.Now()
+
+	a.mu.Lock()
+	last, seen := a.lastLogins[key]
+	if seen && now.Sub(last) < loginAuditInterval {
+		a.mu.Unlock()
+		return nil
+	}
+	a.lastLogins[key] = now
+	a.mu.Unlock()
+
+	return a.Record(AuditEntry{Actor: actor, IP: ip, Action: auditLoginSuccess})
+}
+
+// AuditQuery filters audit entries. Zero values match everything.
+type AuditQuery struct {
+	Actor  string
+	Action string
+	Since  time.Time
+	Until  time.Time
+}
+
+func (q AuditQuery) matches(e AuditEntry) bool {
+	if q.Actor != "" && e.Actor != q.Actor {
+		return false
+	}
+	if q.Action != "" && e.Action != q.Action {
+		return false
+	}
+	if !q.Since.IsZero() && e.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && e.Time.After(q.Until) {
+		return false
+	}
+	return true
+}
+
+// Query returns matching entries in the order they were written
+func (a *AuditLog) Query(q AuditQuery) ([]AuditEntry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entries := make([]AuditEntry, 0)
+
+	f, err := os.Open(a.path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue // Skip a partially written line rather than fail the query
+		}
+		if q.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// adminActor returns the Basic Auth username of an authenticated admin request
+func adminActor(r *http.Request) string {
+	user, _, _ := r.BasicAuth()
+	return user
+}
+
+// recordAudit writes an admin action to the audit log, logging any failure
+func recordAudit(r *http.Request, action, beforeID, afterID string) {
+	err := auditLog.Record(AuditEntry{
+		Actor:    adminActor(r),
+		IP:       getClientIP(r),
+		Action:   action,
+		BeforeID: beforeID,
+		AfterID:  afterID,
+	})
+	if err != nil {
+		requestLogger(r).Error("Failed to write audit entry", "action", action, "err", err)
+	}
+}
+
+func handleAdminAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	q := AuditQuery{
+		Actor:  query.Get("actor"),
+		Action: query.Get("action"),
+	}
+
+	var err error
+	if q.Since, err = parseAuditTime(query.Get("since")); err != nil {
+		http.Error(w, "Invalid 'since' time, expected RFC 3339 or Unix seconds", http.StatusBadRequest)
+		return
+	}
+	if q.Until, err = parseAuditTime(query.Get("until")); err != nil {
+		http.Error(w, "Invalid 'until' time, expected RFC 3339 or Unix seconds", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := auditLog.Query(q)
+	if err != nil {
+		http.Error(w, "Failed to read audit log", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, entries)
+}
+
+// parseAuditTime accepts RFC 3339 timestamps or Unix seconds; empty is zero
+func parseAuditTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Captcha types
+const (
+	captchaPoW        = "pow"
+	captchaArithmetic = "arithmetic"
+)
+
+// captchaTTL is how long a challenge token stays valid
+const captchaTTL = 10 * time.Minute
+
+// CaptchaChallenge for anti-spam. The ID handed to clients is a signed
+// token carrying everything needed to verify the answer, so no server-side
+// state is kept per challenge.
+type CaptchaChallenge struct {
+	ID         string
+	Type       string
+	Nonce      string // random; also identifies the token for replay protection
+	Question   string // arithmetic
+	Answer     int    // arithmetic; only known while issuing
+	AnswerHash []byte // arithmetic; keyed hash of Answer carried in the token
+	Difficulty int    // proof of work, in leading zero bits
+	ExpiresAt  time.Time
+}
+
+// captchaClaims is the signed payload of a challenge token
+type captchaClaims struct {
+	Type       string `json:"t"`
+	Nonce      string `json:"n"`
+	AnswerHash []byte `json:"h,omitempty"`
+	Difficulty int    `json:"d,omitempty"`
+	ExpiresAt  int64  `json:"e"`
+}
+
+// Proof-of-work difficulty is measured in leading zero bits of the hash.
+// Each extra bit doubles the expected work for the client.
+const (
+	powMaxDifficulty     = 24
+	powMaxSolutionLength = 64
+	powVolumeWindow      = time.Hour
+	powVolumeThreshold   = 10 // submissions per window before difficulty rises
+)
+
+// CaptchaProvider issues and verifies one kind of challenge. Issue fills in
+// the type-specific fields; ID, nonce and expiry are set by the signer.
+type CaptchaProvider interface {
+	Issue() CaptchaChallenge
+	Verify(s *CaptchaSigner, c CaptchaChallenge, answer string) bool
+}
+
+// captchaProviders by type. Only the configured type is issued or accepted,
+// so clients can't pick the easier challenge.
+var captchaProviders = map[string]CaptchaProvider{
+	captchaPoW:        proofOfWorkCaptcha{},
+	captchaArithmetic: arithmeticCaptcha{},
+}
+
+// captchaSecretGenerated is set when no secret is configured, so main can warn
+var captchaSecretGenerated bool
+
+// captchaSecret returns the configured secret, or a random one if unset
+func captchaSecret(configured string) []byte {
+	if configured != "" {
+		return []byte(configured)
+	}
+	captchaSecretGenerated = true
+	return randomSecret()
+}
+
+func randomSecret() []byte {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return secret
+}
+
+// CaptchaSigner issues and validates HMAC-signed challenge tokens. Replicas
+// that share the secret can validate each other's tokens.
+type CaptchaSigner struct {
+	secret []byte
+	used   *usedTokenStore
+}
+
+func NewCaptchaSigner(secret []byte) *CaptchaSigner {
+	return &CaptchaSigner{
+		secret: secret,
+		used:   newUsedTokenStore(),
+	}
+}
+
+func (s *CaptchaSigner) mac(parts ...[]byte) []byte {
+	h := hmac.New(sha256.New, s.secret)
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
+
+// answerHash binds an answer to a single challenge so the hash in one token
+// can't be reused to answer another
+func (s *CaptchaSigner) answerHash(nonce, answer string) []byte {
+	return s.mac([]byte("answer:"), []byte(nonce), []byte(":"), []byte(answer))
+}
+
+// Create issues a challenge from provider and signs it into a token
+func (s *CaptchaSigner) Create(provider CaptchaProvider) CaptchaChallenge {
+	captcha := provider.Issue()
+
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	captcha.Nonce = hex.EncodeToString(nonce)
+	captcha.ExpiresAt = time.Now().Add(captchaTTL)
+	if captcha.Type == captchaArithmetic {
+		captcha.AnswerHash = s.answerHash(captcha.Nonce, strconv.Itoa(captcha.Answer))
+	}
+
+	payload, _ := json.Marshal(captchaClaims{
+		Type:       captcha.Type,
+		Nonce:      captcha.Nonce,
+		AnswerHash: captcha.AnswerHash,
+		Difficulty: captcha.Difficulty,
+		ExpiresAt:  captcha.ExpiresAt.Unix(),
+	})
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	sig := base64.RawURLEncoding.EncodeToString(s.mac([]byte(encoded)))
+	captcha.ID = encoded + "." + sig
+	captchaIssued.Inc(captcha.Type)
+	return captcha
+}
+
+// Validate checks a token's signature, expiry and type, consumes it, and
+// verifies the answer. Each token can be used once, whether or not the
+// answer is right.
+func (s *CaptchaSigner) Validate(token, captchaType, answer string) bool {
+	captcha, reason := s.validate(token, captchaType, answer)
+	if reason != "" {
+		captchaFailed.Inc(reason)
+		return false
+	}
+	captchaValidated.Inc(captcha.Type)
+	return true
+}
+
+// validate returns the decoded challenge, or the reason validation failed
+func (s *CaptchaSigner) validate(token, captchaType, answer string) (CaptchaChallenge, string) {
+	encoded, sig, ok := bytes.Cut([]byte(token), []byte("."))
+	if !ok {
+		return CaptchaChallenge{}, "malformed"
+	}
+	wantSig, err := base64.RawURLEncoding.DecodeString(string(sig))
+	if err != nil || !hmac.Equal(wantSig, s.mac(encoded)) {
+		return CaptchaChallenge{}, "signature"
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(string(encoded))
+	if err != nil {
+		return CaptchaChallenge{}, "malformed"
+	}
+	var claims captchaClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return CaptchaChallenge{}, "malformed"
+	}
+
+	// Tokens issued before the type was reconfigured no longer count
+	if claims.Type != captchaType {
+		return CaptchaChallenge{}, "type"
+	}
+
+	expiresAt := time.Unix(claims.ExpiresAt, 0)
+	if time.Now().After(expiresAt) {
+		return CaptchaChallenge{}, "expired"
+	}
+	if !s.used.markUsed(claims.Nonce, expiresAt) {
+		return CaptchaChallenge{}, "replay"
+	}
+
+	provider, ok := captchaProviders[claims.Type]
+	if !ok {
+		return CaptchaChallenge{}, "malformed"
+	}
+	captcha := CaptchaChallenge{
+		ID:         token,
+		Type:       claims.Type,
+		Nonce:      claims.Nonce,
+		AnswerHash: claims.AnswerHash,
+		Difficulty: claims.Difficulty,
+		ExpiresAt:  expiresAt,
+	}
+	if !provider.Verify(s, captcha, answer) {
+		return captcha, "answer"
+	}
+	return captcha, ""
+}
+
+// usedTokenStore remembers consumed token nonces until they expire. Nonces
+// are kept as fixed-size keys, so each entry costs a few dozen bytes and the
+// store is bounded by the issue rate times captchaTTL.
+type usedTokenStore struct {
+	mu   sync.Mutex
+	used map[[16]byte]int64 // nonce -> expiry (Unix seconds)
+}
+
+func newUsedTokenStore() *usedTokenStore {
+	return &usedTokenStore{used: make(map[[16]byte]int64)}
+}
+
+// markUsed records nonce and reports whether it was unused
+func (u *usedTokenStore) markUsed(nonce string, expiresAt time.Time) bool {
+	var key [16]byte
+	if n, err := hex.Decode(key[:], []byte(nonce)); err != nil || n != len(key) {
+		return false
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if _, seen := u.used[key]; seen {
+		return false
+	}
+	u.used[key] = expiresAt.Unix()
+	return true
+}
+
+func (u *usedTokenStore) cleanup() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	now := time.Now().Unix()
+	for key, exp := range u.used {
+		if exp < now {
+			delete(u.used, key)
+		}
+	}
+}
+
+// public returns the fields of a challenge that are safe to send to clients
+func (c CaptchaChallenge) public() map[string]interface{} {
+	resp := map[string]interface{}{
+		"id":   c.ID,
+		"type": c.Type,
+	}
+	switch c.Type {
+	case captchaArithmetic:
+		resp["question"] = c.Question
+	case captchaPoW:
+		resp["nonce"] = c.Nonce
+		resp["difficulty"] = c.Difficulty
+	}
+	return resp
+}
+
+// arithmeticCaptcha asks the user to add two small numbers
+type arithmeticCaptcha struct{}
+
+func (arithmeticCaptcha) Issue() CaptchaChallenge {
+	a, _ := rand.Int(rand.Reader, big.NewInt(20))
+	b, _ := rand.Int(rand.Reader, big.NewInt(20))
+	num1 := int(a.Int64()) + 1
+	num2 := int(b.Int64()) + 1
+
+	return CaptchaChallenge{
+		Type:     captchaArithmetic,
+		Question: fmt.Sprintf("What is %d + %d?", num1, num2),
+		Answer:   num1 + num2,
+	}
+}
+
+func (arithmeticCaptcha) Verify(s *CaptchaSigner, c CaptchaChallenge, answer string) bool {
+	n, err := strconv.Atoi(answer)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(c.AnswerHash, s.answerHash(c.Nonce, strconv.Itoa(n)))
+}
+
+// proofOfWorkCaptcha is a hashcash-style challenge: the client must find a
+// solution such that SHA-256(nonce + ":" + solution) starts with at least
+// Difficulty zero bits
+type proofOfWorkCaptcha struct{}
+
+func (proofOfWorkCaptcha) Issue() CaptchaChallenge {
+	return CaptchaChallenge{
+		Type:       captchaPoW,
+		Difficulty: powDifficulty(db.RecentSubmissionCount(powVolumeWindow)),
+	}
+}
+
+func (proofOfWorkCaptcha) Verify(_ *CaptchaSigner, c CaptchaChallenge, answer string) bool {
+	if answer == "" || len(answer) > powMaxSolutionLength {
+		return false
+	}
+	sum := sha256.Sum256([]byte(c.Nonce + ":" + answer))
+	return leadingZeroBits(sum[:]) >= c.Difficulty
+}
+
+// powDifficulty adds one bit of difficulty each time recent submission
+// volume doubles past powVolumeThreshold
+func powDifficulty(recent int) int {
+	difficulty := current().powDifficulty
+	for threshold := powVolumeThreshold; recent >= threshold && difficulty < powMaxDifficulty; threshold *= 2 {
+		difficulty++
+	}
+	return difficulty
+}
+
+func leadingZeroBits(b []byte) int {
+	n := 0
+	for _, x := range b {
+		if x != 0 {
+			return n + bits.LeadingZeros8(x)
+		}
+		n += 8
+	}
+	return n
+}
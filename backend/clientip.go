@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// clientIPHeaders are single-value headers a proxy can be configured to set.
+// One is only honoured when named in config, since a proxy that passes it
+// through unchanged would let clients pick their own address.
+var clientIPHeaders = []string{
+	"CF-Connecting-IP", // Cloudflare
+	"True-Client-IP",   // Cloudflare Enterprise / Akamai
+	"X-Real-IP",        // Nginx proxy
+}
+
+// validClientIPHeader reports whether name is one of clientIPHeaders
+func validClientIPHeader(name string) bool {
+	for _, header := range clientIPHeaders {
+		if strings.EqualFold(header, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTrustedProxies parses a comma-separated list of CIDRs or single IPs
+func parseTrustedProxies(value string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, err
+			}
+			if prefix.Addr().Is4In6() {
+				// ::ffff:10.0.0.0/104 is the same network as 10.0.0.0/8. A
+				// shorter prefix reaches outside the mapped range, and
+				// would otherwise have to become 0.0.0.0/0.
+				if prefix.Bits() < 96 {
+					return nil, fmt.Errorf("%s: an IPv4-mapped prefix must be at least /96", entry)
+				}
+				prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := parseIP(entry)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// parseIP parses an address, dropping any IPv6 zone and unmapping
+// IPv4-mapped IPv6 addresses so both forms compare equal
+func parseIP(s string) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(s))
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return addr.WithZone("").Unmap(), nil
+}
+
+func isTrustedProxy(addr netip.Addr) bool {
+	for _, prefix := range current().trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteIP returns the direct peer address of the request without its port
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		// No port present, e.g. a bare address set by a test or unix socket
+		host = strings.Trim(r.RemoteAddr, "[]")
+	}
+	if addr, err := parseIP(host); err == nil {
+		return addr.String()
+	}
+	return host
+}
+
+// getClientIP extracts the client IP from the request. Proxy headers are
+// only consulted when the direct peer is a trusted proxy, otherwise any
+// client could pick its own address and dodge per-IP rate limits. The
+// configured single-value header, if any, wins over X-Forwarded-For.
+func getClientIP(r *http.Request) string {
+	peer := remoteIP(r)
+	peerAddr, err := parseIP(peer)
+	if err != nil || !isTrustedProxy(peerAddr) {
+		return peer
+	}
+
+	if header := current().cfg.ClientIPHeader; header != "" {
+		if addr, err := parseIP(r.Header.Get(header)); err == nil {
+			return addr.String()
+		}
+	}
+	if ip, ok := forwardedForClient(r.Header.Values("X-Forwarded-For")); ok {
+		return ip
+	}
+	return peer
+}
+
+// forwardedForClient walks X-Forwarded-For from right to left, skipping
+// trusted proxies, and returns the first address that is not one of ours.
+// Entries further left were supplied by the client and cannot be trusted.
+func forwardedForClient(values []string) (string, bool) {
+	var hops []string
+	for _, value := range values {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+
+	var leftmost string
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := parseIP(hops[i])
+		if err != nil {
+			// A malformed hop means we can't tell who added what beyond here
+			break
+		}
+		if !isTrustedProxy(addr) {
+			return addr.String(), true
+		}
+		leftmost = addr.String()
+	}
+
+	// Every parsed hop was a trusted proxy; the earliest one is the best we have
+	if leftmost != "" {
+		return leftmost, true
+	}
+	return "", false
+}
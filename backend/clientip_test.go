@@ -0,0 +1,168 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// withProxies installs a default state that trusts proxies and reads header
+func withProxies(t *testing.T, header string, proxies ...string) {
+	t.Helper()
+	cfg := defaultConfig()
+	cfg.TrustedProxies = proxies
+	cfg.ClientIPHeader = header
+	prev := current()
+	state.Store(newAppState(cfg, nil))
+	t.Cleanup(func() { state.Store(prev) })
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+		err  bool
+	}{
+		{in: "", want: nil},
+		{in: "10.0.0.0/8", want: []string{"10.0.0.0/8"}},
+		{in: "10.1.2.3/8", want: []string{"10.0.0.0/8"}},
+		{in: "::ffff:10.0.0.0/104", want: []string{"10.0.0.0/8"}},
+		{in: "::ffff:0.0.0.0/96", want: []string{"0.0.0.0/0"}},
+		{in: "::ffff:0.0.0.0/80", err: true},
+		{in: "::ffff:10.0.0.0/95", err: true},
+		{in: "192.168.1.1", want: []string{"192.168.1.1/32"}},
+		{in: "::ffff:192.168.1.1", want: []string{"192.168.1.1/32"}},
+		{in: "fe80::1%eth0", want: []string{"fe80::1/128"}},
+		{in: " 10.0.0.1 , 2001:db8::/32 ,", want: []string{"10.0.0.1/32", "2001:db8::/32"}},
+		{in: "10.0.0.0/33", err: true},
+		{in: "10.0.0/8", err: true},
+		{in: "not-an-ip", err: true},
+		{in: "10.0.0.1, bogus", err: true},
+	}
+	for _, tt := range tests {
+		got, err := parseTrustedProxies(tt.in)
+		if tt.err {
+			if err == nil {
+				t.Errorf("parseTrustedProxies(%q) = %v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseTrustedProxies(%q): %v", tt.in, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("parseTrustedProxies(%q) = %v, want %v", tt.in, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i].String() != tt.want[i] {
+				t.Errorf("parseTrustedProxies(%q)[%d] = %s, want %s", tt.in, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestParseIP(t *testing.T) {
+	tests := []struct {
+		in, want string
+		err      bool
+	}{
+		{in: "203.0.113.7", want: "203.0.113.7"},
+		{in: " 203.0.113.7 ", want: "203.0.113.7"},
+		{in: "2001:db8::1", want: "2001:db8::1"},
+		{in: "2001:DB8:0:0::1", want: "2001:db8::1"},
+		{in: "fe80::1%eth0", want: "fe80::1"},
+		{in: "::ffff:203.0.113.7", want: "203.0.113.7"},
+		{in: "::ffff:cb00:7107", want: "203.0.113.7"},
+		{in: "::1", want: "::1"},
+		{in: "", err: true},
+		{in: "203.0.113.7:8080", err: true},
+		{in: "[2001:db8::1]", err: true},
+		{in: "unknown", err: true},
+	}
+	for _, tt := range tests {
+		got, err := parseIP(tt.in)
+		switch {
+		case tt.err && err == nil:
+			t.Errorf("parseIP(%q) = %s, want an error", tt.in, got)
+		case !tt.err && err != nil:
+			t.Errorf("parseIP(%q): %v", tt.in, err)
+		case !tt.err && got.String() != tt.want:
+			t.Errorf("parseIP(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestForwardedForClient(t *testing.T) {
+	withProxies(t, "", "10.0.0.0/8", "2001:db8::/32")
+	tests := []struct {
+		name   string
+		values []string
+		want   string
+		ok     bool
+	}{
+		{"single client", []string{"203.0.113.7"}, "203.0.113.7", true},
+		{"rightmost untrusted wins", []string{"198.51.100.1, 203.0.113.7, 10.0.0.2"}, "203.0.113.7", true},
+		{"spoofed left entries ignored", []string{"1.2.3.4, 203.0.113.7, 10.0.0.1, 10.0.0.2"}, "203.0.113.7", true},
+		{"all hops trusted", []string{"10.0.0.5, 10.0.0.1"}, "10.0.0.5", true},
+		{"ipv6 client behind ipv6 proxy", []string{"2001:db9::7, 2001:db8::1"}, "2001:db9::7", true},
+		{"mapped client", []string{"::ffff:203.0.113.7, 10.0.0.1"}, "203.0.113.7", true},
+		{"mapped proxy is trusted", []string{"203.0.113.7, ::ffff:10.0.0.1"}, "203.0.113.7", true},
+		{"malformed hop stops the walk", []string{"203.0.113.7, garbage, 10.0.0.1"}, "10.0.0.1", true},
+		{"malformed rightmost hop", []string{"203.0.113.7, garbage"}, "", false},
+		{"multiple header values", []string{"198.51.100.1", "203.0.113.7, 10.0.0.1"}, "203.0.113.7", true},
+		{"client in earlier header value", []string{"203.0.113.7", "10.0.0.2, 10.0.0.1"}, "203.0.113.7", true},
+		{"empty", nil, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := forwardedForClient(tt.values)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: forwardedForClient(%q) = %q, %v; want %q, %v", tt.name, tt.values, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestGetClientIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string // configured single-value header
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{"untrusted peer ignores headers", "", "203.0.113.9:1234",
+			map[string]string{"X-Forwarded-For": "1.2.3.4"}, "203.0.113.9"},
+		{"untrusted peer ignores configured header", "X-Real-IP", "203.0.113.9:1234",
+			map[string]string{"X-Real-IP": "1.2.3.4"}, "203.0.113.9"},
+		{"remote without port", "", "203.0.113.9", nil, "203.0.113.9"},
+		{"bracketed ipv6 without port", "", "[2001:db9::1]", nil, "2001:db9::1"},
+		{"ipv6 remote with zone", "", "[fe80::1%eth0]:1234", nil, "fe80::1"},
+		{"mapped remote", "", "[::ffff:203.0.113.9]:1234", nil, "203.0.113.9"},
+		{"trusted peer uses forwarded-for", "", "10.0.0.1:1234",
+			map[string]string{"X-Forwarded-For": "203.0.113.7"}, "203.0.113.7"},
+		{"single-value headers are opt-in", "", "10.0.0.1:1234",
+			map[string]string{"X-Real-IP": "1.2.3.4", "CF-Connecting-IP": "5.6.7.8", "X-Forwarded-For": "203.0.113.7"}, "203.0.113.7"},
+		{"configured header wins", "CF-Connecting-IP", "10.0.0.1:1234",
+			map[string]string{"CF-Connecting-IP": "198.51.100.4", "X-Forwarded-For": "203.0.113.7"}, "198.51.100.4"},
+		{"other single-value headers ignored", "X-Real-IP", "10.0.0.1:1234",
+			map[string]string{"True-Client-IP": "1.2.3.4", "X-Forwarded-For": "203.0.113.7"}, "203.0.113.7"},
+		{"malformed configured header falls back", "X-Real-IP", "10.0.0.1:1234",
+			map[string]string{"X-Real-IP": "nope", "X-Forwarded-For": "203.0.113.7"}, "203.0.113.7"},
+		{"trusted peer without headers", "", "10.0.0.1:1234", nil, "10.0.0.1"},
+		{"trusted peer with malformed forwarded-for", "", "10.0.0.1:1234",
+			map[string]string{"X-Forwarded-For": "garbage"}, "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withProxies(t, tt.header, "10.0.0.0/8")
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			if got := getClientIP(r); got != tt.want {
+				t.Errorf("getClientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Bounds on a comparison group; the compare page shows at most four
+// algorithms side by side
+const (
+	minComparisonAlgorithms = 2
+	maxComparisonAlgorithms = 4
+	maxComparisonIDLength   = 64
+)
+
+var comparisonIDPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+var errComparisonNotFound = errors.New("comparison group not found")
+var errComparisonExists = errors.New("comparison group already exists")
+var errInvalidComparison = errors.New("invalid comparison group")
+
+// ComparisonGroup is a curated side-by-side comparison of algorithms
+type ComparisonGroup struct {
+	ID             string          `json:"id"`
+	Name           string          `json:"name"`
+	Description    string          `json:"description"`
+	Algorithms     []string        `json:"algorithms"`
+	KeyDifferences []KeyDifference `json:"keyDifferences"`
+}
+
+// KeyDifference contrasts the algorithms in a group on one aspect
+type KeyDifference struct {
+	Aspect string            `json:"aspect"`
+	Values map[string]string `json:"values"` // algorithm ID -> how it behaves
+}
+
+// validateComparisonGroup checks a group's fields against the configured
+// limits and that every algorithm it references exists
+func validateComparisonGroup(g *ComparisonGroup, exists func(id string) bool) error {
+	limits := current().cfg.Limits
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if len(g.ID) > maxComparisonIDLength || !comparisonIDPattern.MatchString(g.ID) {
+		fail("id: %q must be lowercase letters, digits and dashes", g.ID)
+	}
+	if strings.TrimSpace(g.Name) == "" {
+		fail("name: must not be empty")
+	} else if len(g.Name) > limits.MaxNameLength {
+		fail("name: exceeds maximum length")
+	}
+	if len(g.Description) > limits.MaxDescriptionLength {
+		fail("description: exceeds maximum length")
+	}
+
+	if n := len(g.Algorithms); n < minComparisonAlgorithms || n > maxComparisonAlgorithms {
+		fail("algorithms: must list between %d and %d algorithms", minComparisonAlgorithms, maxComparisonAlgorithms)
+	}
+	members := make(map[string]bool, len(g.Algorithms))
+	for _, id := range g.Algorithms {
+		switch {
+		case members[id]:
+			fail("algorithms: %q is listed twice", id)
+		case !exists(id):
+			fail("algorithms: unknown algorithm %q", id)
+		}
+		members[id] = true
+	}
+
+	if len(g.KeyDifferences) > limits.MaxArrayLength {
+		fail("keyDifferences: too many entries")
+	}
+	for i, diff := range g.KeyDifferences {
+		if strings.TrimSpace(diff.Aspect) == "" {
+			fail("keyDifferences[%d]: aspect must not be empty", i)
+		}
+		for id, value := range diff.Values {
+			if !members[id] {
+				fail("keyDifferences[%d]: %q is not in the group", i, id)
+			}
+			if len(value) > limits.MaxDescriptionLength {
+				fail("keyDifferences[%d]: value for %q exceeds maximum length", i, id)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w:\n%w", errInvalidComparison, errors.Join(errs...))
+	}
+	return nil
+}
+
+// approvedIDSetUnlocked returns the IDs of published algorithms. Callers must
+// hold d.mu.
+func (d *Database) approvedIDSetUnlocked() map[string]bool {
+	ids := make(map[string]bool, len(d.Algorithms))
+	for _, algo := range d.Algorithms {
+		if algo.Approved {
+			ids[algo.ID] = true
+		}
+	}
+	return ids
+}
+
+func (d *Database) comparisonIndexUnlocked(id string) int {
+	for i := range d.ComparisonGroups {
+		if d.ComparisonGroups[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// GetComparisonGroups returns the groups, limited to those that include
+// algorithmID when it is set
+func (d *Database) GetComparisonGroups(algorithmID string) []ComparisonGroup {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	groups := make([]ComparisonGroup, 0, len(d.ComparisonGroups))
+	for _, g := range d.ComparisonGroups {
+		if algorithmID == "" || containsString(g.Algorithms, algorithmID) {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+func (d *Database) GetComparisonGroup(id string) *ComparisonGroup {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if i := d.comparisonIndexUnlocked(id); i >= 0 {
+		g := d.ComparisonGroups[i]
+		return &g
+	}
+	return nil
+}
+
+// CreateComparisonGroup validates and adds a new group
+func (d *Database) CreateComparisonGroup(g ComparisonGroup) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	approved := d.approvedIDSetUnlocked()
+	if err := validateComparisonGroup(&g, func(id string) bool { return approved[id] }); err != nil {
+		return err
+	}
+	if d.comparisonIndexUnlocked(g.ID) >= 0 {
+		return errComparisonExists
+	}
+
+	d.ComparisonGroups = append(d.ComparisonGroups, g)
+	d.markComparisonEdited(g.ID)
+	d.catalogChanged()
+	return d.saveUnlocked()
+}
+
+// UpdateComparisonGroup validates g and replaces the group with its ID
+func (d *Database) UpdateComparisonGroup(g ComparisonGroup) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	i := d.comparisonIndexUnlocked(g.ID)
+	if i < 0 {
+		return errComparisonNotFound
+	}
+	approved := d.approvedIDSetUnlocked()
+	if err := validateComparisonGroup(&g, func(id string) bool { return approved[id] }); err != nil {
+		return err
+	}
+
+	d.ComparisonGroups[i] = g
+	d.markComparisonEdited(g.ID)
+	d.catalogChanged()
+	return d.saveUnlocked()
+}
+
+func (d *Database) DeleteComparisonGroup(id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	i := d.comparisonIndexUnlocked(id)
+	if i < 0 {
+		return errComparisonNotFound
+	}
+
+	d.ComparisonGroups = append(d.ComparisonGroups[:i], d.ComparisonGroups[i+1:]...)
+	d.markComparisonEdited(id)
+	d.catalogChanged()
+	return d.saveUnlocked()
+}
+
+// markComparisonEdited keeps seed merges from undoing an admin's change to
+// the group. Callers must hold d.mu.
+func (d *Database) markComparisonEdited(id string) {
+	if !containsString(d.EditedComparisons, id) {
+		d.EditedComparisons = append(d.EditedComparisons, id)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func handleComparisons(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	respondJSON(w, db.GetComparisonGroups(r.URL.Query().Get("algorithm")))
+}
+
+func handleComparisonByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/comparisons/")
+	if id == "" {
+		http.Error(w, "Comparison group ID required", http.StatusBadRequest)
+		return
+	}
+
+	g := db.GetComparisonGroup(id)
+	if g == nil {
+		http.Error(w, errComparisonNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	respondJSON(w, g)
+}
+
+// decodeComparisonGroup reads a group from the request body
+func decodeComparisonGroup(w http.ResponseWriter, r *http.Request) (ComparisonGroup, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, 64<<10)
+
+	var g ComparisonGroup
+	if err := json.NewDecoder(r.Body).Decode(&g); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return g, false
+	}
+	if g.KeyDifferences == nil {
+		g.KeyDifferences = []KeyDifference{}
+	}
+	return g, true
+}
+
+// handleAdminComparisons creates a group. The ID defaults to a slug of the name.
+func handleAdminComparisons(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	g, ok := decodeComparisonGroup(w, r)
+	if !ok {
+		return
+	}
+	if g.ID == "" {
+		g.ID = generateSlug(g.Name)
+	}
+
+	if err := db.CreateComparisonGroup(g); err != nil {
+		respondComparisonError(w, r, g.ID, err)
+		return
+	}
+	recordAudit(r, auditComparisonCreate, "", g.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(g)
+}
+
+// handleAdminComparisonByID replaces (PUT) or deletes (DELETE) a group
+func handleAdminComparisonByID(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/admin/comparisons/")
+	if id == "" {
+		http.Error(w, "Comparison group ID required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		g, ok := decodeComparisonGroup(w, r)
+		if !ok {
+			return
+		}
+		if g.ID != "" && g.ID != id {
+			http.Error(w, "id in body does not match the URL", http.StatusBadRequest)
+			return
+		}
+		g.ID = id
+
+		if err := db.UpdateComparisonGroup(g); err != nil {
+			respondComparisonError(w, r, id, err)
+			return
+		}
+		recordAudit(r, auditComparisonUpdate, id, id)
+		respondJSON(w, g)
+
+	case http.MethodDelete:
+		if err := db.DeleteComparisonGroup(id); err != nil {
+			respondComparisonError(w, r, id, err)
+			return
+		}
+		recordAudit(r, auditComparisonDelete, id, "")
+		respondJSON(w, map[string]string{"message": "Comparison group deleted"})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// respondComparisonError maps a comparison group error to a response.
+// Anything that isn't a lookup or validation failure came from saving.
+func respondComparisonError(w http.ResponseWriter, r *http.Request, id string, err error) {
+	switch {
+	case errors.Is(err, errComparisonNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, errComparisonExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, errInvalidComparison):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		requestLogger(r).Error("Failed to save comparison group", "comparison_id", id, "err", err)
+		http.Error(w, "Failed to save comparison group", http.StatusInternalServerError)
+	}
+}
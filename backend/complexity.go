@@ -0,0 +1,1004 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Complexity classes, from cheapest to most expensive. Multivariate bounds
+// are classed by their total degree, so O(V * E) is quadratic.
+const (
+	classConstant     = "constant"
+	classLogarithmic  = "logarithmic"
+	classSublinear    = "sublinear"
+	classLinear       = "linear"
+	classLinearithmic = "linearithmic"
+	classQuadratic    = "quadratic"
+	classCubic        = "cubic"
+	classPolynomial   = "polynomial"
+	classExponential  = "exponential"
+	classFactorial    = "factorial"
+	classUnknown      = "unknown"
+)
+
+var complexityClasses = []string{
+	classConstant, classLogarithmic, classSublinear, classLinear, classLinearithmic,
+	classQuadratic, classCubic, classPolynomial, classExponential, classFactorial, classUnknown,
+}
+
+// complexityRank orders classes; unknown sorts last
+var complexityRank = func() map[string]int {
+	rank := make(map[string]int, len(complexityClasses))
+	for i, class := range complexityClasses {
+		rank[class] = i
+	}
+	return rank
+}()
+
+// complexityQualifiers maps phrases in the free text to qualifiers, longest
+// phrases first so "worst case" wins over "worst"
+var complexityQualifiers = []struct {
+	phrase    string
+	qualifier string
+}{
+	{"worst-case", "worst-case"},
+	{"worst case", "worst-case"},
+	{"worst", "worst-case"},
+	{"average-case", "average-case"},
+	{"average case", "average-case"},
+	{"average", "average-case"},
+	{"expected", "average-case"},
+	{"best-case", "best-case"},
+	{"best case", "best-case"},
+	{"best", "best-case"},
+	{"amortized", "amortized"},
+	{"per operation", "per-operation"},
+	{"each operation", "per-operation"},
+	{"auxiliary", "auxiliary"},
+}
+
+// ComplexityBound is one big-O bound parsed from the free text
+type ComplexityBound struct {
+	Expression string   `json:"expression"` // normalized, without the O( )
+	Dominant   string   `json:"dominant"`   // the fastest-growing terms
+	Variables  []string `json:"variables"`
+	Class      string   `json:"class"`
+	Qualifiers []string `json:"qualifiers,omitempty"` // worst-case, amortized, ...
+	Note       string   `json:"note,omitempty"`       // remaining free text
+}
+
+// ParsedComplexity is a complexity string broken into bounds. Class comes
+// from the worst-case bound if there is one, else the first.
+type ParsedComplexity struct {
+	Raw    string            `json:"raw"`
+	Class  string            `json:"class"`
+	Bounds []ComplexityBound `json:"bounds"`
+}
+
+var (
+	errNoBigO     = errors.New("expected big-O notation such as O(n log n)")
+	errTooComplex = errors.New("expression is too complex")
+	errTooDeep    = errors.New("expression is nested too deeply")
+)
+
+// Bounds on parsing, which expands products into sums of terms: a
+// submitted string can't make it take long or use much memory
+const (
+	maxComplexityLength = 200
+	maxComplexityDepth  = 20  // nested powers, functions and parentheses
+	maxMonomials        = 256 // terms in an expansion
+	maxCachedParses     = 1024
+)
+
+// complexityCache memoizes parses of catalog strings, which rarely change.
+// It is emptied when full, so edits can't grow it forever.
+var complexityCache = struct {
+	sync.Mutex
+	parses map[string]*ParsedComplexity
+}{parses: make(map[string]*ParsedComplexity)}
+
+// parseComplexityCached returns the parse of s, or an unknown class if it
+// doesn't parse
+func parseComplexityCached(s string) *ParsedComplexity {
+	complexityCache.Lock()
+	cached, ok := complexityCache.parses[s]
+	complexityCache.Unlock()
+	if ok {
+		return cached
+	}
+	parsed, err := parseComplexity(s)
+	if err != nil {
+		parsed = &ParsedComplexity{Raw: s, Class: classUnknown, Bounds: []ComplexityBound{}}
+	}
+	complexityCache.Lock()
+	if len(complexityCache.parses) >= maxCachedParses {
+		clear(complexityCache.parses)
+	}
+	complexityCache.parses[s] = parsed
+	complexityCache.Unlock()
+	return parsed
+}
+
+// parseComplexity finds every O(...) in s. Text after a bound, up to the
+// next one, is read for qualifiers and kept as its note.
+func parseComplexity(s string) (*ParsedComplexity, error) {
+	parsed := &ParsedComplexity{Raw: s, Class: classUnknown, Bounds: []ComplexityBound{}}
+
+	type span struct{ start, open, end int } // O, its "(", and one past ")"
+	var spans []span
+	for i := 0; i < len(s); i++ {
+		if s[i] != 'O' || (i > 0 && isIdentRune(rune(s[i-1]))) {
+			continue
+		}
+		open := i + 1
+		for open < len(s) && s[open] == ' ' {
+			open++
+		}
+		if open >= len(s) || s[open] != '(' {
+			continue
+		}
+		depth, end := 0, -1
+		for j := open; j < len(s) && end < 0; j++ {
+			switch s[j] {
+			case '(':
+				depth++
+			case ')':
+				if depth--; depth == 0 {
+					end = j + 1
+				}
+			}
+		}
+		if end < 0 {
+			return nil, fmt.Errorf("unbalanced parentheses in %q", s[i:])
+		}
+		spans = append(spans, span{i, open, end})
+		i = end - 1
+	}
+	if len(spans) == 0 {
+		return nil, errNoBigO
+	}
+
+	for n, sp := range spans {
+		bound, err := parseBound(s[sp.open+1 : sp.end-1])
+		if err != nil {
+			return nil, fmt.Errorf("O(%s): %w", s[sp.open+1:sp.end-1], err)
+		}
+		rest := s[sp.end:]
+		if n+1 < len(spans) {
+			rest = s[sp.end:spans[n+1].start]
+		}
+		bound.Qualifiers, bound.Note = complexityNote(rest)
+		parsed.Bounds = append(parsed.Bounds, bound)
+	}
+
+	parsed.Class = parsed.Bounds[0].Class
+	for _, bound := range parsed.Bounds {
+		if containsString(bound.Qualifiers, "worst-case") {
+			parsed.Class = bound.Class
+			break
+		}
+	}
+	return parsed, nil
+}
+
+// complexityNote pulls qualifiers out of the text following a bound
+func complexityNote(text string) ([]string, string) {
+	qualifiers := make([]string, 0)
+	note := " " + strings.ToLower(text) + " "
+	original := " " + text + " "
+	for _, q := range complexityQualifiers {
+		i := indexWord(note, q.phrase)
+		if i < 0 {
+			continue
+		}
+		if !containsString(qualifiers, q.qualifier) {
+			qualifiers = append(qualifiers, q.qualifier)
+		}
+		note = note[:i] + note[i+len(q.phrase):]
+		original = original[:i] + original[i+len(q.phrase):]
+	}
+	words := strings.Fields(strings.TrimFunc(original, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(",;:-–≈", r)
+	}))
+	// "O(1) or O(k)" leaves a dangling "or" between the bounds
+	if n := len(words); n > 0 && (words[n-1] == "or" || words[n-1] == "and") {
+		words = words[:n-1]
+	}
+	return qualifiers, strings.Join(words, " ")
+}
+
+// indexWord finds phrase in s at word boundaries, or returns -1
+func indexWord(s, phrase string) int {
+	for offset := 0; ; {
+		i := strings.Index(s[offset:], phrase)
+		if i < 0 {
+			return -1
+		}
+		i += offset
+		before, after := i > 0 && isIdentRune(rune(s[i-1])), i+len(phrase) < len(s) && isIdentRune(rune(s[i+len(phrase)]))
+		if !before && !after {
+			return i
+		}
+		offset = i + 1
+	}
+}
+
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// Expression tokens
+type cxToken struct {
+	kind byte // 'n' number, 'i' identifier, or the operator/paren itself
+	text string
+}
+
+// cxFunctions are applied to the following factor, with or without parens.
+// α is the inverse Ackermann function, constant for practical purposes.
+var cxFunctions = map[string]bool{"log": true, "lg": true, "ln": true, "log2": true, "sqrt": true, "min": true, "max": true, "α": true}
+
+var superscripts = map[rune]string{'⁰': "0", '¹': "1", '²': "2", '³': "3", '⁴': "4", '⁵': "5", '⁶': "6", '⁷': "7", '⁸': "8", '⁹': "9", 'ⁿ': "n"}
+
+func lexComplexity(expr string) ([]cxToken, error) {
+	var tokens []cxToken
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case superscripts[r] != "":
+			j := i
+			var power strings.Builder
+			for j < len(runes) && superscripts[runes[j]] != "" {
+				power.WriteString(superscripts[runes[j]])
+				j++
+			}
+			kind := byte('n')
+			if _, err := strconv.Atoi(power.String()); err != nil {
+				kind = 'i'
+			}
+			tokens = append(tokens, cxToken{'^', "^"}, cxToken{kind, power.String()})
+			i = j
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, cxToken{'n', string(runes[i:j])})
+			i = j
+		case isIdentRune(r):
+			j := i
+			for j < len(runes) && isIdentRune(runes[j]) && superscripts[runes[j]] == "" {
+				j++
+			}
+			tokens = append(tokens, cxToken{'i', string(runes[i:j])})
+			i = j
+		case strings.ContainsRune("+-*/^!(),", r):
+			tokens = append(tokens, cxToken{byte(r), string(r)})
+			i++
+		case r == '·' || r == '×':
+			tokens = append(tokens, cxToken{'*', "*"})
+			i++
+		case r == '√':
+			tokens = append(tokens, cxToken{'i', "sqrt"})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected %q", r)
+		}
+	}
+
+	// Runs of words such as "number of unique states" name one quantity
+	merged := make([]cxToken, 0, len(tokens))
+	for _, tok := range tokens {
+		if n := len(merged); n > 0 && tok.kind == 'i' && isWord(tok.text) && merged[n-1].kind == 'i' && isWord(merged[n-1].text) {
+			merged[n-1].text += " " + tok.text
+			continue
+		}
+		merged = append(merged, tok)
+	}
+	return merged, nil
+}
+
+// isWord reports whether an identifier reads as an English word rather than
+// a variable (n, V, h) or a function
+func isWord(s string) bool {
+	return len([]rune(s)) > 1 && !cxFunctions[s] && !strings.Contains(s, "_")
+}
+
+// cxNode is a parsed expression
+type cxNode struct {
+	op       string // "num", "var", "func", "+", "-", "*", "/", "^", "!"
+	text     string // number, variable or function name
+	implicit bool   // "*" written as juxtaposition, as in "n log n"
+	args     []*cxNode
+}
+
+type cxParser struct {
+	tokens []cxToken
+	pos    int
+	depth  int
+}
+
+func (p *cxParser) peek() byte {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos].kind
+	}
+	return 0
+}
+
+func (p *cxParser) next() cxToken {
+	tok := p.tokens[p.pos]
+	p.pos++
+	return tok
+}
+
+func (p *cxParser) expect(kind byte) error {
+	if p.peek() != kind {
+		return fmt.Errorf("expected %q", kind)
+	}
+	p.pos++
+	return nil
+}
+
+// expr := term (("+" | "-") term)*
+func (p *cxParser) expr() (*cxNode, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == '+' || p.peek() == '-' {
+		op := string(p.next().kind)
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		left = &cxNode{op: op, args: []*cxNode{left, right}}
+	}
+	return left, nil
+}
+
+// term := power (("*" | "/")? power)*
+func (p *cxParser) term() (*cxNode, error) {
+	left, err := p.power()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch kind := p.peek(); kind {
+		case '*', '/':
+			p.next()
+			right, err := p.power()
+			if err != nil {
+				return nil, err
+			}
+			left = &cxNode{op: string(kind), args: []*cxNode{left, right}}
+		case 'n', 'i', '(':
+			right, err := p.power()
+			if err != nil {
+				return nil, err
+			}
+			left = &cxNode{op: "*", implicit: true, args: []*cxNode{left, right}}
+		default:
+			return left, nil
+		}
+	}
+}
+
+// power := atom "!"* ("^" power)?
+func (p *cxParser) power() (*cxNode, error) {
+	// Every nested expression passes through here
+	if p.depth++; p.depth > maxComplexityDepth {
+		return nil, errTooDeep
+	}
+	defer func() { p.depth-- }()
+	base, err := p.atom()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == '!' {
+		p.next()
+		base = &cxNode{op: "!", args: []*cxNode{base}}
+	}
+	if p.peek() != '^' {
+		return base, nil
+	}
+	p.next()
+	exponent, err := p.power()
+	if err != nil {
+		return nil, err
+	}
+	return &cxNode{op: "^", args: []*cxNode{base, exponent}}, nil
+}
+
+// atom := number | variable | function ("(" args ")" | atom) | "(" expr ")"
+func (p *cxParser) atom() (*cxNode, error) {
+	switch p.peek() {
+	case 'n':
+		return &cxNode{op: "num", text: p.next().text}, nil
+	case 'i':
+		name := p.next().text
+		if !cxFunctions[name] {
+			return &cxNode{op: "var", text: name}, nil
+		}
+		if p.peek() != '(' {
+			arg, err := p.power()
+			if err != nil {
+				return nil, err
+			}
+			return &cxNode{op: "func", text: name, args: []*cxNode{arg}}, nil
+		}
+		p.next()
+		var args []*cxNode
+		for {
+			arg, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.peek() != ',' {
+				break
+			}
+			p.next()
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		return &cxNode{op: "func", text: name, args: args}, nil
+	case '(':
+		p.next()
+		inner, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		return &cxNode{op: "()", args: []*cxNode{inner}}, nil
+	case 0:
+		return nil, errors.New("unexpected end of expression")
+	default:
+		return nil, fmt.Errorf("unexpected %q", p.next().text)
+	}
+}
+
+// String renders the expression with normalized spacing
+func (n *cxNode) String() string {
+	switch n.op {
+	case "num", "var":
+		return n.text
+	case "()":
+		return "(" + n.args[0].String() + ")"
+	case "func":
+		args := make([]string, len(n.args))
+		for i, arg := range n.args {
+			args[i] = arg.String()
+		}
+		if len(n.args) == 1 && (n.args[0].op == "var" || n.args[0].op == "num") && n.text != "α" {
+			return n.text + " " + args[0]
+		}
+		return n.text + "(" + strings.Join(args, ", ") + ")"
+	case "!":
+		return n.args[0].String() + "!"
+	case "^":
+		return n.args[0].String() + "^" + n.args[1].String()
+	case "*":
+		if n.implicit {
+			return n.args[0].String() + " " + n.args[1].String()
+		}
+		return n.args[0].String() + " * " + n.args[1].String()
+	default:
+		return n.args[0].String() + " " + n.op + " " + n.args[1].String()
+	}
+}
+
+// monomial is one product term of an expanded expression. Only growth
+// matters, so coefficients are dropped.
+type monomial struct {
+	degree map[string]float64 // variable -> power
+	logs   []string           // logarithmic factors, e.g. "log V"
+	exps   []string           // exponential factors, e.g. "2^n"
+	facts  []string           // factorial factors, e.g. "n!"
+}
+
+func (m monomial) totalDegree() float64 {
+	total := 0.0
+	for _, d := range m.degree {
+		total += d
+	}
+	return total
+}
+
+// grows compares growth: factorial, then exponential, then degree, then logs
+func (m monomial) grows(o monomial) int {
+	keys := [][2]float64{
+		{float64(len(m.facts)), float64(len(o.facts))},
+		{float64(len(m.exps)), float64(len(o.exps))},
+		{m.totalDegree(), o.totalDegree()},
+		{float64(len(m.logs)), float64(len(o.logs))},
+	}
+	for _, k := range keys {
+		if k[0] != k[1] {
+			if k[0] > k[1] {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
+
+func (m monomial) class() string {
+	switch d := m.totalDegree(); {
+	case len(m.facts) > 0:
+		return classFactorial
+	case len(m.exps) > 0:
+		return classExponential
+	case d == 0 && len(m.logs) == 0:
+		return classConstant
+	case d == 0:
+		return classLogarithmic
+	case d < 1:
+		return classSublinear
+	case d == 1 && len(m.logs) == 0:
+		return classLinear
+	case d == 1:
+		return classLinearithmic
+	case d == 2:
+		return classQuadratic
+	case d == 3:
+		return classCubic
+	default:
+		return classPolynomial
+	}
+}
+
+func (m monomial) String() string {
+	vars := make([]string, 0, len(m.degree))
+	for v := range m.degree {
+		vars = append(vars, v)
+	}
+	sort.Strings(vars)
+
+	var parts []string
+	for _, v := range vars {
+		d := m.degree[v]
+		if strings.Contains(v, " ") && !strings.HasSuffix(v, ")") {
+			v = "(" + v + ")"
+		}
+		switch {
+		case d == 1:
+			parts = append(parts, v)
+		case d == math.Trunc(d):
+			parts = append(parts, fmt.Sprintf("%s^%d", v, int(d)))
+		default:
+			parts = append(parts, fmt.Sprintf("%s^%g", v, d))
+		}
+	}
+	parts = append(parts, m.logs...)
+	parts = append(parts, m.exps...)
+	parts = append(parts, m.facts...)
+	if len(parts) == 0 {
+		return "1"
+	}
+	return strings.Join(parts, " ")
+}
+
+func constantPoly() []monomial {
+	return []monomial{{degree: map[string]float64{}}}
+}
+
+// multiply expands the product of two sums, failing once it has more than
+// maxMonomials terms
+func multiply(a, b []monomial) ([]monomial, error) {
+	if len(a)*len(b) > maxMonomials {
+		return nil, errTooComplex
+	}
+	product := make([]monomial, 0, len(a)*len(b))
+	for _, x := range a {
+		for _, y := range b {
+			m := monomial{degree: make(map[string]float64)}
+			for v, d := range x.degree {
+				m.degree[v] += d
+			}
+			for v, d := range y.degree {
+				m.degree[v] += d
+			}
+			for v, d := range m.degree {
+				if d == 0 {
+					delete(m.degree, v)
+				}
+			}
+			m.logs = append(append(m.logs, x.logs...), y.logs...)
+			m.exps = append(append(m.exps, x.exps...), y.exps...)
+			m.facts = append(append(m.facts, x.facts...), y.facts...)
+			product = append(product, m)
+		}
+	}
+	return product, nil
+}
+
+// expand turns an expression into a sum of monomials, collecting the
+// variables it mentions
+func expand(n *cxNode, vars map[string]bool) ([]monomial, error) {
+	// both expands two operands
+	both := func() ([]monomial, []monomial, error) {
+		left, err := expand(n.args[0], vars)
+		if err != nil {
+			return nil, nil, err
+		}
+		right, err := expand(n.args[1], vars)
+		return left, right, err
+	}
+	// mentions collects the variables in args without expanding them
+	mentions := func(args []*cxNode, into map[string]bool) error {
+		for _, arg := range args {
+			if _, err := expand(arg, into); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	switch n.op {
+	case "num":
+		return constantPoly(), nil
+	case "var":
+		vars[n.text] = true
+		return []monomial{{degree: map[string]float64{n.text: 1}}}, nil
+	case "()":
+		return expand(n.args[0], vars)
+	case "+", "-":
+		left, right, err := both()
+		if err != nil {
+			return nil, err
+		}
+		if len(left)+len(right) > maxMonomials {
+			return nil, errTooComplex
+		}
+		return append(left, right...), nil
+	case "*":
+		left, right, err := both()
+		if err != nil {
+			return nil, err
+		}
+		return multiply(left, right)
+	case "/":
+		left, right, err := both()
+		if err != nil {
+			return nil, err
+		}
+		if len(right) != 1 || len(right[0].logs)+len(right[0].exps)+len(right[0].facts) > 0 {
+			return left, nil // only simple divisors are understood
+		}
+		inverse := monomial{degree: make(map[string]float64)}
+		for v, d := range right[0].degree {
+			inverse.degree[v] = -d
+		}
+		return multiply(left, []monomial{inverse})
+	case "^":
+		base, err := expand(n.args[0], vars)
+		if err != nil {
+			return nil, err
+		}
+		exponentVars := make(map[string]bool)
+		if err := mentions(n.args[1:], exponentVars); err != nil {
+			return nil, err
+		}
+		for v := range exponentVars {
+			vars[v] = true
+		}
+		if len(exponentVars) > 0 {
+			return []monomial{{degree: map[string]float64{}, exps: []string{n.String()}}}, nil
+		}
+		k, err := strconv.ParseFloat(n.args[1].String(), 64)
+		if err != nil {
+			return base, nil
+		}
+		if len(base) == 1 {
+			m := monomial{degree: make(map[string]float64), logs: base[0].logs, exps: base[0].exps, facts: base[0].facts}
+			for v, d := range base[0].degree {
+				m.degree[v] = d * k
+			}
+			return []monomial{m}, nil
+		}
+		result := constantPoly()
+		for i := 0; i < int(k) && i < 5; i++ {
+			if result, err = multiply(result, base); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	case "!":
+		if err := mentions(n.args, vars); err != nil {
+			return nil, err
+		}
+		return []monomial{{degree: map[string]float64{}, facts: []string{n.String()}}}, nil
+	case "func":
+		switch n.text {
+		case "α":
+			if err := mentions(n.args, vars); err != nil {
+				return nil, err
+			}
+			return constantPoly(), nil
+		case "sqrt":
+			return expand(&cxNode{op: "^", args: []*cxNode{n.args[0], {op: "num", text: "0.5"}}}, vars)
+		case "max":
+			var sum []monomial
+			for _, arg := range n.args {
+				terms, err := expand(arg, vars)
+				if err != nil {
+					return nil, err
+				}
+				if sum = append(sum, terms...); len(sum) > maxMonomials {
+					return nil, errTooComplex
+				}
+			}
+			return sum, nil
+		case "min":
+			// Bounded by any one argument; keep it as a single quantity
+			if err := mentions(n.args, vars); err != nil {
+				return nil, err
+			}
+			return []monomial{{degree: map[string]float64{n.String(): 1}}}, nil
+		default: // logarithms
+			if err := mentions(n.args, vars); err != nil {
+				return nil, err
+			}
+			return []monomial{{degree: map[string]float64{}, logs: []string{n.String()}}}, nil
+		}
+	}
+	return constantPoly(), nil
+}
+
+// parseBound parses the inside of one O( )
+func parseBound(expr string) (ComplexityBound, error) {
+	tokens, err := lexComplexity(expr)
+	if err != nil {
+		return ComplexityBound{}, err
+	}
+	p := &cxParser{tokens: tokens}
+	root, err := p.expr()
+	if err != nil {
+		return ComplexityBound{}, err
+	}
+	if p.pos < len(p.tokens) {
+		return ComplexityBound{}, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+
+	vars := make(map[string]bool)
+	terms, err := expand(root, vars)
+	if err != nil {
+		return ComplexityBound{}, err
+	}
+
+	// Keep the fastest-growing terms, once each
+	var dominant []monomial
+	for _, m := range terms {
+		switch {
+		case len(dominant) == 0 || m.grows(dominant[0]) > 0:
+			dominant = []monomial{m}
+		case m.grows(dominant[0]) == 0:
+			dominant = append(dominant, m)
+		}
+	}
+	seen := make(map[string]bool)
+	var rendered []string
+	for _, m := range dominant {
+		if s := m.String(); !seen[s] {
+			seen[s] = true
+			rendered = append(rendered, s)
+		}
+	}
+
+	variables := make([]string, 0, len(vars))
+	for v := range vars {
+		variables = append(variables, v)
+	}
+	sort.Strings(variables)
+
+	return ComplexityBound{
+		Expression: root.String(),
+		Dominant:   strings.Join(rendered, " + "),
+		Variables:  variables,
+		Class:      dominant[0].class(),
+	}, nil
+}
+
+// validateComplexity checks that a submitted complexity, if given, is in
+// big-O notation
+func validateComplexity(c Complexity) error {
+	for _, field := range []struct{ name, value string }{{"time", c.Time}, {"space", c.Space}} {
+		if strings.TrimSpace(field.value) == "" {
+			continue
+		}
+		if len(field.value) > maxComplexityLength {
+			return fmt.Errorf("%s complexity: longer than %d characters", field.name, maxComplexityLength)
+		}
+		if _, err := parseComplexity(field.value); err != nil {
+			return fmt.Errorf("%s complexity: %w", field.name, err)
+		}
+	}
+	return nil
+}
+
+// parseComplexityClasses reads a comma-separated list of class names
+func parseComplexityClasses(list string) (map[string]bool, error) {
+	classes := make(map[string]bool)
+	for _, class := range splitIDs(list) {
+		if _, ok := complexityRank[class]; !ok {
+			return nil, fmt.Errorf("unknown complexity class %q", class)
+		}
+		classes[class] = true
+	}
+	return classes, nil
+}
+
+// Sort keys for ?sort= on /api/algorithms; a leading "-" sorts most
+// expensive first
+const (
+	sortTime  = "time"
+	sortSpace = "space"
+)
+
+// complexityFilter holds the complexity parameters of /api/algorithms
+type complexityFilter struct {
+	time, space       map[string]bool // allowed classes; nil allows any
+	maxTime, maxSpace int             // highest allowed rank
+	sortBy            string          // time, space or ""
+	descending        bool
+}
+
+// parseComplexityFilter reads ?time=, ?space=, ?maxTime=, ?maxSpace= and
+// ?sort=
+func parseComplexityFilter(query url.Values) (*complexityFilter, error) {
+	f := &complexityFilter{maxTime: len(complexityClasses), maxSpace: len(complexityClasses)}
+	var err error
+	if query.Has("time") {
+		if f.time, err = parseComplexityClasses(query.Get("time")); err != nil {
+			return nil, fmt.Errorf("time: %w", err)
+		}
+	}
+	if query.Has("space") {
+		if f.space, err = parseComplexityClasses(query.Get("space")); err != nil {
+			return nil, fmt.Errorf("space: %w", err)
+		}
+	}
+	for _, limit := range []struct {
+		param string
+		rank  *int
+	}{{"maxTime", &f.maxTime}, {"maxSpace", &f.maxSpace}} {
+		if !query.Has(limit.param) {
+			continue
+		}
+		rank, ok := complexityRank[query.Get(limit.param)]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown complexity class %q", limit.param, query.Get(limit.param))
+		}
+		*limit.rank = rank
+	}
+
+	switch sortBy := query.Get("sort"); sortBy {
+	case "":
+	case sortTime, sortSpace, "-" + sortTime, "-" + sortSpace:
+		f.descending = strings.HasPrefix(sortBy, "-")
+		f.sortBy = strings.TrimPrefix(sortBy, "-")
+	default:
+		return nil, fmt.Errorf("sort must be %q, %q, %q or %q", sortTime, "-"+sortTime, sortSpace, "-"+sortSpace)
+	}
+	return f, nil
+}
+
+// match reports whether algo's complexity classes pass the filter
+func (f *complexityFilter) match(algo Algorithm) bool {
+	timeClass := parseComplexityCached(algo.Complexity.Time).Class
+	spaceClass := parseComplexityCached(algo.Complexity.Space).Class
+	switch {
+	case f.time != nil && !f.time[timeClass]:
+		return false
+	case f.space != nil && !f.space[spaceClass]:
+		return false
+	case complexityRank[timeClass] > f.maxTime:
+		return false
+	case complexityRank[spaceClass] > f.maxSpace:
+		return false
+	}
+	return true
+}
+
+// sort orders algos by complexity class, keeping the existing order among
+// algorithms in the same class. Unknown complexities always sort last.
+func (f *complexityFilter) sort(algos []Algorithm) {
+	if f.sortBy == "" {
+		return
+	}
+	rank := func(algo Algorithm) int {
+		s := algo.Complexity.Time
+		if f.sortBy == sortSpace {
+			s = algo.Complexity.Space
+		}
+		class := parseComplexityCached(s).Class
+		if f.descending && class != classUnknown {
+			return -complexityRank[class]
+		}
+		return complexityRank[class]
+	}
+	sort.SliceStable(algos, func(i, j int) bool { return rank(algos[i]) < rank(algos[j]) })
+}
+
+// ComplexityComparison is one algorithm's parsed complexities
+type ComplexityComparison struct {
+	ID    string            `json:"id"`
+	Name  string            `json:"name"`
+	Time  *ParsedComplexity `json:"time"`
+	Space *ParsedComplexity `json:"space"`
+}
+
+// complexityOrder groups ids from cheapest to most expensive class;
+// algorithms in the same class share a group
+func complexityOrder(rows []ComplexityComparison, class func(ComplexityComparison) string) [][]string {
+	sorted := append([]ComplexityComparison{}, rows...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return complexityRank[class(sorted[i])] < complexityRank[class(sorted[j])]
+	})
+
+	groups := make([][]string, 0)
+	for i, row := range sorted {
+		if i == 0 || class(row) != class(sorted[i-1]) {
+			groups = append(groups, []string{})
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], row.ID)
+	}
+	return groups
+}
+
+// handleComplexity compares the complexities of the ?ids= algorithms
+func handleComplexity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ids, err := parseIDs(r.URL.Query().Get("ids"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(ids) == 0 {
+		http.Error(w, "ids is required", http.StatusBadRequest)
+		return
+	}
+
+	byID := make(map[string]Algorithm)
+	for _, algo := range db.GetApprovedAlgorithms() {
+		byID[algo.ID] = algo
+	}
+	rows := make([]ComplexityComparison, 0, len(ids))
+	for _, id := range ids {
+		algo, ok := byID[id]
+		if !ok {
+			http.Error(w, fmt.Sprintf("Algorithm not found: %s", id), http.StatusNotFound)
+			return
+		}
+		rows = append(rows, ComplexityComparison{
+			ID:    algo.ID,
+			Name:  algo.Name,
+			Time:  parseComplexityCached(algo.Complexity.Time),
+			Space: parseComplexityCached(algo.Complexity.Space),
+		})
+	}
+
+	respondJSON(w, map[string]interface{}{
+		"algorithms": rows,
+		"timeOrder":  complexityOrder(rows, func(c ComplexityComparison) string { return c.Time.Class }),
+		"spaceOrder": complexityOrder(rows, func(c ComplexityComparison) string { return c.Space.Class }),
+	})
+}
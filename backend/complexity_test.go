@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseComplexity(t *testing.T) {
+	tests := []struct {
+		in, class, dominant string
+	}{
+		{"O(1)", classConstant, "1"},
+		{"O(n log n)", classLinearithmic, "n log n"},
+		{"O(V + E)", classLinear, "V + E"},
+		{"O(V * E)", classQuadratic, "E V"},
+		{"O((n + m)^2)", classQuadratic, "n^2 + m n + m^2"},
+		{"O(2^n)", classExponential, "2^n"},
+	}
+	for _, tt := range tests {
+		parsed, err := parseComplexity(tt.in)
+		if err != nil {
+			t.Errorf("parseComplexity(%q): %v", tt.in, err)
+			continue
+		}
+		if parsed.Class != tt.class || parsed.Bounds[0].Dominant != tt.dominant {
+			t.Errorf("parseComplexity(%q) = %s %q, want %s %q", tt.in, parsed.Class, parsed.Bounds[0].Dominant, tt.class, tt.dominant)
+		}
+	}
+}
+
+// Pathological strings must fail fast rather than expand exponentially or
+// exhaust the stack
+func TestParseComplexityPathological(t *testing.T) {
+	tests := []string{
+		"O(" + strings.Repeat("(a+b)", 30) + ")",
+		"O(" + strings.Repeat("(a+b+c+d)*", 8) + "1)",
+		"O(" + strings.Repeat("(", 10000) + "n" + strings.Repeat(")", 10000) + ")",
+		"O(" + strings.Repeat("log ", 10000) + "n)",
+		"O(" + strings.Repeat("n^", 10000) + "2)",
+		"O(max(" + strings.Repeat("(a+b)*", 20) + "1))",
+	}
+	for _, in := range tests {
+		start := time.Now()
+		if _, err := parseComplexity(in); err == nil {
+			t.Errorf("parseComplexity(%.40q...) succeeded", in)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("parseComplexity(%.40q...) took %v", in, elapsed)
+		}
+	}
+}
+
+func TestValidateComplexityLength(t *testing.T) {
+	long := "O(n) " + strings.Repeat("x", maxComplexityLength)
+	if err := validateComplexity(Complexity{Time: long}); err == nil {
+		t.Error("validateComplexity accepted an overlong time complexity")
+	}
+	if err := validateComplexity(Complexity{Time: "O(n log n)", Space: "O(n)"}); err != nil {
+		t.Errorf("validateComplexity: %v", err)
+	}
+}
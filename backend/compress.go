@@ -0,0 +1,181 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// apiEncodings are the encodings applied to API responses, in order of
+// preference when the client rates them equally
+var apiEncodings = []string{"br", "gzip"}
+
+// Compressors are pooled; a brotli writer in particular allocates several
+// hundred KB of window state. Moderate levels keep per-request CPU low.
+var (
+	gzipPool = sync.Pool{New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return w
+	}}
+	brotliPool = sync.Pool{New: func() interface{} {
+		return brotli.NewWriterLevel(io.Discard, 5)
+	}}
+)
+
+// encodingQuality returns the q-value the Accept-Encoding header gives
+// encoding, falling back to the * wildcard; 0 means not acceptable
+func encodingQuality(header, encoding string) float64 {
+	wildcard := 0.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.TrimSpace(name)
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				if v, err := strconv.ParseFloat(value, 64); err == nil {
+					q = v
+				}
+			}
+		}
+
+		switch {
+		case strings.EqualFold(name, encoding):
+			return q
+		case name == "*":
+			wildcard = q
+		}
+	}
+	return wildcard
+}
+
+// acceptsEncoding reports whether the request allows encoding
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	return encodingQuality(r.Header.Get("Accept-Encoding"), encoding) > 0
+}
+
+// negotiateEncoding picks the best of the offered encodings, or "" for none
+func negotiateEncoding(r *http.Request, offered []string) string {
+	header := r.Header.Get("Accept-Encoding")
+	best, bestQ := "", 0.0
+	for _, encoding := range offered {
+		if q := encodingQuality(header, encoding); q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// compressible reports whether a content type benefits from compression
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") ||
+		mediaType == "application/json" ||
+		mediaType == "application/javascript" ||
+		mediaType == "image/svg+xml"
+}
+
+// compressMiddleware compresses API responses with brotli or gzip. Static
+// files are left alone; they are served precompressed.
+func compressMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(r, apiEncodings)
+		if encoding == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// compressWriter decides whether to compress when the headers are written
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    string
+	enc         io.WriteCloser // nil unless compressing
+	release     func()
+	wroteHeader bool
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+
+	h := cw.Header()
+	if status == http.StatusNotModified {
+		// Report the validator of the representation the client has cached
+		tagETag(h, cw.encoding)
+	} else if status >= 200 && status != http.StatusNoContent && status != http.StatusPartialContent &&
+		h.Get("Content-Encoding") == "" && compressible(h.Get("Content-Type")) {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", cw.encoding)
+		tagETag(h, cw.encoding)
+		cw.start()
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *compressWriter) start() {
+	switch cw.encoding {
+	case "br":
+		bw := brotliPool.Get().(*brotli.Writer)
+		bw.Reset(cw.ResponseWriter)
+		cw.enc, cw.release = bw, func() { brotliPool.Put(bw) }
+	case "gzip":
+		gw := gzipPool.Get().(*gzip.Writer)
+		gw.Reset(cw.ResponseWriter)
+		cw.enc, cw.release = gw, func() { gzipPool.Put(gw) }
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.enc != nil {
+		return cw.enc.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Close flushes the compressor and returns it to its pool
+func (cw *compressWriter) Close() error {
+	if cw.enc == nil {
+		return nil
+	}
+	err := cw.enc.Close()
+	cw.release()
+	cw.enc = nil
+	return err
+}
+
+// tagETag marks a strong ETag with the content coding, since a compressed
+// body is a different representation. matchETag strips the mark again.
+func tagETag(h http.Header, encoding string) {
+	etag := h.Get("ETag")
+	if etag == "" || strings.HasPrefix(etag, "W/") || !strings.HasSuffix(etag, `"`) {
+		return
+	}
+	h.Set("ETag", strings.TrimSuffix(etag, `"`)+"-"+encoding+`"`)
+}
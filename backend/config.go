@@ -0,0 +1,543 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// defaultAdminPass is refused at startup unless running in dev mode
+const defaultAdminPass = "changeme"
+
+// Config holds every setting for the server. Values are layered in order of
+// increasing precedence: built-in defaults, the JSON config file, environment
+// variables, then command-line flags.
+type Config struct {
+	Port       string `json:"port"`
+	DataDir    string `json:"dataDir"`
+	CORSOrigin string `json:"corsOrigin"`
+	Reseed     bool   `json:"reseed"`
+	Dev        bool   `json:"dev"`       // allows the default admin password
+	StaticDir  string `json:"staticDir"` // serve the frontend from disk instead of the embedded copy
+
+	Admin struct {
+		User string `json:"user"`
+		Pass string `json:"pass"`
+	} `json:"admin"`
+
+	TrustedProxies []string `json:"trustedProxies"`
+	// ClientIPHeader is a header such as X-Real-IP that the trusted proxies
+	// always overwrite; empty means only X-Forwarded-For is read
+	ClientIPHeader string   `json:"clientIPHeader"`
+	APIKeys        []string `json:"apiKeys"`
+	// IPHashSecret keys the client IP hashes stored with submissions
+	IPHashSecret string `json:"ipHashSecret"`
+
+	RateLimit struct {
+		// Policies maps limiter name to "limit/window", e.g. "api": "100/1m"
+		Policies   map[string]string `json:"policies"`
+		MaxClients int               `json:"maxClients"`
+	} `json:"rateLimit"`
+
+	Captcha struct {
+		Type          string `json:"type"`
+		Secret        string `json:"secret"`
+		PoWDifficulty int    `json:"powDifficulty"`
+	} `json:"captcha"`
+
+	Log struct {
+		Format string `json:"format"`
+		Level  string `json:"level"`
+	} `json:"log"`
+
+	Server struct {
+		ReadTimeout     Duration `json:"readTimeout"`
+		WriteTimeout    Duration `json:"writeTimeout"`
+		IdleTimeout     Duration `json:"idleTimeout"`
+		ShutdownTimeout Duration `json:"shutdownTimeout"`
+	} `json:"server"`
+
+	Limits struct {
+		MaxNameLength        int `json:"maxNameLength"`
+		MaxDescriptionLength int `json:"maxDescriptionLength"`
+		MaxPseudoCodeLength  int `json:"maxPseudoCodeLength"`
+		MaxFieldLength       int `json:"maxFieldLength"`
+		MaxArrayLength       int `json:"maxArrayLength"`
+
+		// Reference implementations are capped per language, keyed by
+		// canonical name ("rust"), falling back to MaxImplementationLength
+		MaxImplementationLength  int            `json:"maxImplementationLength"`
+		MaxImplementationLengths map[string]int `json:"maxImplementationLengths"`
+
+		// Bounds on POST /api/simulate
+		MaxGridCells       int `json:"maxGridCells"`
+		MaxSimulationSteps int `json:"maxSimulationSteps"`
+
+		// Sandbox for POST /api/run: statements executed, and container
+		// elements and string bytes created
+		MaxRunSteps       int `json:"maxRunSteps"`
+		MaxRunAllocations int `json:"maxRunAllocations"`
+	} `json:"limits"`
+}
+
+// Duration is a time.Duration written as a Go duration string ("30s") in JSON
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\"")
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func defaultConfig() *Config {
+	cfg := &Config{
+		Port:           "8080",
+		CORSOrigin:     "*",
+		TrustedProxies: []string{},
+		APIKeys:        []string{},
+	}
+	cfg.Admin.User = "admin"
+	cfg.Admin.Pass = defaultAdminPass
+
+	cfg.RateLimit.Policies = make(map[string]string, len(defaultRateLimitPolicies))
+	for name, policy := range defaultRateLimitPolicies {
+		cfg.RateLimit.Policies[name] = policy.spec()
+	}
+	cfg.RateLimit.MaxClients = defaultMaxRateLimitClients
+
+	cfg.Captcha.Type = captchaPoW
+	cfg.Captcha.PoWDifficulty = 16
+
+	cfg.Log.Format = "text"
+	cfg.Log.Level = "info"
+
+	cfg.Server.ReadTimeout = Duration(15 * time.Second)
+	cfg.Server.WriteTimeout = Duration(30 * time.Second)
+	cfg.Server.IdleTimeout = Duration(2 * time.Minute)
+	cfg.Server.ShutdownTimeout = Duration(20 * time.Second)
+
+	cfg.Limits.MaxNameLength = 200
+	cfg.Limits.MaxDescriptionLength = 5000
+	cfg.Limits.MaxPseudoCodeLength = 50000
+	cfg.Limits.MaxFieldLength = 1000
+	cfg.Limits.MaxArrayLength = 50
+	cfg.Limits.MaxImplementationLength = 20000
+	cfg.Limits.MaxImplementationLengths = map[string]int{"go": 30000, "rust": 30000, "java": 30000}
+	cfg.Limits.MaxGridCells = 2500
+	cfg.Limits.MaxSimulationSteps = 5000
+	cfg.Limits.MaxRunSteps = 100000
+	cfg.Limits.MaxRunAllocations = 1000000
+	return cfg
+}
+
+// loadConfig builds the configuration from all sources. args excludes the
+// program name and any subcommand. The config file is named by -config or
+// CONFIG_FILE.
+func loadConfig(args []string, stderr io.Writer) (*Config, error) {
+	cfg := defaultConfig()
+
+	fs := flag.NewFlagSet("aoc-algo-buddy", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a JSON config file")
+	port := fs.String("port", "", "port to listen on")
+	dataDir := fs.String("data-dir", "", "directory for data.json and audit.log")
+	corsOrigin := fs.String("cors-origin", "", "allowed CORS origin")
+	reseed := fs.Bool("reseed", false, "rebuild the database from seed_data.json")
+	dev := fs.Bool("dev", false, "development mode: allow the default admin password")
+	staticDir := fs.String("static-dir", "", "serve the frontend from this directory instead of the embedded build")
+	logFormat := fs.String("log-format", "", "log format: text or json")
+	logLevel := fs.String("log-level", "", "log level: debug, info, warn or error")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *configFile != "" {
+		if err := cfg.loadFile(*configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	// Only flags that were given explicitly override earlier sources
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "port":
+			cfg.Port = *port
+		case "data-dir":
+			cfg.DataDir = *dataDir
+		case "cors-origin":
+			cfg.CORSOrigin = *corsOrigin
+		case "reseed":
+			cfg.Reseed = *reseed
+		case "dev":
+			cfg.Dev = *dev
+		case "static-dir":
+			cfg.StaticDir = *staticDir
+		case "log-format":
+			cfg.Log.Format = *logFormat
+		case "log-level":
+			cfg.Log.Level = *logLevel
+		}
+	})
+
+	return cfg, nil
+}
+
+func (cfg *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.DisallowUnknownFields() // Catch typos rather than silently ignoring them
+	if err := dec.Decode(cfg); err != nil {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv overrides settings from environment variables. Unset or empty
+// variables leave the current value alone.
+func (cfg *Config) applyEnv() error {
+	var errs []error
+
+	str := func(key string, dst *string) {
+		if v := os.Getenv(key); v != "" {
+			*dst = v
+		}
+	}
+	list := func(key string, dst *[]string) {
+		if v := os.Getenv(key); v != "" {
+			*dst = splitList(v)
+		}
+	}
+	boolean := func(key string, dst *bool) {
+		if v := os.Getenv(key); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: expected true or false, got %q", key, v))
+				return
+			}
+			*dst = b
+		}
+	}
+	integer := func(key string, dst *int) {
+		if v := os.Getenv(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: expected an integer, got %q", key, v))
+				return
+			}
+			*dst = n
+		}
+	}
+	duration := func(key string, dst *Duration) {
+		if v := os.Getenv(key); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: expected a duration such as 30s, got %q", key, v))
+				return
+			}
+			*dst = Duration(d)
+		}
+	}
+
+	str("PORT", &cfg.Port)
+	str("DATA_DIR", &cfg.DataDir)
+	str("CORS_ORIGIN", &cfg.CORSOrigin)
+	boolean("RESEED", &cfg.Reseed)
+	boolean("DEV_MODE", &cfg.Dev)
+	str("STATIC_DIR", &cfg.StaticDir)
+	str("ADMIN_USER", &cfg.Admin.User)
+	str("ADMIN_PASS", &cfg.Admin.Pass)
+	list("TRUSTED_PROXIES", &cfg.TrustedProxies)
+	str("CLIENT_IP_HEADER", &cfg.ClientIPHeader)
+	list("API_KEYS", &cfg.APIKeys)
+	str("IP_HASH_SECRET", &cfg.IPHashSecret)
+	integer("RATE_LIMIT_MAX_CLIENTS", &cfg.RateLimit.MaxClients)
+	str("CAPTCHA_TYPE", &cfg.Captcha.Type)
+	str("CAPTCHA_SECRET", &cfg.Captcha.Secret)
+	integer("POW_DIFFICULTY", &cfg.Captcha.PoWDifficulty)
+	str("LOG_FORMAT", &cfg.Log.Format)
+	str("LOG_LEVEL", &cfg.Log.Level)
+	duration("READ_TIMEOUT", &cfg.Server.ReadTimeout)
+	duration("WRITE_TIMEOUT", &cfg.Server.WriteTimeout)
+	duration("IDLE_TIMEOUT", &cfg.Server.IdleTimeout)
+	duration("SHUTDOWN_TIMEOUT", &cfg.Server.ShutdownTimeout)
+
+	// RATE_LIMITS="api=100/1m,submit=5/1m" overrides individual policies
+	if v := os.Getenv("RATE_LIMITS"); v != "" {
+		if cfg.RateLimit.Policies == nil { // "policies": null in the file
+			cfg.RateLimit.Policies = make(map[string]string)
+		}
+		for _, entry := range splitList(v) {
+			name, spec, ok := strings.Cut(entry, "=")
+			if !ok {
+				errs = append(errs, fmt.Errorf("RATE_LIMITS: %q: expected name=limit/window", entry))
+				continue
+			}
+			cfg.RateLimit.Policies[strings.TrimSpace(name)] = strings.TrimSpace(spec)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Validate checks every setting and reports all problems at once
+func (cfg *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if n, err := strconv.Atoi(cfg.Port); err != nil || n < 1 || n > 65535 {
+		fail("port: %q is not a valid port number", cfg.Port)
+	}
+	if cfg.DataDir != "" {
+		if info, err := os.Stat(cfg.DataDir); err != nil {
+			fail("dataDir: %v", err)
+		} else if !info.IsDir() {
+			fail("dataDir: %s is not a directory", cfg.DataDir)
+		}
+	}
+	if cfg.StaticDir != "" {
+		if info, err := os.Stat(cfg.StaticDir); err != nil {
+			fail("staticDir: %v", err)
+		} else if !info.IsDir() {
+			fail("staticDir: %s is not a directory", cfg.StaticDir)
+		}
+	}
+	if cfg.CORSOrigin == "" {
+		fail("corsOrigin: must not be empty (use * to allow any origin)")
+	}
+
+	if cfg.Admin.User == "" {
+		fail("admin.user: must not be empty (ADMIN_USER)")
+	}
+	if cfg.Admin.Pass == "" {
+		fail("admin.pass: must not be empty (ADMIN_PASS)")
+	} else if cfg.Admin.Pass == defaultAdminPass && !cfg.Dev {
+		fail("admin.pass: refusing to start with the default password %q; set ADMIN_PASS, or pass -dev (DEV_MODE=true) for local development", defaultAdminPass)
+	}
+
+	if _, err := parseTrustedProxies(strings.Join(cfg.TrustedProxies, ",")); err != nil {
+		fail("trustedProxies: %v", err)
+	}
+	if cfg.ClientIPHeader != "" && !validClientIPHeader(cfg.ClientIPHeader) {
+		fail("clientIPHeader: %q is not one of %s", cfg.ClientIPHeader, strings.Join(clientIPHeaders, ", "))
+	}
+	if _, err := cfg.rateLimitPolicies(); err != nil {
+		fail("rateLimit.policies: %v", err)
+	}
+	if cfg.RateLimit.MaxClients < 1 {
+		fail("rateLimit.maxClients: must be at least 1")
+	}
+
+	if _, ok := captchaProviders[cfg.Captcha.Type]; !ok {
+		fail("captcha.type: %q is not one of %q or %q", cfg.Captcha.Type, captchaPoW, captchaArithmetic)
+	}
+	if cfg.Captcha.PoWDifficulty < 1 || cfg.Captcha.PoWDifficulty > powMaxDifficulty {
+		fail("captcha.powDifficulty: must be between 1 and %d", powMaxDifficulty)
+	}
+
+	if _, err := newLogger(cfg.Log.Format, cfg.Log.Level); err != nil {
+		fail("log: %v", err)
+	}
+
+	for name, d := range map[string]Duration{
+		"server.readTimeout":     cfg.Server.ReadTimeout,
+		"server.writeTimeout":    cfg.Server.WriteTimeout,
+		"server.idleTimeout":     cfg.Server.IdleTimeout,
+		"server.shutdownTimeout": cfg.Server.ShutdownTimeout,
+	} {
+		if d <= 0 {
+			fail("%s: must be positive", name)
+		}
+	}
+
+	for name, n := range map[string]int{
+		"limits.maxNameLength":           cfg.Limits.MaxNameLength,
+		"limits.maxDescriptionLength":    cfg.Limits.MaxDescriptionLength,
+		"limits.maxPseudoCodeLength":     cfg.Limits.MaxPseudoCodeLength,
+		"limits.maxFieldLength":          cfg.Limits.MaxFieldLength,
+		"limits.maxArrayLength":          cfg.Limits.MaxArrayLength,
+		"limits.maxImplementationLength": cfg.Limits.MaxImplementationLength,
+		"limits.maxGridCells":            cfg.Limits.MaxGridCells,
+		"limits.maxSimulationSteps":      cfg.Limits.MaxSimulationSteps,
+		"limits.maxRunSteps":             cfg.Limits.MaxRunSteps,
+		"limits.maxRunAllocations":       cfg.Limits.MaxRunAllocations,
+	} {
+		if n < 1 {
+			fail("%s: must be at least 1", name)
+		}
+	}
+	for lang, n := range cfg.Limits.MaxImplementationLengths {
+		if _, ok := implementationLanguages[lang]; !ok {
+			fail("limits.maxImplementationLengths: %q is not a supported language", lang)
+		} else if n < 1 {
+			fail("limits.maxImplementationLengths.%s: must be at least 1", lang)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// rateLimitPolicies parses the configured policy specs
+func (cfg *Config) rateLimitPolicies() (map[string]RateLimitPolicy, error) {
+	entries := make([]string, 0, len(cfg.RateLimit.Policies))
+	for name, spec := range cfg.RateLimit.Policies {
+		entries = append(entries, name+"="+spec)
+	}
+	return parseRateLimitPolicies(strings.Join(entries, ","))
+}
+
+// Redacted returns a copy safe to print, with secrets masked
+func (cfg *Config) Redacted() *Config {
+	const mask = "[redacted]"
+
+	c := *cfg
+	if c.Admin.Pass != "" {
+		c.Admin.Pass = mask
+	}
+	if c.Captcha.Secret != "" {
+		c.Captcha.Secret = mask
+	}
+	if c.IPHashSecret != "" {
+		c.IPHashSecret = mask
+	}
+	c.APIKeys = make([]string, len(cfg.APIKeys))
+	for i := range c.APIKeys {
+		c.APIKeys[i] = mask
+	}
+	return &c
+}
+
+// appState is the active configuration together with everything derived
+// from it. A reload builds a complete new state and swaps it in with a single
+// store, so each request sees either the old settings or the new ones.
+type appState struct {
+	cfg            *Config
+	trustedProxies []netip.Prefix
+	exemptKeys     [][sha256.Size]byte // SHA-256 hashes of rate limit exempt API keys
+	ipHashKey      []byte              // HMAC key for hashIP
+	submitLimiter  *RateLimiter
+	adminLimiter   *RateLimiter
+	apiLimiter     *RateLimiter
+	captchaType    string // the only type issued and accepted
+	powDifficulty  int    // proof-of-work difficulty under normal volume
+	captchaSigner  *CaptchaSigner
+}
+
+var state atomic.Pointer[appState]
+
+// current returns the active state
+func current() *appState {
+	return state.Load()
+}
+
+// newAppState builds the state for c, which must already be valid. Anything
+// whose settings are unchanged from prev (which may be nil) is carried over,
+// so rate limit buckets and used captcha tokens survive a reload.
+func newAppState(c *Config, prev *appState) *appState {
+	s := &appState{
+		cfg:           c,
+		exemptKeys:    hashAPIKeys(c.APIKeys),
+		captchaType:   c.Captcha.Type,
+		powDifficulty: c.Captcha.PoWDifficulty,
+	}
+	s.trustedProxies, _ = parseTrustedProxies(strings.Join(c.TrustedProxies, ","))
+
+	policies, _ := c.rateLimitPolicies()
+	var prevSubmit, prevAdmin, prevAPI *RateLimiter
+	if prev != nil {
+		prevSubmit, prevAdmin, prevAPI = prev.submitLimiter, prev.adminLimiter, prev.apiLimiter
+	}
+	s.submitLimiter = reuseLimiter(prevSubmit, "submit", policies["submit"], c.RateLimit.MaxClients)
+	s.adminLimiter = reuseLimiter(prevAdmin, "admin", policies["admin"], c.RateLimit.MaxClients)
+	s.apiLimiter = reuseLimiter(prevAPI, "api", policies["api"], c.RateLimit.MaxClients)
+
+	if prev != nil && prev.cfg.IPHashSecret == c.IPHashSecret {
+		s.ipHashKey = prev.ipHashKey
+	} else {
+		s.ipHashKey = ipHashSecret(c.IPHashSecret)
+	}
+
+	switch {
+	case prev == nil:
+		s.captchaSigner = NewCaptchaSigner(captchaSecret(c.Captcha.Secret))
+	case prev.cfg.Captcha.Secret == c.Captcha.Secret:
+		s.captchaSigner = prev.captchaSigner
+	default:
+		// Tokens signed with the old secret stop validating; keep the replay
+		// store anyway in case the secret is rolled back
+		s.captchaSigner = NewCaptchaSigner(captchaSecret(c.Captcha.Secret))
+		s.captchaSigner.used = prev.captchaSigner.used
+	}
+	return s
+}
+
+// reuseLimiter returns prev if its policy is unchanged, or a new limiter
+func reuseLimiter(prev *RateLimiter, name string, policy RateLimitPolicy, maxClients int) *RateLimiter {
+	if prev != nil && prev.policy == policy && prev.maxClients == maxClients {
+		return prev
+	}
+	return NewRateLimiter(name, policy, maxClients)
+}
+
+// applyConfig makes c the active configuration. c must already be valid.
+func applyConfig(c *Config) {
+	logger, _ := newLogger(c.Log.Format, c.Log.Level)
+	slog.SetDefault(logger)
+
+	state.Store(newAppState(c, current()))
+}
+
+// runConfigCommand prints the effective configuration with secrets redacted,
+// followed by any validation errors
+func runConfigCommand(cfg *Config, stdout, stderr io.Writer) int {
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(cfg.Redacted())
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(stderr, "\nInvalid configuration:\n%v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// A config file may null out the policies map that RATE_LIMITS writes into
+func TestLoadConfigNullPolicies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"rateLimit": {"policies": null}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("ADMIN_PASS", "secretpass123")
+
+	for _, env := range []string{"", "api=50/1m"} {
+		t.Setenv("RATE_LIMITS", env)
+		cfg, err := loadConfig(nil, io.Discard)
+		if err != nil {
+			t.Fatalf("RATE_LIMITS=%q: %v", env, err)
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Fatalf("RATE_LIMITS=%q: %v", env, err)
+		}
+		policies, err := cfg.rateLimitPolicies()
+		if err != nil {
+			t.Fatalf("RATE_LIMITS=%q: %v", env, err)
+		}
+
+		wantAPI := defaultRateLimitPolicies["api"]
+		if env != "" {
+			wantAPI = RateLimitPolicy{Limit: 50, Window: time.Minute}
+		}
+		if policies["api"] != wantAPI || policies["submit"] != defaultRateLimitPolicies["submit"] {
+			t.Errorf("RATE_LIMITS=%q: policies %v", env, policies)
+		}
+	}
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Days follow UTC so every visitor sees the same pick at the same moment
+const dailyDateLayout = "2006-01-02"
+
+const (
+	dailyNoRepeatDays     = 30 // an algorithm isn't picked again within this many picks
+	dailyHistoryLimit     = 30 // default entries returned by /api/daily/history
+	dailyMaxHistoryLimit  = 365
+	dailyPreviewDays      = 14 // upcoming days shown to admins
+	maxDailyOverrideAhead = 366 * 24 * time.Hour
+)
+
+// Where a pick came from
+const (
+	dailySourceRotation = "rotation"
+	dailySourceOverride = "override"
+)
+
+var errDailyUnknownAlgorithm = errors.New("unknown algorithm")
+var errDailyPastDate = errors.New("date is in the past")
+
+// DailyPick records the algorithm shown on a given day
+type DailyPick struct {
+	Date        string `json:"date"` // YYYY-MM-DD, UTC
+	AlgorithmID string `json:"algorithmId"`
+	Source      string `json:"source"` // rotation or override
+}
+
+// DailySchedule holds admin overrides and the picks already made. A day's
+// pick is recorded the first time it's served, so later catalog changes
+// can't alter it.
+type DailySchedule struct {
+	Overrides map[string]string `json:"overrides"` // date -> algorithm ID
+	History   []DailyPick       `json:"history"`
+}
+
+func today() string {
+	return time.Now().UTC().Format(dailyDateLayout)
+}
+
+// daily returns the schedule, creating it if needed. Callers must hold d.mu.
+func (d *Database) daily() *DailySchedule {
+	if d.Daily == nil {
+		d.Daily = &DailySchedule{Overrides: map[string]string{}, History: []DailyPick{}}
+	}
+	if d.Daily.Overrides == nil {
+		d.Daily.Overrides = map[string]string{}
+	}
+	return d.Daily
+}
+
+// approvedIDsUnlocked returns the sorted IDs of published algorithms
+func (d *Database) approvedIDsUnlocked() []string {
+	ids := make([]string, 0, len(d.Algorithms))
+	for _, algo := range d.Algorithms {
+		if algo.Approved {
+			ids = append(ids, algo.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// pickDaily chooses the algorithm for date given the picks before it. An
+// override wins if its algorithm still exists; otherwise the date hashes to
+// one of the algorithms not picked recently.
+func pickDaily(date string, ids []string, overrides map[string]string, history []DailyPick) (DailyPick, bool) {
+	if len(ids) == 0 {
+		return DailyPick{}, false
+	}
+
+	if id, ok := overrides[date]; ok {
+		i := sort.SearchStrings(ids, id)
+		if i < len(ids) && ids[i] == id {
+			return DailyPick{Date: date, AlgorithmID: id, Source: dailySourceOverride}, true
+		}
+	}
+
+	recent := make(map[string]bool)
+	window := min(dailyNoRepeatDays, len(ids)-1)
+	for i := len(history) - 1; i >= 0 && len(recent) < window; i-- {
+		recent[history[i].AlgorithmID] = true
+	}
+	candidates := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !recent[id] {
+			candidates = append(candidates, id)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = ids
+	}
+
+	sum := sha256.Sum256([]byte("daily:" + date))
+	index := binary.BigEndian.Uint64(sum[:8]) % uint64(len(candidates))
+	return DailyPick{Date: date, AlgorithmID: candidates[index], Source: dailySourceRotation}, true
+}
+
+// TodaysPick returns today's pick, recording it on first use
+func (d *Database) TodaysPick() (DailyPick, bool, error) {
+	date := today()
+
+	d.mu.RLock()
+	if d.Daily != nil {
+		if n := len(d.Daily.History); n > 0 && d.Daily.History[n-1].Date == date {
+			pick := d.Daily.History[n-1]
+			d.mu.RUnlock()
+			return pick, true, nil
+		}
+	}
+	d.mu.RUnlock()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	schedule := d.daily()
+	if n := len(schedule.History); n > 0 && schedule.History[n-1].Date == date {
+		return schedule.History[n-1], true, nil // Recorded while we waited for the lock
+	}
+	pick, ok := pickDaily(date, d.approvedIDsUnlocked(), schedule.Overrides, schedule.History)
+	if !ok {
+		return DailyPick{}, false, nil
+	}
+	schedule.History = append(schedule.History, pick)
+	return pick, true, d.saveUnlocked()
+}
+
+// DailyHistory returns up to limit past picks, newest first
+func (d *Database) DailyHistory(limit int) []DailyPick {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	picks := make([]DailyPick, 0, limit)
+	if d.Daily == nil {
+		return picks
+	}
+	for i := len(d.Daily.History) - 1; i >= 0 && len(picks) < limit; i-- {
+		picks = append(picks, d.Daily.History[i])
+	}
+	return picks
+}
+
+// UpcomingDaily previews the next days' picks without recording them
+func (d *Database) UpcomingDaily(days int) []DailyPick {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var overrides map[string]string
+	var history []DailyPick
+	if d.Daily != nil {
+		overrides = d.Daily.Overrides
+		history = append(history, d.Daily.History...)
+	}
+	ids := d.approvedIDsUnlocked()
+
+	upcoming := make([]DailyPick, 0, days)
+	start := time.Now().UTC()
+	for i := 0; i < days; i++ {
+		date := start.AddDate(0, 0, i).Format(dailyDateLayout)
+		if n := len(history); n > 0 && history[n-1].Date == date {
+			upcoming = append(upcoming, history[n-1])
+			continue
+		}
+		pick, ok := pickDaily(date, ids, overrides, history)
+		if !ok {
+			break
+		}
+		history = append(history, pick)
+		upcoming = append(upcoming, pick)
+	}
+	return upcoming
+}
+
+// DailyOverrides returns the overrides for today and later
+func (d *Database) DailyOverrides() map[string]string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	overrides := make(map[string]string)
+	if d.Daily == nil {
+		return overrides
+	}
+	from := today()
+	for date, id := range d.Daily.Overrides {
+		if date >= from {
+			overrides[date] = id
+		}
+	}
+	return overrides
+}
+
+// SetDailyOverride schedules algorithmID for date, or clears the override
+// when algorithmID is empty. Overriding today replaces today's pick.
+func (d *Database) SetDailyOverride(date, algorithmID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := today()
+	if date < now {
+		return errDailyPastDate
+	}
+
+	schedule := d.daily()
+	if algorithmID == "" {
+		delete(schedule.Overrides, date)
+	} else {
+		ids := d.approvedIDsUnlocked()
+		i := sort.SearchStrings(ids, algorithmID)
+		if i >= len(ids) || ids[i] != algorithmID {
+			return errDailyUnknownAlgorithm
+		}
+		schedule.Overrides[date] = algorithmID
+	}
+
+	// Today's pick may already have been served; re-pick so the change shows
+	if n := len(schedule.History); date == now && n > 0 && schedule.History[n-1].Date == now {
+		if pick, ok := pickDaily(now, d.approvedIDsUnlocked(), schedule.Overrides, schedule.History[:n-1]); ok {
+			schedule.History[n-1] = pick
+		}
+	}
+	return d.saveUnlocked()
+}
+
+// dailyFields returns the requested projection, defaulting to the summary
+func dailyFields(r *http.Request) ([]string, error) {
+	query := r.URL.Query()
+	if !query.Has("fields") && !query.Has("view") {
+		return summaryFields, nil
+	}
+	return parseProjection(query)
+}
+
+func handleDaily(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	fields, err := dailyFields(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pick, ok, err := db.TodaysPick()
+	if err != nil {
+		requestLogger(r).Error("Failed to save daily pick", "date", pick.Date, "err", err)
+	}
+	if !ok {
+		http.Error(w, "No algorithms available", http.StatusNotFound)
+		return
+	}
+	algo := db.GetAlgorithmByID(pick.AlgorithmID)
+	if algo == nil {
+		http.Error(w, "Algorithm not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Cache-Control", cacheDaily)
+	respondJSON(w, map[string]interface{}{
+		"date":      pick.Date,
+		"source":    pick.Source,
+		"algorithm": projectAlgorithm(*algo, fields),
+	})
+}
+
+// dailyHistoryEntry is a past pick with the algorithm's current name, which
+// is empty if the algorithm has since been removed
+type dailyHistoryEntry struct {
+	DailyPick
+	Name string `json:"name,omitempty"`
+}
+
+func handleDailyHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := dailyHistoryLimit
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > dailyMaxHistoryLimit {
+			http.Error(w, "limit must be between 1 and "+strconv.Itoa(dailyMaxHistoryLimit), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	// Record today's pick first so the history includes it
+	if _, _, err := db.TodaysPick(); err != nil {
+		requestLogger(r).Error("Failed to save daily pick", "err", err)
+	}
+
+	picks := db.DailyHistory(limit)
+	entries := make([]dailyHistoryEntry, 0, len(picks))
+	for _, pick := range picks {
+		entry := dailyHistoryEntry{DailyPick: pick}
+		if algo := db.GetAlgorithmByID(pick.AlgorithmID); algo != nil {
+			entry.Name = algo.Name
+		}
+		entries = append(entries, entry)
+	}
+
+	w.Header().Set("Cache-Control", cacheDaily)
+	respondJSON(w, entries)
+}
+
+// dailyOverrideRequest sets or, with an empty algorithmId, clears an override
+type dailyOverrideRequest struct {
+	Date        string `json:"date"`
+	AlgorithmID string `json:"algorithmId"`
+}
+
+func handleAdminDaily(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		respondJSON(w, map[string]interface{}{
+			"overrides": db.DailyOverrides(),
+			"upcoming":  db.UpcomingDaily(dailyPreviewDays),
+		})
+
+	case http.MethodPost:
+		r.Body = http.MaxBytesReader(w, r.Body, 1<<10)
+
+		var req dailyOverrideRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		date, err := time.Parse(dailyDateLayout, req.Date)
+		if err != nil {
+			http.Error(w, "date must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		if date.Sub(time.Now().UTC()) > maxDailyOverrideAhead {
+			http.Error(w, "date is more than a year ahead", http.StatusBadRequest)
+			return
+		}
+
+		switch err := db.SetDailyOverride(req.Date, req.AlgorithmID); {
+		case errors.Is(err, errDailyPastDate), errors.Is(err, errDailyUnknownAlgorithm):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			requestLogger(r).Error("Failed to save daily override", "date", req.Date, "err", err)
+			http.Error(w, "Failed to save override", http.StatusInternalServerError)
+			return
+		}
+		recordAudit(r, auditDailyOverride, req.Date, req.AlgorithmID)
+
+		respondJSON(w, map[string]interface{}{
+			"overrides": db.DailyOverrides(),
+			"upcoming":  db.UpcomingDaily(dailyPreviewDays),
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
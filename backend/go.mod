@@ -1,3 +1,5 @@
 module github.com/smiggiddy/aoc-algo-buddy/backend
 
 go 1.24.7
+
+require github.com/andybalholm/brotli v1.2.0
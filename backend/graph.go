@@ -0,0 +1,383 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Edge types in /api/graph. Prerequisite edges point from the algorithm to
+// learn first to the one that builds on it; related edges are undirected.
+const (
+	edgePrerequisite = "prerequisite"
+	edgeRelated      = "related"
+)
+
+// Output formats for /api/graph
+const (
+	graphFormatJSON = "json"
+	graphFormatDOT  = "dot"
+)
+
+// difficultyRank orders study steps that are otherwise interchangeable
+var difficultyRank = map[string]int{"Beginner": 0, "Intermediate": 1, "Advanced": 2}
+
+// GraphNode is an algorithm in the prerequisite graph
+type GraphNode struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Category   string `json:"category"`
+	Difficulty string `json:"difficulty"`
+}
+
+// GraphEdge links two algorithms
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"` // prerequisite or related
+}
+
+// Graph is the prerequisite DAG plus related-algorithm links
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// prereqGraph indexes algorithms by ID with their prerequisites resolved.
+// References to algorithms that don't exist are dropped.
+type prereqGraph struct {
+	ids     []string // sorted
+	nodes   map[string]GraphNode
+	prereqs map[string][]string
+	related map[string][]string
+}
+
+func newPrereqGraph(algos []Algorithm) *prereqGraph {
+	g := &prereqGraph{
+		ids:     make([]string, 0, len(algos)),
+		nodes:   make(map[string]GraphNode, len(algos)),
+		prereqs: make(map[string][]string, len(algos)),
+		related: make(map[string][]string, len(algos)),
+	}
+	for _, algo := range algos {
+		if _, dup := g.nodes[algo.ID]; !dup {
+			g.ids = append(g.ids, algo.ID)
+		}
+		g.nodes[algo.ID] = GraphNode{ID: algo.ID, Name: algo.Name, Category: algo.Category, Difficulty: algo.Difficulty}
+	}
+	sort.Strings(g.ids)
+
+	for _, algo := range algos {
+		g.prereqs[algo.ID] = g.resolve(algo.ID, algo.Prerequisites)
+		g.related[algo.ID] = g.resolve(algo.ID, algo.RelatedAlgos)
+	}
+	return g
+}
+
+// resolve returns the sorted, de-duplicated refs that name known algorithms.
+// A self-reference is kept so cycle detection can report it.
+func (g *prereqGraph) resolve(id string, refs []string) []string {
+	resolved := make([]string, 0, len(refs))
+	seen := make(map[string]bool, len(refs))
+	for _, ref := range refs {
+		if _, ok := g.nodes[ref]; ok && !seen[ref] {
+			seen[ref] = true
+			resolved = append(resolved, ref)
+		}
+	}
+	sort.Strings(resolved)
+	return resolved
+}
+
+// findCycle returns a prerequisite cycle as a path that starts and ends at
+// the same algorithm, or nil if the graph is acyclic
+func (g *prereqGraph) findCycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(g.ids))
+	var stack []string
+
+	var visit func(id string) []string
+	visit = func(id string) []string {
+		state[id] = visiting
+		stack = append(stack, id)
+		for _, prereq := range g.prereqs[id] {
+			switch state[prereq] {
+			case visiting:
+				for i, s := range stack {
+					if s == prereq {
+						// stack runs against the edges; flip it so the
+						// path reads prerequisite first
+						cycle := append([]string{}, stack[i:]...)
+						cycle = append(cycle, prereq)
+						for l, r := 0, len(cycle)-1; l < r; l, r = l+1, r-1 {
+							cycle[l], cycle[r] = cycle[r], cycle[l]
+						}
+						return cycle
+					}
+				}
+			case unvisited:
+				if cycle := visit(prereq); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = done
+		return nil
+	}
+
+	for _, id := range g.ids {
+		if state[id] == unvisited {
+			if cycle := visit(id); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// prerequisiteCycleError reports prerequisites that depend on each other
+type prerequisiteCycleError struct {
+	cycle []string
+}
+
+func (e *prerequisiteCycleError) Error() string {
+	return "prerequisite cycle: " + strings.Join(e.cycle, " -> ")
+}
+
+// checkPrerequisites rejects a catalog whose prerequisites form a cycle
+func checkPrerequisites(algos []Algorithm) error {
+	if cycle := newPrereqGraph(algos).findCycle(); cycle != nil {
+		return &prerequisiteCycleError{cycle: cycle}
+	}
+	return nil
+}
+
+// graph returns the nodes and edges in a stable order
+func (g *prereqGraph) graph() Graph {
+	graph := Graph{Nodes: make([]GraphNode, 0, len(g.ids)), Edges: make([]GraphEdge, 0)}
+	for _, id := range g.ids {
+		graph.Nodes = append(graph.Nodes, g.nodes[id])
+	}
+	for _, id := range g.ids {
+		for _, prereq := range g.prereqs[id] {
+			graph.Edges = append(graph.Edges, GraphEdge{From: prereq, To: id, Type: edgePrerequisite})
+		}
+	}
+
+	// Related links are often listed on both ends; emit each pair once
+	seen := make(map[[2]string]bool)
+	for _, id := range g.ids {
+		for _, other := range g.related[id] {
+			pair := [2]string{min(id, other), max(id, other)}
+			if id == other || seen[pair] {
+				continue
+			}
+			seen[pair] = true
+			graph.Edges = append(graph.Edges, GraphEdge{From: pair[0], To: pair[1], Type: edgeRelated})
+		}
+	}
+	return graph
+}
+
+// LearningStep is one algorithm to study on the way to a target
+type LearningStep struct {
+	GraphNode
+	Prerequisites []string `json:"prerequisites"`
+	Ready         bool     `json:"ready"` // every prerequisite is already known
+}
+
+// learningPath lists what must be studied to reach target, given the
+// algorithms already known, in topological order ending with the target.
+// Prerequisites of known algorithms are assumed known too. Among steps that
+// could come next, easier ones come first.
+func (g *prereqGraph) learningPath(target string, known map[string]bool) []LearningStep {
+	known = g.withPrerequisites(known)
+	needed := make(map[string]bool)
+	var collect func(id string)
+	collect = func(id string) {
+		if known[id] || needed[id] {
+			return
+		}
+		needed[id] = true
+		for _, prereq := range g.prereqs[id] {
+			collect(prereq)
+		}
+	}
+	collect(target)
+
+	// Kahn's algorithm over the needed subgraph
+	pending := make(map[string]int, len(needed))
+	dependents := make(map[string][]string, len(needed))
+	for id := range needed {
+		for _, prereq := range g.prereqs[id] {
+			if needed[prereq] {
+				pending[id]++
+				dependents[prereq] = append(dependents[prereq], id)
+			}
+		}
+	}
+	var queue []string
+	for id := range needed {
+		if pending[id] == 0 {
+			queue = append(queue, id)
+		}
+	}
+
+	steps := make([]LearningStep, 0, len(needed))
+	for len(queue) > 0 {
+		sort.Slice(queue, func(i, j int) bool { return g.studyBefore(queue[i], queue[j]) })
+		id := queue[0]
+		queue = queue[1:]
+
+		steps = append(steps, LearningStep{
+			GraphNode:     g.nodes[id],
+			Prerequisites: g.prereqs[id],
+			Ready:         g.ready(id, known),
+		})
+		for _, dependent := range dependents[id] {
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				queue = append(queue, dependent)
+			}
+		}
+	}
+	// Anything on a cycle never becomes ready and is left out; the catalog
+	// is checked for cycles whenever it changes, so this only affects data
+	// saved by older versions
+	return steps
+}
+
+// withPrerequisites returns known together with everything its algorithms
+// build on, directly or not
+func (g *prereqGraph) withPrerequisites(known map[string]bool) map[string]bool {
+	closure := make(map[string]bool, len(known))
+	var add func(id string)
+	add = func(id string) {
+		if closure[id] {
+			return
+		}
+		closure[id] = true
+		for _, prereq := range g.prereqs[id] {
+			add(prereq)
+		}
+	}
+	for id := range known {
+		add(id)
+	}
+	return closure
+}
+
+func (g *prereqGraph) studyBefore(a, b string) bool {
+	ra, rb := rankDifficulty(g.nodes[a].Difficulty), rankDifficulty(g.nodes[b].Difficulty)
+	if ra != rb {
+		return ra < rb
+	}
+	return a < b
+}
+
+func rankDifficulty(difficulty string) int {
+	if rank, ok := difficultyRank[difficulty]; ok {
+		return rank
+	}
+	return difficultyRank["Intermediate"]
+}
+
+func (g *prereqGraph) ready(id string, known map[string]bool) bool {
+	for _, prereq := range g.prereqs[id] {
+		if !known[prereq] {
+			return false
+		}
+	}
+	return true
+}
+
+// writeDOT renders the graph in Graphviz DOT. Related links are drawn
+// dashed and don't affect the layout.
+func (graph Graph) writeDOT(w io.Writer) {
+	io.WriteString(w, "digraph prerequisites {\n")
+	io.WriteString(w, "  rankdir=LR;\n")
+	io.WriteString(w, "  node [shape=box, style=rounded];\n")
+	for _, node := range graph.Nodes {
+		fmt.Fprintf(w, "  %s [label=%s];\n", dotQuote(node.ID), dotQuote(node.Name))
+	}
+	for _, edge := range graph.Edges {
+		switch edge.Type {
+		case edgePrerequisite:
+			fmt.Fprintf(w, "  %s -> %s;\n", dotQuote(edge.From), dotQuote(edge.To))
+		case edgeRelated:
+			fmt.Fprintf(w, "  %s -> %s [style=dashed, dir=none, constraint=false];\n", dotQuote(edge.From), dotQuote(edge.To))
+		}
+	}
+	io.WriteString(w, "}\n")
+}
+
+// dotQuote makes s a DOT quoted string
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+func handleGraph(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	graph := newPrereqGraph(db.GetApprovedAlgorithms()).graph()
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", graphFormatJSON:
+		respondJSON(w, graph)
+	case graphFormatDOT:
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		graph.writeDOT(w)
+	default:
+		http.Error(w, fmt.Sprintf("format must be %q or %q", graphFormatJSON, graphFormatDOT), http.StatusBadRequest)
+	}
+}
+
+func handleLearningPath(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	target := strings.TrimSpace(query.Get("target"))
+	if target == "" {
+		http.Error(w, "target is required", http.StatusBadRequest)
+		return
+	}
+
+	g := newPrereqGraph(db.GetApprovedAlgorithms())
+	if _, ok := g.nodes[target]; !ok {
+		http.Error(w, "Algorithm not found", http.StatusNotFound)
+		return
+	}
+
+	// Unknown IDs are ignored; progress saved in a browser may name
+	// algorithms that have since been removed
+	known := make(map[string]bool)
+	knownIDs := make([]string, 0)
+	for _, id := range splitIDs(query.Get("known")) {
+		if _, ok := g.nodes[id]; ok {
+			known[id] = true
+			knownIDs = append(knownIDs, id)
+		}
+	}
+
+	respondJSON(w, map[string]interface{}{
+		"target": target,
+		"known":  knownIDs,
+		"steps":  g.learningPath(target, known),
+	})
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLearningPath(t *testing.T) {
+	// A diamond: target needs left and right, which both need base, which
+	// needs root
+	g := newPrereqGraph([]Algorithm{
+		{ID: "root", Difficulty: "Beginner"},
+		{ID: "base", Difficulty: "Beginner", Prerequisites: []string{"root"}},
+		{ID: "left", Difficulty: "Intermediate", Prerequisites: []string{"base"}},
+		{ID: "right", Difficulty: "Beginner", Prerequisites: []string{"base"}},
+		{ID: "target", Difficulty: "Advanced", Prerequisites: []string{"left", "right"}},
+	})
+
+	tests := []struct {
+		name  string
+		known []string
+		want  []string
+		ready []string
+	}{
+		{name: "nothing known", want: []string{"root", "base", "right", "left", "target"}, ready: []string{"root"}},
+		{name: "one side known", known: []string{"left"}, want: []string{"right", "target"}, ready: []string{"right"}},
+		{name: "base known", known: []string{"base"}, want: []string{"right", "left", "target"}, ready: []string{"right", "left"}},
+		{name: "both sides known", known: []string{"left", "right"}, want: []string{"target"}, ready: []string{"target"}},
+		{name: "target known", known: []string{"target"}, want: []string{}},
+	}
+	for _, tt := range tests {
+		known := make(map[string]bool)
+		for _, id := range tt.known {
+			known[id] = true
+		}
+		got, ready := []string{}, []string(nil)
+		for _, step := range g.learningPath("target", known) {
+			got = append(got, step.ID)
+			if step.Ready {
+				ready = append(ready, step.ID)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) || !reflect.DeepEqual(ready, tt.ready) {
+			t.Errorf("%s: steps %v, ready %v; want %v, ready %v", tt.name, got, ready, tt.want, tt.ready)
+		}
+		if len(known) != len(tt.known) {
+			t.Errorf("%s: known was modified: %v", tt.name, known)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// Build metadata, normally filled from the module's VCS stamp. Builds without
+// a .git directory (e.g. the Docker image) can set them with
+// -ldflags "-X main.buildCommit=... -X main.buildTime=..."
+var (
+	buildCommit string
+	buildTime   string
+)
+
+// schemaVersion is the version of the data.json layout written by this build
+const schemaVersion = 2
+
+type versionInfo struct {
+	Commit         string `json:"commit"`
+	Modified       bool   `json:"modified,omitempty"`
+	BuildTime      string `json:"buildTime,omitempty"`
+	GoVersion      string `json:"goVersion"`
+	SchemaVersion  int    `json:"schemaVersion"`
+	AlgorithmCount int    `json:"algorithmCount"`
+}
+
+func readVersionInfo() versionInfo {
+	info := versionInfo{
+		Commit:        buildCommit,
+		BuildTime:     buildTime,
+		GoVersion:     runtime.Version(),
+		SchemaVersion: schemaVersion,
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info.GoVersion = bi.GoVersion
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.BuildTime == "" {
+					info.BuildTime = s.Value
+				}
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	return info
+}
+
+// handleHealthz reports that the process is up and serving requests
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	respondJSON(w, map[string]string{"status": "ok"})
+}
+
+// readinessCheck is the result of one /readyz check
+type readinessCheck struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+func runReadinessChecks() []readinessCheck {
+	checks := []struct {
+		name string
+		fn   func() error
+	}{
+		{"data_loaded", checkDataLoaded},
+		{"data_dir_writable", checkDataDirWritable},
+		{"frontend_present", checkFrontend},
+	}
+
+	results := make([]readinessCheck, 0, len(checks))
+	for _, c := range checks {
+		result := readinessCheck{Name: c.name, OK: true}
+		if err := c.fn(); err != nil {
+			result.OK = false
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func checkDataLoaded() error {
+	if db == nil {
+		return fmt.Errorf("database not initialised")
+	}
+	if len(db.GetApprovedAlgorithms()) == 0 {
+		return fmt.Errorf("no algorithms loaded")
+	}
+	return nil
+}
+
+// checkDataDirWritable creates and removes a temp file next to data.json
+func checkDataDirWritable() error {
+	f, err := os.CreateTemp(filepath.Dir(db.dataFile), ".readyz-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
+
+func checkFrontend() error {
+	if site == nil || !site.hasIndex() {
+		return fmt.Errorf("index.html not found; build the frontend")
+	}
+	return nil
+}
+
+// handleReadyz reports whether the server can handle traffic, returning 503
+// with the failing checks if not
+func handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	checks := runReadinessChecks()
+	status := "ready"
+	for _, c := range checks {
+		if !c.OK {
+			status = "not ready"
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			break
+		}
+	}
+
+	respondJSON(w, map[string]interface{}{
+		"status": status,
+		"checks": checks,
+	})
+}
+
+func handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	info := readVersionInfo()
+	info.AlgorithmCount = len(db.GetApprovedAlgorithms())
+	respondJSON(w, info)
+}
+
+// runHealthcheck probes /healthz on the local server. It backs the
+// "healthcheck" subcommand used by container health checks, since the
+// distroless image has no curl or wget.
+func runHealthcheck(port string) int {
+	client := &http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Get("http://127.0.0.1:" + port + "/healthz")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "healthcheck failed:", err)
+		return 1
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintln(os.Stderr, "healthcheck failed: status", resp.StatusCode)
+		return 1
+	}
+	return 0
+}
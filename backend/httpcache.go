@@ -0,0 +1,129 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cache-Control policies for API routes. Catalog responses may be cached
+// briefly and are cheap to revalidate; anything per-user or per-request
+// must not be stored.
+const (
+	cacheCatalog = "public, max-age=60"
+	cacheDaily   = "public, max-age=300" // overrides for today show up within minutes
+	cacheNoStore = "no-store"
+)
+
+// catalogEpoch distinguishes catalog versions across restarts, since the
+// counter starts again from zero
+var catalogEpoch = strconv.FormatInt(time.Now().UnixNano(), 36)
+
+// catalogChanged records a change to the published algorithms. Callers
+// must hold d.mu.
+func (d *Database) catalogChanged() {
+	d.catalogVersion++
+	d.catalogModified = time.Now()
+}
+
+// CatalogVersion returns the catalog's change counter and when it last moved
+func (d *Database) CatalogVersion() (uint64, time.Time) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.catalogVersion, d.catalogModified
+}
+
+// catalogCached serves a catalog route with validators derived from the
+// catalog version, answering conditional requests with 304 Not Modified.
+// Errors such as an unknown ID are not marked cacheable.
+func catalogCached(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next(w, r)
+			return
+		}
+
+		version, modified := db.CatalogVersion()
+		cw := &catalogWriter{
+			ResponseWriter: w,
+			etag:           `"` + catalogEpoch + "." + strconv.FormatUint(version, 36) + `"`,
+			modified:       modified.UTC().Truncate(time.Second),
+		}
+
+		if notModified(r, cw.etag, cw.modified) {
+			cw.WriteHeader(http.StatusNotModified)
+			return
+		}
+		next(cw, r)
+	}
+}
+
+// catalogWriter adds the catalog's caching headers when a successful or
+// 304 response is written
+type catalogWriter struct {
+	http.ResponseWriter
+	etag        string
+	modified    time.Time
+	wroteHeader bool
+}
+
+func (cw *catalogWriter) WriteHeader(status int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+
+	if status >= 200 && status < 300 || status == http.StatusNotModified {
+		h := cw.Header()
+		h.Set("Cache-Control", cacheCatalog)
+		h.Set("ETag", cw.etag)
+		h.Set("Last-Modified", cw.modified.Format(http.TimeFormat))
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *catalogWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// noStore marks a route's responses as uncacheable
+func noStore(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", cacheNoStore)
+		next(w, r)
+	}
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when there is no
+// If-None-Match (RFC 9110 section 13.2.2)
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return matchETag(inm, etag)
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		t, err := http.ParseTime(ims)
+		return err == nil && !modified.After(t)
+	}
+	return false
+}
+
+// matchETag uses weak comparison, as If-None-Match requires, and ignores
+// the content-coding mark added by compressMiddleware
+func matchETag(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+		for _, encoding := range apiEncodings {
+			if candidate == strings.TrimSuffix(etag, `"`)+"-"+encoding+`"` {
+				return true
+			}
+		}
+	}
+	return false
+}
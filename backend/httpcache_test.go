@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCatalogCached(t *testing.T) {
+	prevDB := db
+	db = &Database{}
+	t.Cleanup(func() { db = prevDB })
+
+	handler := catalogCached(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/algorithms/bfs" {
+			http.Error(w, "Algorithm not found", http.StatusNotFound)
+			return
+		}
+		respondJSON(w, map[string]string{"id": "bfs"})
+	})
+	get := func(path, ifNoneMatch string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, r)
+		return rec
+	}
+
+	ok := get("/api/algorithms/bfs", "")
+	etag := ok.Header().Get("ETag")
+	if ok.Code != http.StatusOK || etag == "" || ok.Header().Get("Cache-Control") != cacheCatalog ||
+		ok.Header().Get("Last-Modified") == "" {
+		t.Fatalf("status %d, headers %v", ok.Code, ok.Header())
+	}
+
+	missing := get("/api/algorithms/nope", "")
+	if missing.Code != http.StatusNotFound {
+		t.Fatalf("status %d", missing.Code)
+	}
+	for _, name := range []string{"Cache-Control", "ETag", "Last-Modified"} {
+		if v := missing.Header().Get(name); v != "" {
+			t.Errorf("404 has %s: %s", name, v)
+		}
+	}
+
+	cached := get("/api/algorithms/bfs", etag)
+	if cached.Code != http.StatusNotModified || cached.Header().Get("ETag") != etag || cached.Body.Len() != 0 {
+		t.Errorf("conditional request: status %d, headers %v", cached.Code, cached.Header())
+	}
+}
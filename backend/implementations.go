@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Implementation is reference code for an algorithm in one language.
+// Tested is set by a reviewer, never by the submitter.
+type Implementation struct {
+	Language string `json:"language"`
+	Code     string `json:"code"`
+	Author   string `json:"author,omitempty"`
+	Tested   bool   `json:"tested"`
+}
+
+// implementationLanguages lists the accepted languages by canonical name,
+// with the aliases also accepted in submissions and ?lang=
+var implementationLanguages = map[string][]string{
+	"go":         {"golang"},
+	"python":     {"py", "python3"},
+	"rust":       {"rs"},
+	"javascript": {"js"},
+	"typescript": {"ts"},
+	"java":       {},
+	"kotlin":     {"kt"},
+	"c":          {},
+	"cpp":        {"c++"},
+	"csharp":     {"c#", "cs"},
+	"haskell":    {"hs"},
+	"ocaml":      {"ml"},
+	"ruby":       {"rb"},
+	"elixir":     {"ex"},
+	"zig":        {},
+}
+
+var languageAliases = func() map[string]string {
+	aliases := make(map[string]string)
+	for lang, names := range implementationLanguages {
+		aliases[lang] = lang
+		for _, name := range names {
+			aliases[name] = lang
+		}
+	}
+	return aliases
+}()
+
+// canonicalLanguage resolves a language name or alias, case-insensitively
+func canonicalLanguage(name string) (string, bool) {
+	lang, ok := languageAliases[strings.ToLower(strings.TrimSpace(name))]
+	return lang, ok
+}
+
+// maxImplementationLength is the configured size limit for lang
+func maxImplementationLength(lang string) int {
+	limits := current().cfg.Limits
+	if n, ok := limits.MaxImplementationLengths[lang]; ok {
+		return n
+	}
+	return limits.MaxImplementationLength
+}
+
+// normalizeImplementations canonicalizes language names and orders the
+// implementations by language
+func normalizeImplementations(impls []Implementation) {
+	for i := range impls {
+		if lang, ok := canonicalLanguage(impls[i].Language); ok {
+			impls[i].Language = lang
+		}
+		impls[i].Author = strings.TrimSpace(impls[i].Author)
+	}
+	sort.SliceStable(impls, func(i, j int) bool { return impls[i].Language < impls[j].Language })
+}
+
+// validateImplementations checks normalized implementations: known
+// languages, at most one per language, and code within that language's limit
+func validateImplementations(impls []Implementation) error {
+	limits := current().cfg.Limits
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	seen := make(map[string]bool, len(impls))
+	for _, impl := range impls {
+		if _, ok := implementationLanguages[impl.Language]; !ok {
+			fail("%q is not a supported language", impl.Language)
+			continue
+		}
+		if seen[impl.Language] {
+			fail("%s: only one implementation per language", impl.Language)
+		}
+		seen[impl.Language] = true
+
+		if strings.TrimSpace(impl.Code) == "" {
+			fail("%s: code must not be empty", impl.Language)
+		} else if limit := maxImplementationLength(impl.Language); len(impl.Code) > limit {
+			fail("%s: code exceeds maximum length of %d bytes", impl.Language, limit)
+		}
+		if len(impl.Author) > limits.MaxNameLength {
+			fail("%s: author exceeds maximum length", impl.Language)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// implementationLanguagesOf lists the languages algo has code in
+func implementationLanguagesOf(algo Algorithm) []string {
+	langs := make([]string, 0, len(algo.Implementations))
+	for _, impl := range algo.Implementations {
+		langs = append(langs, impl.Language)
+	}
+	return langs
+}
+
+// selectImplementation narrows algo's implementations to the ?lang= one.
+// It writes the error response and returns false when that fails.
+func selectImplementation(w http.ResponseWriter, algo *Algorithm, name string) bool {
+	lang, ok := canonicalLanguage(name)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Unsupported language %q", name), http.StatusBadRequest)
+		return false
+	}
+	for _, impl := range algo.Implementations {
+		if impl.Language == lang {
+			algo.Implementations = []Implementation{impl}
+			return true
+		}
+	}
+
+	available := "none"
+	if langs := implementationLanguagesOf(*algo); len(langs) > 0 {
+		available = strings.Join(langs, ", ")
+	}
+	http.Error(w, fmt.Sprintf("No %s implementation (available: %s)", lang, available), http.StatusNotFound)
+	return false
+}
+
+// markTested flags the implementations in the given languages as tested.
+// Every language must have an implementation.
+func markTested(impls []Implementation, tested []string) error {
+	for _, name := range tested {
+		lang, ok := canonicalLanguage(name)
+		found := false
+		for i := range impls {
+			if ok && impls[i].Language == lang {
+				impls[i].Tested = true
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: no %s implementation to mark tested", errInvalidReview, name)
+		}
+	}
+	return nil
+}
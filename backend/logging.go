@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// newLogger builds a logger for the given format (json or text) and level
+// (debug, info, warn, error)
+func newLogger(format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, err
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	switch strings.ToLower(format) {
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown LOG_FORMAT %q, expected json or text", format)
+	}
+}
+
+type requestIDKey struct{}
+
+// validRequestID limits propagated IDs to something safe to log and echo
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// requestIDMiddleware propagates the caller's X-Request-ID, or generates
+// one, and echoes it on the response
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID.MatchString(id) {
+			id = generateID()
+		}
+
+		w.Header().Set("X-Request-ID", id)
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requestID returns the ID assigned by requestIDMiddleware
+func requestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestLogger returns a logger that tags every line with the request ID
+func requestLogger(r *http.Request) *slog.Logger {
+	logger := slog.Default().With("request_id", requestID(r))
+	if cfRay := r.Header.Get("CF-Ray"); cfRay != "" {
+		logger = logger.With("cf_ray", cfRay) // Cloudflare Ray ID for request tracing
+	}
+	return logger
+}
+
+func loggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		wrapped := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(wrapped, r)
+
+		level := slog.LevelInfo
+		if wrapped.status >= 500 {
+			level = slog.LevelError
+		}
+
+		requestLogger(r).LogAttrs(r.Context(), level, "request",
+			slog.String("ip", getClientIP(r)),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", wrapped.status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("ua", truncateUA(r.Header.Get("User-Agent"))),
+		)
+	})
+}
+
+// truncateUA truncates User-Agent to a reasonable length for logging
+func truncateUA(ua string) string {
+	if len(ua) > 100 {
+		return ua[:100] + "..."
+	}
+	return ua
+}
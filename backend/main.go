@@ -1,45 +1,57 @@
 package main
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
-	"math/big"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
+
+	"github.com/smiggiddy/aoc-algo-buddy/backend/pseudo"
 )
 
 // Algorithm represents an algorithm entry
 type Algorithm struct {
-	ID               string     `json:"id"`
-	Name             string     `json:"name"`
-	Category         string     `json:"category"`
-	Tags             []string   `json:"tags"`
-	Difficulty       string     `json:"difficulty"`
-	Description      string     `json:"description"`
-	WhenToUse        []string   `json:"whenToUse"`
-	PseudoCode       string     `json:"pseudoCode"`
-	Complexity       Complexity `json:"complexity"`
-	AoCExamples      []string   `json:"aocExamples"`
-	Resources        []string   `json:"resources"`
-	Examples         []Example  `json:"examples"`
-	Prerequisites    []string   `json:"prerequisites,omitempty"`
-	KeyInsight       string     `json:"keyInsight,omitempty"`
-	CommonPitfalls   []string   `json:"commonPitfalls,omitempty"`
-	RelatedAlgos     []string   `json:"relatedAlgos,omitempty"`
-	RecognitionHints []string   `json:"recognitionHints,omitempty"`
-	Approved         bool       `json:"approved"`
-	CreatedAt        time.Time  `json:"createdAt"`
-	SubmittedBy      string     `json:"submittedBy,omitempty"`
+	ID               string           `json:"id"`
+	Name             string           `json:"name"`
+	Category         string           `json:"category"`
+	Tags             []string         `json:"tags"`
+	Keywords         []string         `json:"keywords,omitempty"` // puzzle phrases that suggest it, for /api/recommend
+	Difficulty       string           `json:"difficulty"`
+	Description      string           `json:"description"`
+	WhenToUse        []string         `json:"whenToUse"`
+	PseudoCode       string           `json:"pseudoCode"`
+	Implementations  []Implementation `json:"implementations,omitempty"`
+	Complexity       Complexity       `json:"complexity"`
+	AoCExamples      []AoCReference   `json:"aocExamples"`
+	AoCNotes         []string         `json:"aocNotes,omitempty"` // general uses that aren't one puzzle
+	Resources        []string         `json:"resources"`
+	Examples         []Example        `json:"examples"`
+	Prerequisites    []string         `json:"prerequisites,omitempty"`
+	KeyInsight       string           `json:"keyInsight,omitempty"`
+	CommonPitfalls   []string         `json:"commonPitfalls,omitempty"`
+	RelatedAlgos     []string         `json:"relatedAlgos,omitempty"`
+	RecognitionHints []string         `json:"recognitionHints,omitempty"`
+	Approved         bool             `json:"approved"`
+	CreatedAt        time.Time        `json:"createdAt"`
+	SubmittedBy      string           `json:"submittedBy,omitempty"`
 }
 
 type Complexity struct {
@@ -64,179 +76,209 @@ type Step struct {
 
 // Submission represents a pending algorithm submission
 type Submission struct {
-	ID          string    `json:"id"`
-	Algorithm   Algorithm `json:"algorithm"`
-	SubmittedAt time.Time `json:"submittedAt"`
-	Status      string    `json:"status"` // pending, approved, rejected
+	ID          string     `json:"id"`
+	Algorithm   Algorithm  `json:"algorithm"`
+	SubmittedAt time.Time  `json:"submittedAt"`
+	Status      string     `json:"status"` // pending, quarantined, approved, rejected
 	ReviewedAt  *time.Time `json:"reviewedAt,omitempty"`
+	SpamScore   float64    `json:"spamScore"`
+	SpamRules   []string   `json:"spamRules,omitempty"` // rules that contributed to SpamScore
+	IPHash      string     `json:"ipHash,omitempty"`
+	ContentHash string     `json:"contentHash,omitempty"`
+	// Lint holds what the pseudo code linter found, for reviewers
+	Lint []pseudo.Diagnostic `json:"lint,omitempty"`
 }
 
-// CaptchaChallenge for anti-spam
-type CaptchaChallenge struct {
-	ID        string    `json:"id"`
-	Question  string    `json:"question"`
-	Answer    int       `json:"answer"`
-	ExpiresAt time.Time `json:"expiresAt"`
+// Submission statuses. Submissions whose spam score reaches the threshold
+// are quarantined instead of entering the pending queue.
+const (
+	statusPending     = "pending"
+	statusQuarantined = "quarantined"
+	statusApproved    = "approved"
+	statusRejected    = "rejected"
+)
+
+// isReviewable reports whether an admin can still approve or reject
+func (s Submission) isReviewable() bool {
+	return s.Status == statusPending || s.Status == statusQuarantined
 }
 
 // Database holds all data with file persistence
 type Database struct {
-	mu          sync.RWMutex
-	Algorithms  []Algorithm         `json:"algorithms"`
-	Submissions []Submission        `json:"submissions"`
-	dataFile    string
-	captchas    map[string]CaptchaChallenge
-	captchaMu   sync.RWMutex
+	mu            sync.RWMutex
+	SchemaVersion int          `json:"schemaVersion"`
+	Algorithms    []Algorithm  `json:"algorithms"`
+	Submissions   []Submission `json:"submissions"`
+	// SpamSettings holds admin overrides for spam scoring; nil means defaults
+	SpamSettings *SpamSettings `json:"spamSettings,omitempty"`
+	// Daily holds Algorithm of the Day overrides and past picks
+	Daily *DailySchedule `json:"daily,omitempty"`
+	// ComparisonGroups is nil only in data saved before groups were stored
+	ComparisonGroups []ComparisonGroup `json:"comparisonGroups"`
+	// EditedComparisons lists groups an admin created, replaced or deleted;
+	// merging seed data leaves them alone
+	EditedComparisons []string `json:"editedComparisons,omitempty"`
+	dataFile          string
+
+	// Bumped whenever the published algorithms change; see catalogCached
+	catalogVersion  uint64
+	catalogModified time.Time
 }
 
-// RateLimiter provides simple rate limiting per IP
-type RateLimiter struct {
-	mu       sync.Mutex
-	requests map[string][]time.Time
-	limit    int           // max requests
-	window   time.Duration // time window
-}
+var db *Database
 
-func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
-	rl := &RateLimiter{
-		requests: make(map[string][]time.Time),
-		limit:    limit,
-		window:   window,
-	}
-	// Cleanup old entries periodically
-	go func() {
-		for {
-			time.Sleep(window)
-			rl.cleanup()
-		}
-	}()
-	return rl
-}
-
-func (rl *RateLimiter) cleanup() {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-	now := time.Now()
-	for ip, times := range rl.requests {
-		var valid []time.Time
-		for _, t := range times {
-			if now.Sub(t) < rl.window {
-				valid = append(valid, t)
-			}
-		}
-		if len(valid) == 0 {
-			delete(rl.requests, ip)
-		} else {
-			rl.requests[ip] = valid
-		}
-	}
-}
+var errSubmissionNotFound = errors.New("submission not found")
+var errInvalidReview = errors.New("invalid review")
+var auditLog *AuditLog
 
-func (rl *RateLimiter) Allow(ip string) bool {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
+// openDatabase loads data.json from the configured data directory, falling
+// back to seed_data.json when it is missing or a reseed is requested
+func openDatabase(cfg *Config) {
+	dataFile := "data.json"
+	auditFile := "audit.log"
+	if cfg.DataDir != "" {
+		dataFile = filepath.Join(cfg.DataDir, "data.json")
+		auditFile = filepath.Join(cfg.DataDir, "audit.log")
+	}
 
-	now := time.Now()
-	times := rl.requests[ip]
+	auditLog = NewAuditLog(auditFile)
 
-	// Filter to only requests within the window
-	var valid []time.Time
-	for _, t := range times {
-		if now.Sub(t) < rl.window {
-			valid = append(valid, t)
-		}
+	db = &Database{
+		dataFile: dataFile,
 	}
 
-	if len(valid) >= rl.limit {
-		return false
+	if cfg.Reseed {
+		slog.Info("Reseed requested: Rebuilding database from seed_data.json")
+		loadFromSeed(db)
+	} else if err := db.Load(); err != nil {
+		slog.Info("No existing data.json, loading from seed_data.json", "err", err)
+		loadFromSeed(db)
+	} else {
+		slog.Info("Loaded algorithms from data.json", "count", len(db.Algorithms))
+		if db.SchemaVersion < schemaVersion {
+			if err := db.Migrate(); err != nil {
+				log.Fatalf("Failed to save migrated data: %v", err)
+			}
+		}
+		if db.ComparisonGroups == nil {
+			seedComparisons(db)
+		}
+		if err := checkPrerequisites(db.GetApprovedAlgorithms()); err != nil {
+			slog.Warn("Learning paths will skip algorithms on a prerequisite cycle", "err", err)
+		}
 	}
-
-	rl.requests[ip] = append(valid, now)
-	return true
 }
 
-// Rate limiters for different endpoints
-var (
-	submitLimiter = NewRateLimiter(5, time.Minute)       // 5 submissions per minute
-	adminLimiter  = NewRateLimiter(10, time.Minute)      // 10 admin attempts per minute
-	apiLimiter    = NewRateLimiter(100, time.Minute)     // 100 API requests per minute
-)
-
-// Maximum sizes for input validation
+// Seed files hold the built-in catalog, relative to the working directory.
+// The comparison groups file is optional.
 const (
-	maxNameLength        = 200
-	maxDescriptionLength = 5000
-	maxPseudoCodeLength  = 50000
-	maxFieldLength       = 1000
-	maxArrayLength       = 50
+	seedFile            = "seed_data.json"
+	seedComparisonsFile = "seed_comparisons.json"
 )
 
-var db *Database
-
-// Admin credentials (set via environment variables)
-var adminUser = getEnv("ADMIN_USER", "admin")
-var adminPass = getEnv("ADMIN_PASS", "changeme")
-var dataDir = getEnv("DATA_DIR", "")
-
-func getEnv(key, defaultVal string) string {
-	if val := os.Getenv(key); val != "" {
-		return val
-	}
-	return defaultVal
+// Seed is the built-in catalog
+type Seed struct {
+	Algorithms       []Algorithm
+	ComparisonGroups []ComparisonGroup
 }
 
-func init() {
-	dataFile := "data.json"
-	if dataDir != "" {
-		dataFile = filepath.Join(dataDir, "data.json")
+// readSeed parses and validates the seed files
+func readSeed() (*Seed, error) {
+	seed := &Seed{ComparisonGroups: []ComparisonGroup{}}
+	if err := readSeedFile(seedFile, &seed.Algorithms); err != nil {
+		return nil, err
 	}
-
-	db = &Database{
-		dataFile: dataFile,
-		captchas: make(map[string]CaptchaChallenge),
+	if err := checkPrerequisites(seed.Algorithms); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", seedFile, err)
 	}
-
-	// Check if reseed is requested via env var
-	reseed := os.Getenv("RESEED") == "true" || os.Getenv("RESEED") == "1"
-
-	if reseed {
-		log.Println("RESEED=true: Rebuilding database from seed_data.json")
-		loadFromSeed(db)
-	} else if err := db.Load(); err != nil {
-		log.Printf("No existing data.json, loading from seed_data.json: %v", err)
-		loadFromSeed(db)
-	} else {
-		log.Printf("Loaded %d algorithms from data.json", len(db.Algorithms))
+	for i := range seed.Algorithms {
+		algo := &seed.Algorithms[i]
+		normalizeAoC(algo)
+		for _, ref := range algo.AoCExamples {
+			if err := validateAoCReference(ref); err != nil {
+				return nil, fmt.Errorf("invalid %s: %s: aocExamples: %w", seedFile, algo.ID, err)
+			}
+		}
+		if err := validateComplexity(algo.Complexity); err != nil {
+			return nil, fmt.Errorf("invalid %s: %s: %w", seedFile, algo.ID, err)
+		}
+		normalizeImplementations(algo.Implementations)
+		if err := validateImplementations(algo.Implementations); err != nil {
+			return nil, fmt.Errorf("invalid %s: %s: implementations: %w", seedFile, algo.ID, err)
+		}
+		if err := validatePseudoCode(algo.PseudoCode); err != nil {
+			return nil, fmt.Errorf("invalid %s: %s: pseudoCode: %w", seedFile, algo.ID, err)
+		}
 	}
 
-	// Clean up expired captchas periodically
-	go func() {
-		for {
-			time.Sleep(5 * time.Minute)
-			db.cleanExpiredCaptchas()
+	if err := readSeedFile(seedComparisonsFile, &seed.ComparisonGroups); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	ids := make(map[string]bool, len(seed.Algorithms))
+	for _, algo := range seed.Algorithms {
+		ids[algo.ID] = true
+	}
+	seen := make(map[string]bool, len(seed.ComparisonGroups))
+	for i := range seed.ComparisonGroups {
+		g := &seed.ComparisonGroups[i]
+		if seen[g.ID] {
+			return nil, fmt.Errorf("invalid %s: duplicate comparison group %q", seedComparisonsFile, g.ID)
 		}
-	}()
+		seen[g.ID] = true
+		if err := validateComparisonGroup(g, func(id string) bool { return ids[id] }); err != nil {
+			return nil, fmt.Errorf("invalid %s: %s: %w", seedComparisonsFile, g.ID, err)
+		}
+	}
+	return seed, nil
 }
 
-func loadFromSeed(d *Database) {
-	seedData, err := os.ReadFile("seed_data.json")
+func readSeedFile(name string, v interface{}) error {
+	data, err := os.ReadFile(name)
 	if err != nil {
-		log.Fatalf("Failed to read seed_data.json: %v", err)
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
 	}
-	var seedAlgos []Algorithm
-	if err := json.Unmarshal(seedData, &seedAlgos); err != nil {
-		log.Fatalf("Failed to parse seed_data.json: %v", err)
+	return nil
+}
+
+func loadFromSeed(d *Database) {
+	seed, err := readSeed()
+	if err != nil {
+		log.Fatal(err)
 	}
-	d.Algorithms = seedAlgos
+	d.Algorithms = seed.Algorithms
+	d.ComparisonGroups = seed.ComparisonGroups
+	d.EditedComparisons = nil
 	d.Submissions = []Submission{} // Reset submissions on reseed
-	log.Printf("Loaded %d algorithms from seed_data.json", len(seedAlgos))
+	d.catalogChanged()
+	slog.Info("Loaded algorithms from seed_data.json", "count", len(seed.Algorithms))
 
 	// Mark all seeded algorithms as approved
 	for i := range d.Algorithms {
 		d.Algorithms[i].Approved = true
 		d.Algorithms[i].CreatedAt = time.Now()
 	}
-	d.Save()
+	if err := d.Save(); err != nil {
+		slog.Error("Failed to save seeded data", "file", d.dataFile, "err", err)
+	}
+}
+
+// seedComparisons adds the seeded comparison groups to data saved before
+// groups were stored in the database
+func seedComparisons(d *Database) {
+	seed, err := readSeed()
+	if err != nil {
+		slog.Error("Failed to read seed comparison groups", "err", err)
+		return
+	}
+	report, err := d.MergeSeed(&Seed{ComparisonGroups: seed.ComparisonGroups})
+	if err != nil {
+		slog.Error("Failed to save seeded comparison groups", "file", d.dataFile, "err", err)
+		return
+	}
+	slog.Info("Added comparison groups from seed data", "count", len(report.Comparisons.Added))
 }
 
 func (d *Database) Load() error {
@@ -248,6 +290,7 @@ func (d *Database) Load() error {
 		return err
 	}
 
+	d.catalogChanged()
 	return json.Unmarshal(data, d)
 }
 
@@ -255,12 +298,7 @@ func (d *Database) Save() error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	data, err := json.MarshalIndent(d, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	return os.WriteFile(d.dataFile, data, 0644)
+	return d.saveUnlocked()
 }
 
 func (d *Database) GetApprovedAlgorithms() []Algorithm {
@@ -288,59 +326,88 @@ func (d *Database) GetAlgorithmByID(id string) *Algorithm {
 	return nil
 }
 
-func (d *Database) AddSubmission(algo Algorithm, submittedBy string) string {
+func (d *Database) AddSubmission(algo Algorithm, submittedBy, ipHash string, spam SpamResult, lint []pseudo.Diagnostic) (string, error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
 	id := generateID()
+	hash := contentHash(algo)
 	algo.Approved = false
 	algo.CreatedAt = time.Now()
 	algo.SubmittedBy = submittedBy
 
+	status := statusPending
+	if spam.Quarantined {
+		status = statusQuarantined
+	}
+
 	submission := Submission{
 		ID:          id,
 		Algorithm:   algo,
 		SubmittedAt: time.Now(),
-		Status:      "pending",
+		Status:      status,
+		SpamScore:   spam.Score,
+		SpamRules:   spam.Triggered,
+		IPHash:      ipHash,
+		ContentHash: hash,
+		Lint:        lint,
 	}
 
 	d.Submissions = append(d.Submissions, submission)
-	d.saveUnlocked()
-	return id
+	return id, d.saveUnlocked()
 }
 
-func (d *Database) GetPendingSubmissions() []Submission {
+func (d *Database) GetSubmissionsByStatus(status string) []Submission {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
-	pending := make([]Submission, 0)
+	matching := make([]Submission, 0)
 	for _, sub := range d.Submissions {
-		if sub.Status == "pending" {
-			pending = append(pending, sub)
+		if sub.Status == status {
+			matching = append(matching, sub)
 		}
 	}
-	return pending
+	return matching
 }
 
-func (d *Database) ApproveSubmission(id string) error {
+// ApproveSubmission publishes a pending submission, marking the
+// implementations in the tested languages as tested, and returns the new
+// algorithm ID. A submission whose prerequisites would form a cycle is left
+// pending.
+func (d *Database) ApproveSubmission(id string, tested []string) (string, error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
 	for i := range d.Submissions {
-		if d.Submissions[i].ID == id && d.Submissions[i].Status == "pending" {
-			now := time.Now()
-			d.Submissions[i].Status = "approved"
-			d.Submissions[i].ReviewedAt = &now
-
+		if d.Submissions[i].ID == id && d.Submissions[i].isReviewable() {
 			algo := d.Submissions[i].Algorithm
 			algo.Approved = true
 			algo.ID = generateSlug(algo.Name)
+			algo.Implementations = append([]Implementation{}, algo.Implementations...)
+			if err := markTested(algo.Implementations, tested); err != nil {
+				return "", err
+			}
+
+			catalog := make([]Algorithm, 0, len(d.Algorithms)+1)
+			for _, existing := range d.Algorithms {
+				if existing.Approved {
+					catalog = append(catalog, existing)
+				}
+			}
+			if err := checkPrerequisites(append(catalog, algo)); err != nil {
+				return "", err
+			}
+
+			now := time.Now()
+			d.Submissions[i].Status = statusApproved
+			d.Submissions[i].ReviewedAt = &now
 			d.Algorithms = append(d.Algorithms, algo)
+			d.catalogChanged()
 
-			return d.saveUnlocked()
+			return algo.ID, d.saveUnlocked()
 		}
 	}
-	return fmt.Errorf("submission not found")
+	return "", errSubmissionNotFound
 }
 
 func (d *Database) RejectSubmission(id string) error {
@@ -348,115 +415,243 @@ func (d *Database) RejectSubmission(id string) error {
 	defer d.mu.Unlock()
 
 	for i := range d.Submissions {
-		if d.Submissions[i].ID == id && d.Submissions[i].Status == "pending" {
+		if d.Submissions[i].ID == id && d.Submissions[i].isReviewable() {
 			now := time.Now()
-			d.Submissions[i].Status = "rejected"
+			d.Submissions[i].Status = statusRejected
 			d.Submissions[i].ReviewedAt = &now
 			return d.saveUnlocked()
 		}
 	}
-	return fmt.Errorf("submission not found")
+	return errSubmissionNotFound
 }
 
 func (d *Database) saveUnlocked() error {
+	defer func(start time.Time) {
+		dataSaveDuration.Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	d.SchemaVersion = schemaVersion
 	data, err := json.MarshalIndent(d, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(d.dataFile, data, 0644)
+	return writeFileAtomic(d.dataFile, data, 0644)
 }
 
-// Captcha methods
-func (d *Database) CreateCaptcha() CaptchaChallenge {
-	d.captchaMu.Lock()
-	defer d.captchaMu.Unlock()
-
-	a, _ := rand.Int(rand.Reader, big.NewInt(20))
-	b, _ := rand.Int(rand.Reader, big.NewInt(20))
-	num1 := int(a.Int64()) + 1
-	num2 := int(b.Int64()) + 1
+// RecentSubmissionCount returns how many submissions arrived within window
+func (d *Database) RecentSubmissionCount(window time.Duration) int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 
-	captcha := CaptchaChallenge{
-		ID:        generateID(),
-		Question:  fmt.Sprintf("What is %d + %d?", num1, num2),
-		Answer:    num1 + num2,
-		ExpiresAt: time.Now().Add(10 * time.Minute),
+	cutoff := time.Now().Add(-window)
+	count := 0
+	for _, sub := range d.Submissions {
+		if sub.SubmittedAt.After(cutoff) {
+			count++
+		}
 	}
-
-	d.captchas[captcha.ID] = captcha
-	return captcha
+	return count
 }
 
-func (d *Database) ValidateCaptcha(id string, answer int) bool {
-	d.captchaMu.Lock()
-	defer d.captchaMu.Unlock()
-
-	captcha, exists := d.captchas[id]
-	if !exists {
-		return false
+func main() {
+	// Subcommands: serve (default), config, healthcheck
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
 	}
 
-	delete(d.captchas, id) // One-time use
-
-	if time.Now().After(captcha.ExpiresAt) {
-		return false
+	c, err := loadConfig(args, os.Stderr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error loading configuration:", err)
+		os.Exit(2)
 	}
 
-	return captcha.Answer == answer
-}
+	switch command {
+	case "serve":
+	case "config":
+		os.Exit(runConfigCommand(c, os.Stdout, os.Stderr))
+	case "healthcheck":
+		os.Exit(runHealthcheck(c.Port))
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q (expected serve, config or healthcheck)\n", command)
+		os.Exit(2)
+	}
 
-func (d *Database) cleanExpiredCaptchas() {
-	d.captchaMu.Lock()
-	defer d.captchaMu.Unlock()
+	if err := c.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+		os.Exit(1)
+	}
+	applyConfig(c)
+	openDatabase(c)
 
-	now := time.Now()
-	for id, captcha := range d.captchas {
-		if now.After(captcha.ExpiresAt) {
-			delete(d.captchas, id)
-		}
+	site, err = newStaticSite(c.StaticDir)
+	if err != nil {
+		log.Fatalf("Failed to index frontend assets: %v", err)
 	}
-}
 
-func main() {
 	mux := http.NewServeMux()
 
-	// Public API routes
-	mux.HandleFunc("/api/algorithms", handleAlgorithms)
-	mux.HandleFunc("/api/algorithms/", handleAlgorithmByID)
-	mux.HandleFunc("/api/categories", handleCategories)
-	mux.HandleFunc("/api/tags", handleTags)
+	// Health and build info
+	mux.HandleFunc("/healthz", noStore(handleHealthz))
+	mux.HandleFunc("/readyz", noStore(handleReadyz))
+	mux.HandleFunc("/api/version", noStore(handleVersion))
+
+	// Public API routes; cacheable and revalidated against the catalog version
+	mux.HandleFunc("/api/algorithms", catalogCached(handleAlgorithms))
+	mux.HandleFunc("/api/algorithms/", catalogCached(handleAlgorithmByID))
+	mux.HandleFunc("/api/categories", catalogCached(handleCategories))
+	mux.HandleFunc("/api/tags", catalogCached(handleTags))
+	mux.HandleFunc("/api/graph", catalogCached(handleGraph))
+	mux.HandleFunc("/api/learning-path", catalogCached(handleLearningPath))
+	mux.HandleFunc("/api/comparisons", catalogCached(handleComparisons))
+	mux.HandleFunc("/api/comparisons/", catalogCached(handleComparisonByID))
+	mux.HandleFunc("/api/aoc/", catalogCached(handleAoC))
+	mux.HandleFunc("/api/complexity", catalogCached(handleComplexity))
+	mux.HandleFunc("/api/daily", handleDaily)
+	mux.HandleFunc("/api/daily/history", handleDailyHistory)
+
+	// Suggestions for a pasted puzzle description
+	mux.HandleFunc("/api/recommend", noStore(handleRecommend))
+
+	// Step-by-step grid search traces for the Playground
+	mux.HandleFunc("/api/simulate", noStore(handleSimulate))
+
+	// Runs an algorithm's pseudo code, tracing variables per statement
+	mux.HandleFunc("/api/run", noStore(handleRun))
 
 	// Submission routes
-	mux.HandleFunc("/api/captcha", handleCaptcha)
-	mux.HandleFunc("/api/submit", handleSubmit)
+	mux.HandleFunc("/api/captcha", noStore(handleCaptcha))
+	mux.HandleFunc("/api/submit", noStore(handleSubmit))
 
 	// Admin routes (protected)
 	mux.HandleFunc("/api/admin/submissions", adminAuth(handleAdminSubmissions))
 	mux.HandleFunc("/api/admin/approve/", adminAuth(handleAdminApprove))
 	mux.HandleFunc("/api/admin/reject/", adminAuth(handleAdminReject))
+	mux.HandleFunc("/api/admin/audit", adminAuth(handleAdminAudit))
+	mux.HandleFunc("/api/admin/spam", adminAuth(handleAdminSpam))
+	mux.HandleFunc("/api/admin/reload", adminAuth(handleAdminReload))
+	mux.HandleFunc("/api/admin/daily", adminAuth(handleAdminDaily))
+	mux.HandleFunc("/api/admin/comparisons", adminAuth(handleAdminComparisons))
+	mux.HandleFunc("/api/admin/comparisons/", adminAuth(handleAdminComparisonByID))
+
+	// Prometheus metrics
+	mux.HandleFunc("/metrics", handleMetrics)
+
+	// Serve the frontend for production
+	mux.Handle("/", site)
+
+	// Middleware chain: metrics -> request ID -> logging -> security headers -> rate limit -> CORS -> compression -> handler
+	handler := metricsMiddleware(mux, requestIDMiddleware(loggingMiddleware(securityHeadersMiddleware(rateLimitMiddleware(corsMiddleware(compressMiddleware(mux)))))))
+
+	slog.Info("Server starting", "port", c.Port, "data_dir", c.DataDir)
+
+	// Security warning for default credentials (only reachable in dev mode)
+	if c.Admin.Pass == defaultAdminPass {
+		slog.Warn("Using default admin password! Set ADMIN_PASS environment variable for production.")
+	}
+	if captchaSecretGenerated {
+		slog.Warn("CAPTCHA_SECRET not set, using a random secret. Captchas will not survive restarts or work across replicas.")
+	}
+	if ipHashSecretGenerated {
+		slog.Warn("IP_HASH_SECRET not set, using a random secret. Submission bursts will not be matched across restarts or replicas.")
+	}
+
+	// Stop on SIGINT/SIGTERM. Background workers share the same context.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Serve static files for production
-	mux.HandleFunc("/", handleStatic)
+	var workers sync.WaitGroup
+	runEvery(ctx, &workers, time.Minute, cleanupLimiters)
+	// Forget used captcha tokens once they have expired
+	runEvery(ctx, &workers, 5*time.Minute, func() {
+		current().captchaSigner.used.cleanup()
+	})
+	// Reload configuration and seed data on SIGHUP
+	reloader = &Reloader{args: args}
+	reloader.watchSignal(ctx, &workers)
+
+	readTimeout := time.Duration(c.Server.ReadTimeout)
+	srv := &http.Server{
+		Addr:              ":" + c.Port,
+		Handler:           handler,
+		ReadHeaderTimeout: min(readTimeout, 5*time.Second),
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      time.Duration(c.Server.WriteTimeout),
+		IdleTimeout:       time.Duration(c.Server.IdleTimeout),
+	}
 
-	// Middleware chain: logging -> security headers -> rate limit -> CORS -> handler
-	handler := loggingMiddleware(securityHeadersMiddleware(rateLimitMiddleware(apiLimiter)(corsMiddleware(mux))))
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- srv.ListenAndServe()
+	}()
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	select {
+	case err := <-serverErr:
+		slog.Error("Server stopped", "err", err)
+		os.Exit(1)
+	case <-ctx.Done():
 	}
+	stop() // A second signal terminates immediately
+
+	shutdownTimeout := time.Duration(current().cfg.Server.ShutdownTimeout)
+	slog.Info("Shutting down, draining connections", "timeout", shutdownTimeout)
+	drainCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(drainCtx); err != nil {
+		slog.Error("Connections did not drain in time", "err", err)
+	}
+	workers.Wait()
 
-	log.Printf("Server starting on port %s", port)
-	if dataDir != "" {
-		log.Printf("Data directory: %s", dataDir)
+	// Final flush; takes the database lock, so it waits for any save in progress
+	if err := db.Save(); err != nil {
+		slog.Error("Final save failed", "file", db.dataFile, "err", err)
+		os.Exit(1)
 	}
+	slog.Info("Server stopped")
+}
 
-	// Security warning for default credentials
-	if adminPass == "changeme" {
-		log.Println("WARNING: Using default admin password! Set ADMIN_PASS environment variable for production.")
+// runEvery calls fn every interval until ctx is cancelled
+func runEvery(ctx context.Context, wg *sync.WaitGroup, interval time.Duration, fn func()) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				fn()
+			}
+		}
+	}()
+}
+
+// writeFileAtomic writes data to a temp file in the same directory and
+// renames it over path, so a crash mid-write never leaves a truncated file
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
 	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
 
-	log.Fatal(http.ListenAndServe(":"+port, handler))
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
 }
 
 // responseWriter wraps http.ResponseWriter to capture status code
@@ -470,34 +665,6 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
-func loggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-
-		wrapped := &responseWriter{ResponseWriter: w, status: http.StatusOK}
-		next.ServeHTTP(wrapped, r)
-
-		ip := getClientIP(r)
-		userAgent := r.Header.Get("User-Agent")
-		cfRay := r.Header.Get("CF-Ray") // Cloudflare Ray ID for request tracing
-
-		// Enhanced logging with optional Cloudflare tracing
-		if cfRay != "" {
-			log.Printf("%s %s %s %d %s [ray:%s] [ua:%s]", ip, r.Method, r.URL.Path, wrapped.status, time.Since(start), cfRay, truncateUA(userAgent))
-		} else {
-			log.Printf("%s %s %s %d %s [ua:%s]", ip, r.Method, r.URL.Path, wrapped.status, time.Since(start), truncateUA(userAgent))
-		}
-	})
-}
-
-// truncateUA truncates User-Agent to a reasonable length for logging
-func truncateUA(ua string) string {
-	if len(ua) > 100 {
-		return ua[:100] + "..."
-	}
-	return ua
-}
-
 func securityHeadersMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Security headers
@@ -515,13 +682,12 @@ func securityHeadersMiddleware(next http.Handler) http.Handler {
 }
 
 func corsMiddleware(next http.Handler) http.Handler {
-	// Get allowed origin from environment, default to * for development
-	allowedOrigin := getEnv("CORS_ORIGIN", "*")
-
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		// Allowed origin from config, default to * for development
+		w.Header().Set("Access-Control-Allow-Origin", current().cfg.CORSOrigin)
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Request-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After, X-Request-ID")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -532,65 +698,14 @@ func corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-func rateLimitMiddleware(limiter *RateLimiter) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			ip := getClientIP(r)
-
-			if !limiter.Allow(ip) {
-				http.Error(w, "Rate limit exceeded. Please try again later.", http.StatusTooManyRequests)
-				return
-			}
-
-			next.ServeHTTP(w, r)
-		})
-	}
-}
-
-// clientIPHeaders defines the priority order for client IP detection.
-// Headers are checked in order, with the first non-empty value used.
-var clientIPHeaders = []string{
-	"CF-Connecting-IP", // Cloudflare
-	"True-Client-IP",   // Cloudflare Enterprise / Akamai
-	"X-Real-IP",        // Nginx proxy
-	"X-Forwarded-For",  // Standard proxy header (may contain comma-separated list)
-}
-
-// getClientIP extracts the client IP from the request, checking various
-// proxy headers in priority order before falling back to RemoteAddr.
-func getClientIP(r *http.Request) string {
-	for _, header := range clientIPHeaders {
-		if ip := r.Header.Get(header); ip != "" {
-			// X-Forwarded-For can contain multiple IPs; take the first (client) IP
-			if idx := strings.Index(ip, ","); idx != -1 {
-				ip = strings.TrimSpace(ip[:idx])
-			}
-			return strings.TrimSpace(ip)
-		}
-	}
-
-	// Fallback to RemoteAddr, stripping port if present
-	ip := r.RemoteAddr
-	if idx := strings.LastIndex(ip, ":"); idx != -1 {
-		// Check if this is IPv6 (contains multiple colons)
-		if strings.Count(ip, ":") > 1 {
-			// IPv6 address - check for bracket notation [::1]:port
-			if bracketIdx := strings.LastIndex(ip, "]"); bracketIdx != -1 {
-				ip = ip[1:bracketIdx] // Remove brackets
-			}
-		} else {
-			ip = ip[:idx] // IPv4 - just remove port
-		}
-	}
-	return ip
-}
-
 func adminAuth(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Rate limit admin login attempts to prevent brute force
+		w.Header().Set("Cache-Control", cacheNoStore)
+
+		// Rate limit admin login attempts to prevent brute force. API keys
+		// don't exempt these.
 		ip := getClientIP(r)
-		if !adminLimiter.Allow(ip) {
-			http.Error(w, "Too many login attempts. Please try again later.", http.StatusTooManyRequests)
+		if !checkRateLimit(w, r, current().adminLimiter, "Too many login attempts. Please try again later.") {
 			return
 		}
 
@@ -603,18 +718,26 @@ func adminAuth(next http.HandlerFunc) http.HandlerFunc {
 
 		userHash := sha256.Sum256([]byte(user))
 		passHash := sha256.Sum256([]byte(pass))
-		expectedUserHash := sha256.Sum256([]byte(adminUser))
-		expectedPassHash := sha256.Sum256([]byte(adminPass))
+		admin := current().cfg.Admin
+		expectedUserHash := sha256.Sum256([]byte(admin.User))
+		expectedPassHash := sha256.Sum256([]byte(admin.Pass))
 
 		userMatch := subtle.ConstantTimeCompare(userHash[:], expectedUserHash[:]) == 1
 		passMatch := subtle.ConstantTimeCompare(passHash[:], expectedPassHash[:]) == 1
 
 		if !userMatch || !passMatch {
+			if err := auditLog.Record(AuditEntry{Actor: user, IP: ip, Action: auditLoginFailure}); err != nil {
+				requestLogger(r).Error("Failed to write audit entry", "action", auditLoginFailure, "err", err)
+			}
 			w.Header().Set("WWW-Authenticate", `Basic realm="Admin"`)
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
+		if err := auditLog.RecordLogin(user, ip); err != nil {
+			requestLogger(r).Error("Failed to write audit entry", "action", auditLoginSuccess, "err", err)
+		}
+
 		next(w, r)
 	}
 }
@@ -633,6 +756,36 @@ func handleAlgorithms(w http.ResponseWriter, r *http.Request) {
 	search := strings.ToLower(query.Get("search"))
 	difficulty := query.Get("difficulty")
 
+	fields, err := parseProjection(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	complexity, err := parseComplexityFilter(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// ?ids= fetches specific algorithms, returned in the order requested
+	var ids []string
+	if query.Has("ids") {
+		if ids, err = parseIDs(query.Get("ids")); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		byID := make(map[string]Algorithm, len(algorithms))
+		for _, algo := range algorithms {
+			byID[algo.ID] = algo
+		}
+		algorithms = make([]Algorithm, 0, len(ids))
+		for _, id := range ids {
+			if algo, ok := byID[id]; ok {
+				algorithms = append(algorithms, algo)
+			}
+		}
+	}
+
 	filtered := make([]Algorithm, 0)
 	for _, algo := range algorithms {
 		if category != "" && algo.Category != category {
@@ -667,10 +820,14 @@ func handleAlgorithms(w http.ResponseWriter, r *http.Request) {
 				continue
 			}
 		}
+		if !complexity.match(algo) {
+			continue
+		}
 		filtered = append(filtered, algo)
 	}
+	complexity.sort(filtered)
 
-	respondJSON(w, filtered)
+	respondJSON(w, projectAlgorithms(filtered, fields))
 }
 
 func handleAlgorithmByID(w http.ResponseWriter, r *http.Request) {
@@ -685,13 +842,24 @@ func handleAlgorithmByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	fields, err := parseProjection(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	algo := db.GetAlgorithmByID(id)
 	if algo == nil {
 		http.Error(w, "Algorithm not found", http.StatusNotFound)
 		return
 	}
+	// algo points into the database; narrow a copy
+	selected := *algo
+	if lang := r.URL.Query().Get("lang"); lang != "" && !selectImplementation(w, &selected, lang) {
+		return
+	}
 
-	respondJSON(w, algo)
+	respondJSON(w, projectAlgorithm(selected, fields))
 }
 
 func handleCategories(w http.ResponseWriter, r *http.Request) {
@@ -742,18 +910,20 @@ func handleCaptcha(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	captcha := db.CreateCaptcha()
-	respondJSON(w, map[string]string{
-		"id":       captcha.ID,
-		"question": captcha.Question,
-	})
+	// The type comes from config only; letting clients choose would let
+	// bots skip the proof of work
+	s := current()
+	captcha := s.captchaSigner.Create(captchaProviders[s.captchaType])
+	respondJSON(w, captcha.public())
 }
 
 type SubmitRequest struct {
-	CaptchaID     string    `json:"captchaId"`
-	CaptchaAnswer int       `json:"captchaAnswer"`
-	SubmittedBy   string    `json:"submittedBy"`
-	Algorithm     Algorithm `json:"algorithm"`
+	CaptchaID       string    `json:"captchaId"`
+	CaptchaAnswer   int       `json:"captchaAnswer"`   // arithmetic
+	CaptchaSolution string    `json:"captchaSolution"` // proof of work
+	SubmittedBy     string    `json:"submittedBy"`
+	Algorithm       Algorithm `json:"algorithm"`
+	Website         string    `json:"website"` // honeypot; hidden from humans
 }
 
 func handleSubmit(w http.ResponseWriter, r *http.Request) {
@@ -762,10 +932,8 @@ func handleSubmit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Rate limit submissions
-	ip := getClientIP(r)
-	if !submitLimiter.Allow(ip) {
-		http.Error(w, "Too many submissions. Please try again later.", http.StatusTooManyRequests)
+	// Rate limit submissions, unless the client has an exempt API key
+	if !hasExemptAPIKey(r) && !checkRateLimit(w, r, current().submitLimiter, "Too many submissions. Please try again later.") {
 		return
 	}
 
@@ -779,7 +947,11 @@ func handleSubmit(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate captcha
-	if !db.ValidateCaptcha(req.CaptchaID, req.CaptchaAnswer) {
+	answer := req.CaptchaSolution
+	if answer == "" {
+		answer = strconv.Itoa(req.CaptchaAnswer)
+	}
+	if s := current(); !s.captchaSigner.Validate(req.CaptchaID, s.captchaType, answer) {
 		http.Error(w, "Invalid or expired captcha", http.StatusBadRequest)
 		return
 	}
@@ -792,28 +964,87 @@ func handleSubmit(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate field lengths to prevent abuse
-	if len(req.Algorithm.Name) > maxNameLength {
+	limits := current().cfg.Limits
+	if len(req.Algorithm.Name) > limits.MaxNameLength {
 		http.Error(w, "Name exceeds maximum length", http.StatusBadRequest)
 		return
 	}
-	if len(req.Algorithm.Description) > maxDescriptionLength {
+	if len(req.Algorithm.Description) > limits.MaxDescriptionLength {
 		http.Error(w, "Description exceeds maximum length", http.StatusBadRequest)
 		return
 	}
-	if len(req.Algorithm.PseudoCode) > maxPseudoCodeLength {
+	if len(req.Algorithm.PseudoCode) > limits.MaxPseudoCodeLength {
 		http.Error(w, "Pseudo code exceeds maximum length", http.StatusBadRequest)
 		return
 	}
-	if len(req.Algorithm.Tags) > maxArrayLength {
+	if len(req.Algorithm.Tags) > limits.MaxArrayLength {
 		http.Error(w, "Too many tags", http.StatusBadRequest)
 		return
 	}
-	if len(req.Algorithm.WhenToUse) > maxArrayLength {
+	if len(req.Algorithm.Keywords) > limits.MaxArrayLength {
+		http.Error(w, "Too many keywords", http.StatusBadRequest)
+		return
+	}
+	if len(req.Algorithm.WhenToUse) > limits.MaxArrayLength {
 		http.Error(w, "Too many 'when to use' items", http.StatusBadRequest)
 		return
 	}
 
-	submissionID := db.AddSubmission(req.Algorithm, req.SubmittedBy)
+	// AoC examples may arrive as "2022 Day 12 - Hill Climbing" lines;
+	// anything that doesn't name a puzzle is kept as a note
+	normalizeAoC(&req.Algorithm)
+	if len(req.Algorithm.AoCExamples)+len(req.Algorithm.AoCNotes) > limits.MaxArrayLength {
+		http.Error(w, "Too many AoC examples", http.StatusBadRequest)
+		return
+	}
+	for _, ref := range req.Algorithm.AoCExamples {
+		if err := validateAoCReference(ref); err != nil {
+			http.Error(w, "Invalid AoC example: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := validateComplexity(req.Algorithm.Complexity); err != nil {
+		http.Error(w, "Invalid complexity: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Implementations are unverified until a reviewer says otherwise
+	normalizeImplementations(req.Algorithm.Implementations)
+	if err := validateImplementations(req.Algorithm.Implementations); err != nil {
+		http.Error(w, "Invalid implementation: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	for i := range req.Algorithm.Implementations {
+		impl := &req.Algorithm.Implementations[i]
+		impl.Tested = false
+		if impl.Author == "" {
+			impl.Author = req.SubmittedBy
+		}
+	}
+
+	// Lint findings are stored for reviewers and never reject a submission
+	lint := pseudo.Lint(req.Algorithm.PseudoCode)
+
+	// Score for spam. Quarantined submissions get the same response so bots
+	// can't tell they were caught.
+	ipHash := hashIP(getClientIP(r))
+	spam := EvaluateSpam(&SpamInput{
+		Request:     &req,
+		IPHash:      ipHash,
+		ContentHash: contentHash(req.Algorithm),
+		Settings:    db.GetSpamSettings(),
+		Now:         time.Now(),
+	})
+	if spam.Quarantined {
+		requestLogger(r).Warn("Quarantined submission", "ip_hash", ipHash, "score", spam.Score, "rules", spam.Triggered)
+	}
+
+	submissionID, err := db.AddSubmission(req.Algorithm, req.SubmittedBy, ipHash, spam, lint)
+	if err != nil {
+		requestLogger(r).Error("Failed to save submission", "submission_id", submissionID, "err", err)
+		http.Error(w, "Failed to save submission", http.StatusInternalServerError)
+		return
+	}
 
 	respondJSON(w, map[string]string{
 		"message":      "Algorithm submitted for review",
@@ -827,7 +1058,12 @@ func handleAdminSubmissions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	submissions := db.GetPendingSubmissions()
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = statusPending
+	}
+
+	submissions := db.GetSubmissionsByStatus(status)
 	respondJSON(w, submissions)
 }
 
@@ -843,11 +1079,23 @@ func handleAdminApprove(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := db.ApproveSubmission(id); err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	// The body is optional: {"tested": ["go", "python"]}
+	r.Body = http.MaxBytesReader(w, r.Body, 4<<10)
+	var review struct {
+		Tested []string `json:"tested"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
+	algoID, err := db.ApproveSubmission(id, review.Tested)
+	if err != nil {
+		respondSubmissionError(w, r, id, err)
+		return
+	}
+	recordAudit(r, auditApprove, id, algoID)
+
 	respondJSON(w, map[string]string{"message": "Submission approved"})
 }
 
@@ -864,27 +1112,32 @@ func handleAdminReject(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := db.RejectSubmission(id); err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		respondSubmissionError(w, r, id, err)
 		return
 	}
+	recordAudit(r, auditReject, id, "")
 
 	respondJSON(w, map[string]string{"message": "Submission rejected"})
 }
 
-func handleStatic(w http.ResponseWriter, r *http.Request) {
-	if _, err := os.Stat("./static"); os.IsNotExist(err) {
-		http.Error(w, "Frontend not built", http.StatusNotFound)
+// respondSubmissionError maps a review error to a response, logging
+// anything other than a missing submission
+func respondSubmissionError(w http.ResponseWriter, r *http.Request, id string, err error) {
+	if errors.Is(err, errSubmissionNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
-
-	fs := http.FileServer(http.Dir("./static"))
-	path := r.URL.Path
-	if path != "/" && !strings.Contains(path, ".") {
-		http.ServeFile(w, r, "./static/index.html")
+	var cycle *prerequisiteCycleError
+	if errors.As(err, &cycle) {
+		http.Error(w, err.Error(), http.StatusConflict)
 		return
 	}
-
-	fs.ServeHTTP(w, r)
+	if errors.Is(err, errInvalidReview) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	requestLogger(r).Error("Failed to save review", "submission_id", id, "err", err)
+	http.Error(w, "Failed to save review", http.StatusInternalServerError)
 }
 
 func respondJSON(w http.ResponseWriter, data interface{}) {
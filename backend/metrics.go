@@ -0,0 +1,317 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A minimal Prometheus text-format (version 0.0.4) implementation, enough
+// for counters, histograms and scrape-time gauges without pulling in the
+// client library.
+
+// defaultLatencyBuckets are upper bounds in seconds, matching the Prometheus
+// client defaults
+var defaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type metric interface {
+	write(w io.Writer)
+}
+
+// MetricsRegistry holds metrics in registration order
+type MetricsRegistry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+func (reg *MetricsRegistry) register(m metric) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.metrics = append(reg.metrics, m)
+}
+
+func (reg *MetricsRegistry) Write(w io.Writer) {
+	reg.mu.Lock()
+	metrics := append([]metric(nil), reg.metrics...)
+	reg.mu.Unlock()
+
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+// labelKey joins label values into a map key; the separator can't appear in
+// valid UTF-8 text
+func labelKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+func escapeLabelValue(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `"`, `\"`)
+	return strings.ReplaceAll(v, "\n", `\n`)
+}
+
+// formatLabels renders {a="x",b="y"}; extra is appended as-is (used for le)
+func formatLabels(names, values []string, extra string) string {
+	if len(names) == 0 && extra == "" {
+		return ""
+	}
+	parts := make([]string, 0, len(names)+1)
+	for i, name := range names {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, name, escapeLabelValue(values[i])))
+	}
+	if extra != "" {
+		parts = append(parts, extra)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func writeHeader(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// CounterVec is a monotonically increasing counter partitioned by labels
+type CounterVec struct {
+	mu     sync.Mutex
+	name   string
+	help   string
+	labels []string
+	values map[string]float64
+	keys   map[string][]string
+}
+
+func (reg *MetricsRegistry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		name:   name,
+		help:   help,
+		labels: labels,
+		values: make(map[string]float64),
+		keys:   make(map[string][]string),
+	}
+	reg.register(c)
+	return c
+}
+
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	key := labelKey(labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.keys[key]; !ok {
+		c.keys[key] = append([]string(nil), labelValues...)
+	}
+	c.values[key] += v
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	writeHeader(w, c.name, c.help, "counter")
+	for _, key := range sortedKeys(c.keys) {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, c.keys[key], ""), formatFloat(c.values[key]))
+	}
+}
+
+// HistogramVec tracks observations in cumulative buckets, partitioned by labels
+type HistogramVec struct {
+	mu      sync.Mutex
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64 // per bucket, non-cumulative
+	count       uint64
+	sum         float64
+}
+
+func (reg *MetricsRegistry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*histogramSeries),
+	}
+	reg.register(h)
+	return h
+}
+
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := labelKey(labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{
+			labelValues: append([]string(nil), labelValues...),
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.series[key] = s
+	}
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+			break
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	writeHeader(w, h.name, h.help, "histogram")
+	if len(h.labels) == 0 && len(h.series) == 0 {
+		// Unlabelled histograms are always exported, even before the first observation
+		h.series[""] = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+	}
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := h.series[key]
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			le := fmt.Sprintf(`le="%s"`, formatFloat(upper))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, s.labelValues, le), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, s.labelValues, `le="+Inf"`), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, s.labelValues, ""), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, s.labelValues, ""), s.count)
+	}
+}
+
+// GaugeFunc reports a value computed at scrape time
+type GaugeFunc struct {
+	name string
+	help string
+	fn   func() float64
+}
+
+func (reg *MetricsRegistry) NewGaugeFunc(name, help string, fn func() float64) {
+	reg.register(&GaugeFunc{name: name, help: help, fn: fn})
+}
+
+func (g *GaugeFunc) write(w io.Writer) {
+	writeHeader(w, g.name, g.help, "gauge")
+	fmt.Fprintf(w, "%s %s\n", g.name, formatFloat(g.fn()))
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Application metrics
+var (
+	metrics = &MetricsRegistry{}
+
+	httpRequestsTotal = metrics.NewCounterVec("aoc_http_requests_total",
+		"HTTP requests by route, method and status.", "route", "method", "status")
+	httpRequestDuration = metrics.NewHistogramVec("aoc_http_request_duration_seconds",
+		"HTTP request latency by route and status.", defaultLatencyBuckets, "route", "status")
+	rateLimitRejections = metrics.NewCounterVec("aoc_rate_limit_rejections_total",
+		"Requests rejected by each rate limiter.", "limiter")
+	captchaIssued = metrics.NewCounterVec("aoc_captcha_issued_total",
+		"Captcha challenges issued by type.", "type")
+	captchaValidated = metrics.NewCounterVec("aoc_captcha_validated_total",
+		"Captcha answers accepted by type.", "type")
+	captchaFailed = metrics.NewCounterVec("aoc_captcha_failed_total",
+		"Captcha answers rejected by reason.", "reason")
+	dataSaveDuration = metrics.NewHistogramVec("aoc_data_save_duration_seconds",
+		"Time taken to write the data file.", defaultLatencyBuckets)
+	configReloads = metrics.NewCounterVec("aoc_reloads_total",
+		"Configuration and seed data reloads by result.", "result")
+)
+
+func init() {
+	metrics.NewGaugeFunc("aoc_pending_submissions", "Submissions awaiting review.", func() float64 {
+		return float64(len(db.GetSubmissionsByStatus(statusPending)))
+	})
+	metrics.NewGaugeFunc("aoc_quarantined_submissions", "Submissions quarantined as suspected spam.", func() float64 {
+		return float64(len(db.GetSubmissionsByStatus(statusQuarantined)))
+	})
+	metrics.NewGaugeFunc("aoc_algorithms", "Approved algorithms in the catalog.", func() float64 {
+		return float64(len(db.GetApprovedAlgorithms()))
+	})
+}
+
+// metricsMiddleware records request counts and latency. Routes are labelled
+// with the ServeMux pattern rather than the raw path to keep cardinality low.
+func metricsMiddleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		wrapped := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(wrapped, r)
+
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(wrapped.status)
+		httpRequestsTotal.Inc(route, methodLabel(r.Method), status)
+		httpRequestDuration.Observe(time.Since(start).Seconds(), route, status)
+	})
+}
+
+// methodLabel maps the standard methods to themselves and anything else to
+// "other", since clients can send any token as a method
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return method
+	}
+	return "other"
+}
+
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	metrics.Write(w)
+}
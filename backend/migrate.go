@@ -0,0 +1,38 @@
+package main
+
+import "log/slog"
+
+// migrations upgrade data.json one schema version at a time; the key is the
+// version a step produces. Version 1 is the first that was recorded.
+var migrations = map[int]func(d *Database){
+	2: migrateAoCReferences,
+}
+
+// Migrate brings data loaded from an older build up to schemaVersion and
+// saves it
+func (d *Database) Migrate() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	from := d.SchemaVersion
+	for version := from + 1; version <= schemaVersion; version++ {
+		if migrate, ok := migrations[version]; ok {
+			migrate(d)
+		}
+	}
+	d.catalogChanged()
+	slog.Info("Migrated data.json", "from", from, "to", schemaVersion)
+	return d.saveUnlocked()
+}
+
+// migrateAoCReferences splits the old free-text AoC examples into puzzle
+// references and notes. The strings themselves were parsed on load; see
+// AoCReference.UnmarshalJSON.
+func migrateAoCReferences(d *Database) {
+	for i := range d.Algorithms {
+		normalizeAoC(&d.Algorithms[i])
+	}
+	for i := range d.Submissions {
+		normalizeAoC(&d.Submissions[i].Algorithm)
+	}
+}
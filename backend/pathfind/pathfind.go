@@ -0,0 +1,258 @@
+// Package pathfind runs grid searches and records every step, for replaying
+// them in the Playground. Grids use 0 for open cells and 1 for walls, with
+// optional per-cell costs for the weighted searches.
+package pathfind
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Algorithm names accepted by Run
+const (
+	BFS       = "bfs"
+	DFS       = "dfs"
+	Dijkstra  = "dijkstra"
+	AStar     = "astar"
+	FloodFill = "floodfill"
+)
+
+// Algorithms lists every supported algorithm
+var Algorithms = []string{BFS, DFS, Dijkstra, AStar, FloodFill}
+
+// Cell values
+const (
+	Open = 0
+	Wall = 1
+)
+
+// MaxWeight caps the cost of entering a cell, keeping path costs small
+const MaxWeight = 1000
+
+var (
+	ErrUnknownAlgorithm = errors.New("unknown algorithm")
+	ErrInvalidProblem   = errors.New("invalid problem")
+)
+
+// Cell is a grid position
+type Cell struct {
+	Row int `json:"row"`
+	Col int `json:"col"`
+}
+
+func (c Cell) String() string {
+	return fmt.Sprintf("(%d, %d)", c.Row, c.Col)
+}
+
+// Problem is a grid to search. Goal is optional for flood fill, which fills
+// the region around Start. Weights, when set, is the cost of entering each
+// cell and must match the grid's shape; otherwise every move costs 1.
+type Problem struct {
+	Grid    [][]int
+	Weights [][]int
+	Start   Cell
+	Goal    *Cell
+}
+
+// Limits bound the work a single run may do. MaxTraceCells caps the total
+// size of the recorded frontier and visited snapshots.
+type Limits struct {
+	MaxCells      int
+	MaxSteps      int
+	MaxTraceCells int
+}
+
+// Step is one recorded moment of a search
+type Step struct {
+	Action      string `json:"action"` // start, visit, discover, found or exhausted
+	Description string `json:"description"`
+	Current     *Cell  `json:"current,omitempty"` // the cell being expanded
+	Cell        *Cell  `json:"cell,omitempty"`    // the neighbor just discovered
+	Distance    int    `json:"distance"`          // cost from start to Current
+	Frontier    []Cell `json:"frontier"`          // in the order it will be taken
+	Visited     []Cell `json:"visited"`           // in the order cells were reached
+}
+
+// Step actions
+const (
+	ActionStart     = "start"
+	ActionVisit     = "visit"
+	ActionDiscover  = "discover"
+	ActionFound     = "found"
+	ActionExhausted = "exhausted"
+)
+
+// Trace is the outcome of a run. The search always runs to completion;
+// once a limit is hit, later steps are counted but not recorded.
+type Trace struct {
+	Algorithm  string `json:"algorithm"`
+	Found      bool   `json:"found"`
+	Path       []Cell `json:"path"`           // start to goal, when found
+	Cost       int    `json:"cost,omitempty"` // of Path
+	Reached    int    `json:"reached"`        // cells visited
+	Steps      []Step `json:"steps"`
+	TotalSteps int    `json:"totalSteps"`
+	Truncated  bool   `json:"truncated"`
+}
+
+// Validate checks the problem's shape and endpoints against limits
+func (p *Problem) Validate(algorithm string, limits Limits) error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	rows := len(p.Grid)
+	if rows == 0 || len(p.Grid[0]) == 0 {
+		return fmt.Errorf("%w: grid must not be empty", ErrInvalidProblem)
+	}
+	cols := len(p.Grid[0])
+	if rows*cols > limits.MaxCells {
+		return fmt.Errorf("%w: grid has %d cells, more than the limit of %d", ErrInvalidProblem, rows*cols, limits.MaxCells)
+	}
+	for r, row := range p.Grid {
+		if len(row) != cols {
+			fail("grid: row %d has %d cells, expected %d", r, len(row), cols)
+			continue
+		}
+		for c, v := range row {
+			if v != Open && v != Wall {
+				fail("grid: cell (%d, %d) must be %d (open) or %d (wall)", r, c, Open, Wall)
+			}
+		}
+	}
+
+	if p.Weights != nil {
+		if len(p.Weights) != rows {
+			fail("weights: must have %d rows", rows)
+		}
+		for r, row := range p.Weights {
+			if len(row) != cols {
+				fail("weights: row %d must have %d cells", r, cols)
+				continue
+			}
+			for c, w := range row {
+				if w < 1 || w > MaxWeight {
+					fail("weights: cell (%d, %d) must be between 1 and %d", r, c, MaxWeight)
+				}
+			}
+		}
+	}
+
+	inside := func(cell Cell) bool {
+		return cell.Row >= 0 && cell.Row < rows && cell.Col >= 0 && cell.Col < cols
+	}
+	if !inside(p.Start) {
+		fail("start %s is outside the grid", p.Start)
+	} else if len(p.Grid[p.Start.Row]) == cols && p.Grid[p.Start.Row][p.Start.Col] == Wall {
+		fail("start %s is a wall", p.Start)
+	}
+	switch {
+	case p.Goal == nil:
+		if algorithm != FloodFill {
+			fail("goal is required for %s", algorithm)
+		}
+	case !inside(*p.Goal):
+		fail("goal %s is outside the grid", *p.Goal)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w:\n%w", ErrInvalidProblem, errors.Join(errs...))
+	}
+	return nil
+}
+
+// Run validates p and searches it with algorithm, recording each step
+func Run(algorithm string, p Problem, limits Limits) (*Trace, error) {
+	search, ok := searches[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownAlgorithm, algorithm)
+	}
+	if err := p.Validate(algorithm, limits); err != nil {
+		return nil, err
+	}
+
+	s := &searcher{
+		problem: p,
+		rows:    len(p.Grid),
+		cols:    len(p.Grid[0]),
+		limits:  limits,
+		trace:   &Trace{Algorithm: algorithm, Path: []Cell{}, Steps: []Step{}},
+	}
+	search(s)
+	s.trace.Reached = len(s.visited)
+	return s.trace, nil
+}
+
+// searcher holds the state shared by every search
+type searcher struct {
+	problem    Problem
+	rows, cols int
+	limits     Limits
+	trace      *Trace
+
+	visited    []Cell // append-only, so snapshots can share it
+	traceCells int
+}
+
+// record appends a step unless a limit has been reached. frontier is only
+// called for steps that are kept.
+func (s *searcher) record(step Step, frontier func() []Cell) {
+	s.trace.TotalSteps++
+	if s.trace.Truncated {
+		return
+	}
+	if len(s.trace.Steps) >= s.limits.MaxSteps {
+		s.trace.Truncated = true
+		return
+	}
+	step.Frontier = frontier()
+	step.Visited = s.visited[:len(s.visited):len(s.visited)]
+	if s.traceCells += len(step.Frontier) + len(step.Visited); s.traceCells > s.limits.MaxTraceCells {
+		s.trace.Truncated = true
+		return
+	}
+	s.trace.Steps = append(s.trace.Steps, step)
+}
+
+func (s *searcher) isGoal(c Cell) bool {
+	return s.problem.Goal != nil && c == *s.problem.Goal
+}
+
+func (s *searcher) weight(c Cell) int {
+	if s.problem.Weights == nil {
+		return 1
+	}
+	return s.problem.Weights[c.Row][c.Col]
+}
+
+// neighbors returns the open cells next to c: up, down, left, right
+func (s *searcher) neighbors(c Cell) []Cell {
+	out := make([]Cell, 0, 4)
+	for _, d := range [...]Cell{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+		n := Cell{c.Row + d.Row, c.Col + d.Col}
+		if n.Row >= 0 && n.Row < s.rows && n.Col >= 0 && n.Col < s.cols && s.problem.Grid[n.Row][n.Col] != Wall {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// finish records the path to end, following parents back to the start
+func (s *searcher) finish(end Cell, cost int, parent map[Cell]Cell) {
+	path := []Cell{end}
+	for c := end; c != s.problem.Start; {
+		c = parent[c]
+		path = append(path, c)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	s.trace.Found = true
+	s.trace.Path = path
+	s.trace.Cost = cost
+}
+
+func (s *searcher) exhausted(description string) {
+	s.record(Step{Action: ActionExhausted, Description: description}, func() []Cell { return []Cell{} })
+}
@@ -0,0 +1,253 @@
+package pathfind
+
+import (
+	"errors"
+	"testing"
+)
+
+var testLimits = Limits{MaxCells: 10000, MaxSteps: 10000, MaxTraceCells: 1000000}
+
+// corridor has a single route from the top left to the bottom left, around
+// two walls
+var corridor = [][]int{
+	{0, 0, 0},
+	{1, 1, 0},
+	{0, 0, 0},
+}
+
+// checkPath fails unless path is a walk over open cells from p.Start to the
+// goal, and returns its cost
+func checkPath(t *testing.T, p Problem, path []Cell) int {
+	t.Helper()
+	if len(path) == 0 || path[0] != p.Start || path[len(path)-1] != *p.Goal {
+		t.Fatalf("path %v doesn't run from %s to %s", path, p.Start, *p.Goal)
+	}
+	cost := 0
+	for i, c := range path {
+		if p.Grid[c.Row][c.Col] == Wall {
+			t.Fatalf("path %v crosses the wall at %s", path, c)
+		}
+		if i == 0 {
+			continue
+		}
+		if prev := path[i-1]; abs(prev.Row-c.Row)+abs(prev.Col-c.Col) != 1 {
+			t.Fatalf("path %v jumps from %s to %s", path, prev, c)
+		}
+		if p.Weights != nil {
+			cost += p.Weights[c.Row][c.Col]
+		} else {
+			cost++
+		}
+	}
+	return cost
+}
+
+func TestRun(t *testing.T) {
+	open := [][]int{
+		{0, 0, 0},
+		{0, 0, 0},
+		{0, 0, 0},
+	}
+	// The cheapest route avoids the middle column
+	costly := [][]int{
+		{1, 9, 1},
+		{1, 9, 1},
+		{1, 1, 1},
+	}
+	walledOff := [][]int{
+		{0, 1, 0},
+		{0, 1, 0},
+	}
+
+	tests := []struct {
+		name    string
+		problem Problem
+		found   bool
+		cost    map[string]int // by algorithm; others only need a valid path
+		reached int
+	}{
+		{
+			name:    "corridor",
+			problem: Problem{Grid: corridor, Start: Cell{0, 0}, Goal: &Cell{2, 0}},
+			found:   true,
+			cost:    map[string]int{BFS: 6, DFS: 6, Dijkstra: 6, AStar: 6, FloodFill: 6},
+		},
+		{
+			name: "weighted corridor",
+			problem: Problem{
+				Grid:    corridor,
+				Weights: [][]int{{1, 1, 1}, {1, 1, 5}, {1, 1, 1}},
+				Start:   Cell{0, 0},
+				Goal:    &Cell{2, 0},
+			},
+			found: true,
+			cost:  map[string]int{BFS: 10, DFS: 10, Dijkstra: 10, AStar: 10},
+		},
+		{
+			name:    "cheapest route",
+			problem: Problem{Grid: open, Weights: costly, Start: Cell{0, 0}, Goal: &Cell{0, 2}},
+			found:   true,
+			cost:    map[string]int{Dijkstra: 6, AStar: 6},
+		},
+		{
+			name:    "shortest route",
+			problem: Problem{Grid: open, Start: Cell{0, 0}, Goal: &Cell{2, 2}},
+			found:   true,
+			cost:    map[string]int{BFS: 4, Dijkstra: 4, AStar: 4, FloodFill: 4},
+		},
+		{
+			name:    "start is goal",
+			problem: Problem{Grid: open, Start: Cell{1, 1}, Goal: &Cell{1, 1}},
+			found:   true,
+			cost:    map[string]int{BFS: 0, DFS: 0, Dijkstra: 0, AStar: 0, FloodFill: 0},
+		},
+		{
+			name:    "unreachable",
+			problem: Problem{Grid: walledOff, Start: Cell{0, 0}, Goal: &Cell{0, 2}},
+			reached: 2,
+		},
+		{
+			name:    "goal on a wall",
+			problem: Problem{Grid: walledOff, Start: Cell{0, 0}, Goal: &Cell{0, 1}},
+			reached: 2,
+		},
+	}
+	for _, tt := range tests {
+		for _, algorithm := range Algorithms {
+			t.Run(tt.name+"/"+algorithm, func(t *testing.T) {
+				trace, err := Run(algorithm, tt.problem, testLimits)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if trace.Found != tt.found || trace.Truncated {
+					t.Fatalf("found %v, truncated %v", trace.Found, trace.Truncated)
+				}
+				last := trace.Steps[len(trace.Steps)-1]
+				if trace.TotalSteps != len(trace.Steps) || trace.Steps[0].Action != ActionStart {
+					t.Errorf("%d steps of %d, starting with %q", len(trace.Steps), trace.TotalSteps, trace.Steps[0].Action)
+				}
+				if !tt.found {
+					if len(trace.Path) != 0 || last.Action != ActionExhausted || trace.Reached != tt.reached {
+						t.Errorf("path %v, last step %q, reached %d", trace.Path, last.Action, trace.Reached)
+					}
+					return
+				}
+
+				cost := checkPath(t, tt.problem, trace.Path)
+				if algorithm != FloodFill && last.Action != ActionFound {
+					t.Errorf("last step %q", last.Action)
+				}
+				if want, ok := tt.cost[algorithm]; ok && cost != want {
+					t.Errorf("path %v costs %d, want %d", trace.Path, cost, want)
+				}
+				// The unweighted searches report path length, the weighted ones cost
+				want := len(trace.Path) - 1
+				if algorithm == Dijkstra || algorithm == AStar {
+					want = cost
+				}
+				if trace.Cost != want {
+					t.Errorf("reported cost %d, want %d", trace.Cost, want)
+				}
+			})
+		}
+	}
+}
+
+func TestRunFloodFill(t *testing.T) {
+	grid := [][]int{
+		{0, 0, 1, 0},
+		{0, 1, 1, 0},
+		{0, 0, 1, 0},
+	}
+	trace, err := Run(FloodFill, Problem{Grid: grid, Start: Cell{0, 0}}, testLimits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trace.Found || trace.Reached != 5 {
+		t.Errorf("found %v, reached %d, want 5", trace.Found, trace.Reached)
+	}
+	last := trace.Steps[len(trace.Steps)-1]
+	if last.Action != ActionExhausted || len(last.Visited) != 5 {
+		t.Errorf("last step %q with %d visited", last.Action, len(last.Visited))
+	}
+}
+
+func TestRunLimits(t *testing.T) {
+	open := make([][]int, 20)
+	for i := range open {
+		open[i] = make([]int, 20)
+	}
+	p := Problem{Grid: open, Start: Cell{0, 0}, Goal: &Cell{19, 19}}
+
+	for _, algorithm := range Algorithms {
+		t.Run(algorithm+"/steps", func(t *testing.T) {
+			trace, err := Run(algorithm, p, Limits{MaxCells: 400, MaxSteps: 5, MaxTraceCells: 1000000})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(trace.Steps) != 5 || !trace.Truncated || trace.TotalSteps <= 5 {
+				t.Errorf("%d steps of %d, truncated %v", len(trace.Steps), trace.TotalSteps, trace.Truncated)
+			}
+			// The search still runs to completion
+			if !trace.Found || checkPath(t, p, trace.Path) < 38 {
+				t.Errorf("found %v, path %v", trace.Found, trace.Path)
+			}
+		})
+
+		t.Run(algorithm+"/trace cells", func(t *testing.T) {
+			const maxTraceCells = 200
+			trace, err := Run(algorithm, p, Limits{MaxCells: 400, MaxSteps: 10000, MaxTraceCells: maxTraceCells})
+			if err != nil {
+				t.Fatal(err)
+			}
+			cells := 0
+			for _, step := range trace.Steps {
+				cells += len(step.Frontier) + len(step.Visited)
+			}
+			if cells > maxTraceCells || !trace.Truncated || len(trace.Steps) == trace.TotalSteps {
+				t.Errorf("%d cells in %d steps of %d, truncated %v", cells, len(trace.Steps), trace.TotalSteps, trace.Truncated)
+			}
+			if !trace.Found {
+				t.Error("not found")
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	goal := &Cell{0, 1}
+	tests := []struct {
+		name      string
+		algorithm string
+		problem   Problem
+		ok        bool
+	}{
+		{"valid", BFS, Problem{Grid: [][]int{{0, 0}}, Goal: goal}, true},
+		{"flood fill without goal", FloodFill, Problem{Grid: [][]int{{0, 0}}}, true},
+		{"empty grid", BFS, Problem{Grid: [][]int{}, Goal: goal}, false},
+		{"empty row", BFS, Problem{Grid: [][]int{{}}, Goal: goal}, false},
+		{"too many cells", BFS, Problem{Grid: [][]int{make([]int, 101)}, Goal: goal}, false},
+		{"ragged rows", BFS, Problem{Grid: [][]int{{0, 0}, {0}}, Goal: goal}, false},
+		{"bad cell", BFS, Problem{Grid: [][]int{{0, 2}}, Goal: goal}, false},
+		{"weights shape", Dijkstra, Problem{Grid: [][]int{{0, 0}}, Weights: [][]int{{1}}, Goal: goal}, false},
+		{"weight too small", Dijkstra, Problem{Grid: [][]int{{0, 0}}, Weights: [][]int{{1, 0}}, Goal: goal}, false},
+		{"weight too large", Dijkstra, Problem{Grid: [][]int{{0, 0}}, Weights: [][]int{{1, MaxWeight + 1}}, Goal: goal}, false},
+		{"start outside", BFS, Problem{Grid: [][]int{{0, 0}}, Start: Cell{1, 0}, Goal: goal}, false},
+		{"start on wall", BFS, Problem{Grid: [][]int{{1, 0}}, Goal: goal}, false},
+		{"goal missing", AStar, Problem{Grid: [][]int{{0, 0}}}, false},
+		{"goal outside", BFS, Problem{Grid: [][]int{{0, 0}}, Goal: &Cell{0, -1}}, false},
+	}
+	for _, tt := range tests {
+		_, err := Run(tt.algorithm, tt.problem, Limits{MaxCells: 100, MaxSteps: 100, MaxTraceCells: 1000})
+		switch {
+		case tt.ok && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case !tt.ok && !errors.Is(err, ErrInvalidProblem):
+			t.Errorf("%s: error %v, want ErrInvalidProblem", tt.name, err)
+		}
+	}
+
+	if _, err := Run("bogus", Problem{Grid: [][]int{{0}}}, testLimits); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("unknown algorithm: %v", err)
+	}
+}
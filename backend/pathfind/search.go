@@ -0,0 +1,304 @@
+package pathfind
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+)
+
+var searches = map[string]func(*searcher){
+	BFS:       (*searcher).bfs,
+	DFS:       (*searcher).dfs,
+	Dijkstra:  func(s *searcher) { s.bestFirst(false) },
+	AStar:     func(s *searcher) { s.bestFirst(true) },
+	FloodFill: (*searcher).floodFill,
+}
+
+// bfs marks cells visited as they are queued, so the first time the goal is
+// dequeued is along a shortest path
+func (s *searcher) bfs() {
+	start := s.problem.Start
+	queue := []Cell{start}
+	dist := map[Cell]int{start: 0}
+	parent := make(map[Cell]Cell)
+	s.visited = append(s.visited, start)
+	frontier := func() []Cell { return append([]Cell{}, queue...) }
+
+	s.record(Step{Action: ActionStart, Description: fmt.Sprintf("Initialize: start at %s", start)}, frontier)
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		s.record(Step{
+			Action:      ActionVisit,
+			Description: fmt.Sprintf("Dequeue cell %s at distance %d", current, dist[current]),
+			Current:     &current,
+			Distance:    dist[current],
+		}, frontier)
+
+		if s.isGoal(current) {
+			s.finish(current, dist[current], parent)
+			s.record(Step{
+				Action:      ActionFound,
+				Description: fmt.Sprintf("Goal found at %s! Distance: %d", current, dist[current]),
+				Current:     &current,
+				Distance:    dist[current],
+			}, frontier)
+			return
+		}
+
+		for _, n := range s.neighbors(current) {
+			if _, seen := dist[n]; seen {
+				continue
+			}
+			dist[n] = dist[current] + 1
+			parent[n] = current
+			s.visited = append(s.visited, n)
+			queue = append(queue, n)
+			s.record(Step{
+				Action:      ActionDiscover,
+				Description: fmt.Sprintf("Add neighbor %s to queue", n),
+				Current:     &current,
+				Cell:        &n,
+				Distance:    dist[current],
+			}, frontier)
+		}
+	}
+	s.exhausted("No path found - queue exhausted")
+}
+
+// dfs marks cells visited as they are popped. A cell may sit on the stack
+// more than once; each entry remembers who pushed it so the path follows
+// the entry that was actually taken.
+func (s *searcher) dfs() {
+	type entry struct {
+		cell, from Cell
+		depth      int
+	}
+	start := s.problem.Start
+	stack := []entry{{cell: start}}
+	seen := make(map[Cell]bool)
+	parent := make(map[Cell]Cell)
+	frontier := func() []Cell {
+		// Top of the stack first, since that is taken next
+		cells := make([]Cell, len(stack))
+		for i, e := range stack {
+			cells[len(stack)-1-i] = e.cell
+		}
+		return cells
+	}
+
+	s.record(Step{Action: ActionStart, Description: fmt.Sprintf("Initialize: start at %s", start)}, frontier)
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		current := top.cell
+		if seen[current] {
+			continue
+		}
+		seen[current] = true
+		if current != start {
+			parent[current] = top.from
+		}
+		s.visited = append(s.visited, current)
+		s.record(Step{
+			Action:      ActionVisit,
+			Description: fmt.Sprintf("Pop and visit cell %s", current),
+			Current:     &current,
+			Distance:    top.depth,
+		}, frontier)
+
+		if s.isGoal(current) {
+			s.finish(current, top.depth, parent)
+			s.record(Step{
+				Action:      ActionFound,
+				Description: fmt.Sprintf("Goal found at %s! Path length: %d", current, top.depth+1),
+				Current:     &current,
+				Distance:    top.depth,
+			}, frontier)
+			return
+		}
+
+		for _, n := range s.neighbors(current) {
+			if seen[n] {
+				continue
+			}
+			stack = append(stack, entry{cell: n, from: current, depth: top.depth + 1})
+			s.record(Step{
+				Action:      ActionDiscover,
+				Description: fmt.Sprintf("Push neighbor %s onto stack", n),
+				Current:     &current,
+				Cell:        &n,
+				Distance:    top.depth,
+			}, frontier)
+		}
+	}
+	s.exhausted("No path found - stack exhausted")
+}
+
+// queueItem is a frontier entry for the weighted searches
+type queueItem struct {
+	cell     Cell
+	cost     int // from the start
+	priority int // cost, plus the heuristic for A*
+	seq      int // insertion order, to break ties stably
+}
+
+type priorityQueue []queueItem
+
+func (q priorityQueue) Len() int { return len(q) }
+func (q priorityQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority < q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+func (q priorityQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *priorityQueue) Push(x interface{}) { *q = append(*q, x.(queueItem)) }
+func (q *priorityQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// bestFirst is Dijkstra's algorithm, or A* when guided. A*'s heuristic is
+// the Manhattan distance times the cheapest cell weight, which never
+// overestimates, so both return a cheapest path.
+func (s *searcher) bestFirst(guided bool) {
+	minWeight := 1
+	if s.problem.Weights != nil {
+		minWeight = MaxWeight
+		for _, row := range s.problem.Weights {
+			for _, w := range row {
+				minWeight = min(minWeight, w)
+			}
+		}
+	}
+	heuristic := func(c Cell) int {
+		if !guided {
+			return 0
+		}
+		goal := *s.problem.Goal
+		return (abs(c.Row-goal.Row) + abs(c.Col-goal.Col)) * minWeight
+	}
+
+	start := s.problem.Start
+	best := map[Cell]int{start: 0}
+	parent := make(map[Cell]Cell)
+	settled := make(map[Cell]bool)
+	queue := &priorityQueue{{cell: start, priority: heuristic(start)}}
+	seq := 1
+	// Entries superseded by a cheaper route stay in the heap; leave them out
+	frontier := func() []Cell {
+		live := make([]queueItem, 0, queue.Len())
+		for _, item := range *queue {
+			if !settled[item.cell] && item.cost == best[item.cell] {
+				live = append(live, item)
+			}
+		}
+		sort.Sort(priorityQueue(live))
+		cells := make([]Cell, len(live))
+		for i, item := range live {
+			cells[i] = item.cell
+		}
+		return cells
+	}
+
+	s.record(Step{Action: ActionStart, Description: fmt.Sprintf("Initialize: start at %s with cost 0", start)}, frontier)
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(queueItem)
+		current := item.cell
+		if settled[current] || item.cost > best[current] {
+			continue
+		}
+		settled[current] = true
+		s.visited = append(s.visited, current)
+		s.record(Step{
+			Action:      ActionVisit,
+			Description: fmt.Sprintf("Pop cell %s with cost %d", current, item.cost),
+			Current:     &current,
+			Distance:    item.cost,
+		}, frontier)
+
+		if s.isGoal(current) {
+			s.finish(current, item.cost, parent)
+			s.record(Step{
+				Action:      ActionFound,
+				Description: fmt.Sprintf("Goal found at %s! Cost: %d", current, item.cost),
+				Current:     &current,
+				Distance:    item.cost,
+			}, frontier)
+			return
+		}
+
+		for _, n := range s.neighbors(current) {
+			cost := item.cost + s.weight(n)
+			if old, ok := best[n]; settled[n] || (ok && old <= cost) {
+				continue
+			}
+			best[n] = cost
+			parent[n] = current
+			heap.Push(queue, queueItem{cell: n, cost: cost, priority: cost + heuristic(n), seq: seq})
+			seq++
+			s.record(Step{
+				Action:      ActionDiscover,
+				Description: fmt.Sprintf("Reach neighbor %s with cost %d", n, cost),
+				Current:     &current,
+				Cell:        &n,
+				Distance:    item.cost,
+			}, frontier)
+		}
+	}
+	s.exhausted("No path found - priority queue exhausted")
+}
+
+// floodFill visits every open cell connected to the start, breadth first.
+// With a goal it also reports the path there, but keeps filling.
+func (s *searcher) floodFill() {
+	start := s.problem.Start
+	queue := []Cell{start}
+	dist := map[Cell]int{start: 0}
+	parent := make(map[Cell]Cell)
+	s.visited = append(s.visited, start)
+	frontier := func() []Cell { return append([]Cell{}, queue...) }
+
+	s.record(Step{Action: ActionStart, Description: fmt.Sprintf("Initialize: fill from %s", start)}, frontier)
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		s.record(Step{
+			Action:      ActionVisit,
+			Description: fmt.Sprintf("Fill cell %s", current),
+			Current:     &current,
+			Distance:    dist[current],
+		}, frontier)
+		if s.isGoal(current) {
+			s.finish(current, dist[current], parent)
+		}
+
+		for _, n := range s.neighbors(current) {
+			if _, seen := dist[n]; seen {
+				continue
+			}
+			dist[n] = dist[current] + 1
+			parent[n] = current
+			s.visited = append(s.visited, n)
+			queue = append(queue, n)
+			s.record(Step{
+				Action:      ActionDiscover,
+				Description: fmt.Sprintf("Queue neighbor %s", n),
+				Current:     &current,
+				Cell:        &n,
+				Distance:    dist[current],
+			}, frontier)
+		}
+	}
+	s.exhausted(fmt.Sprintf("Region filled: %d cells", len(s.visited)))
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// Algorithm views. The summary carries what a listing card needs and leaves
+// out the heavy fields (pseudo code, examples).
+const (
+	viewFull    = "full"
+	viewSummary = "summary"
+)
+
+var summaryFields = []string{"id", "name", "category", "difficulty", "tags", "description"}
+
+// maxBatchIDs caps ?ids= so one request can't ask for an unbounded list
+const maxBatchIDs = 50
+
+// algorithmFields is the set of JSON field names accepted by ?fields=
+var algorithmFields = jsonFieldNames(reflect.TypeOf(Algorithm{}))
+
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// parseProjection reads ?fields= and ?view=. fields takes precedence; the id
+// is always included. A nil result means the full object.
+func parseProjection(query url.Values) ([]string, error) {
+	if list := query.Get("fields"); list != "" {
+		fields := []string{"id"}
+		seen := map[string]bool{"id": true}
+		for _, field := range strings.Split(list, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" || seen[field] {
+				continue
+			}
+			if !algorithmFields[field] {
+				return nil, fmt.Errorf("unknown field %q", field)
+			}
+			seen[field] = true
+			fields = append(fields, field)
+		}
+		return fields, nil
+	}
+
+	switch query.Get("view") {
+	case "", viewFull:
+		return nil, nil
+	case viewSummary:
+		return summaryFields, nil
+	default:
+		return nil, fmt.Errorf("view must be %q or %q", viewSummary, viewFull)
+	}
+}
+
+// splitIDs reads a comma-separated ID list, dropping blanks and duplicates
+// while keeping the given order
+func splitIDs(list string) []string {
+	ids := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, id := range strings.Split(list, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// parseIDs reads the ?ids= list
+func parseIDs(list string) ([]string, error) {
+	ids := splitIDs(list)
+	if len(ids) > maxBatchIDs {
+		return nil, fmt.Errorf("at most %d ids per request", maxBatchIDs)
+	}
+	return ids, nil
+}
+
+// projectAlgorithm returns algo restricted to fields, or algo itself when
+// fields is nil
+func projectAlgorithm(algo Algorithm, fields []string) interface{} {
+	if fields == nil {
+		return algo
+	}
+
+	data, _ := json.Marshal(algo)
+	var all map[string]json.RawMessage
+	json.Unmarshal(data, &all)
+
+	projected := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if value, ok := all[field]; ok {
+			projected[field] = value
+		}
+	}
+	return projected
+}
+
+func projectAlgorithms(algos []Algorithm, fields []string) interface{} {
+	if fields == nil {
+		return algos
+	}
+	projected := make([]interface{}, 0, len(algos))
+	for _, algo := range algos {
+		projected = append(projected, projectAlgorithm(algo, fields))
+	}
+	return projected
+}
@@ -0,0 +1,219 @@
+package pseudo
+
+// Expr is an expression node
+type Expr interface{ exprLine() int }
+
+// Stmt is a statement node
+type Stmt interface{ stmtLine() int }
+
+type pos struct{ line int }
+
+func (p pos) exprLine() int { return p.line }
+func (p pos) stmtLine() int { return p.line }
+
+// Expressions
+
+type nameExpr struct {
+	pos
+	name string
+}
+
+type literalExpr struct {
+	pos
+	value Value
+}
+
+// listExpr, tupleExpr and setExpr are bracketed element lists
+type listExpr struct {
+	pos
+	elems []Expr
+}
+
+type tupleExpr struct {
+	pos
+	elems []Expr
+}
+
+type setExpr struct {
+	pos
+	elems []Expr
+}
+
+type dictExpr struct {
+	pos
+	keys, values []Expr
+}
+
+type unaryExpr struct {
+	pos
+	op string // "-", "+", "~", "not", "empty", "not empty"
+	x  Expr
+}
+
+type binaryExpr struct {
+	pos
+	op   string
+	l, r Expr
+}
+
+// compareExpr is a comparison chain: a < b <= c
+type compareExpr struct {
+	pos
+	ops      []string // "<", "in", "not in", "is", "is not", ...
+	operands []Expr
+}
+
+// boolExpr short-circuits: "and" or "or"
+type boolExpr struct {
+	pos
+	op   string
+	l, r Expr
+}
+
+type condExpr struct {
+	pos
+	cond, then, els Expr
+}
+
+type keywordArg struct {
+	name  string
+	value Expr
+}
+
+type callExpr struct {
+	pos
+	fn     Expr
+	args   []Expr
+	kwargs []keywordArg
+}
+
+type attrExpr struct {
+	pos
+	x    Expr
+	name string
+}
+
+type indexExpr struct {
+	pos
+	x, index Expr
+}
+
+// sliceExpr is x[lo:hi:step]; any bound may be nil
+type sliceExpr struct {
+	pos
+	x, lo, hi, step Expr
+}
+
+type lambdaExpr struct {
+	pos
+	params []param
+	body   Expr
+}
+
+type compClause struct {
+	target Expr
+	iter   Expr
+	conds  []Expr
+}
+
+// compExpr is a list, set, dict or generator comprehension. A generator
+// is evaluated eagerly into a list.
+type compExpr struct {
+	pos
+	kind    byte // '[', '{' for sets, ':' for dicts
+	elem    Expr // the value for dicts
+	key     Expr // dicts only
+	clauses []compClause
+}
+
+// Statements
+
+type exprStmt struct {
+	pos
+	x Expr
+}
+
+// assignStmt is a = b = value; each target may be a tuple to unpack
+type assignStmt struct {
+	pos
+	targets []Expr
+	value   Expr
+}
+
+type augAssignStmt struct {
+	pos
+	target Expr
+	op     string // the binary operator, without "="
+	value  Expr
+}
+
+type ifStmt struct {
+	pos
+	cond Expr
+	body []Stmt
+	els  []Stmt // elif chains nest here
+}
+
+type whileStmt struct {
+	pos
+	cond Expr
+	body []Stmt
+	els  []Stmt
+}
+
+type forStmt struct {
+	pos
+	target Expr
+	iter   Expr
+	body   []Stmt
+	els    []Stmt
+}
+
+// forRangeStmt is "for i from a to b", both ends inclusive
+type forRangeStmt struct {
+	pos
+	name     string
+	from, to Expr
+	body     []Stmt
+}
+
+type param struct {
+	name string
+	def  Expr // default value, or nil
+}
+
+type funcDef struct {
+	pos
+	name   string
+	params []param
+	body   []Stmt
+}
+
+type classDef struct {
+	pos
+	name    string
+	fields  []param // declared fields, with their initial values
+	methods []*funcDef
+}
+
+type returnStmt struct {
+	pos
+	value Expr // nil returns null
+}
+
+// scopeStmt is a global or nonlocal declaration
+type scopeStmt struct {
+	pos
+	keyword string
+	names   []string
+}
+
+// badStmt stands in for a statement that didn't parse, when linting
+type badStmt struct {
+	pos
+	names []string // names it mentions, which it may assign
+}
+
+type breakStmt struct{ pos }
+type continueStmt struct{ pos }
+type passStmt struct{ pos }
@@ -0,0 +1,712 @@
+package pseudo
+
+import (
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type builtinFunc = func(in *Interp, args []Value, kwargs map[string]Value) (Value, error)
+
+var builtins map[string]Value
+
+func init() {
+	funcs := map[string]builtinFunc{
+		"len":   builtinLen,
+		"range": builtinRange,
+		"min": func(in *Interp, args []Value, kw map[string]Value) (Value, error) {
+			return in.extreme("min", -1, args, kw)
+		},
+		"max": func(in *Interp, args []Value, kw map[string]Value) (Value, error) {
+			return in.extreme("max", 1, args, kw)
+		},
+		"abs":       builtinAbs,
+		"sum":       builtinSum,
+		"sorted":    builtinSorted,
+		"reversed":  builtinReversed,
+		"enumerate": builtinEnumerate,
+		"zip":       builtinZip,
+		"any": func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			return in.anyAll("any", args, true)
+		},
+		"all": func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			return in.anyAll("all", args, false)
+		},
+		"list":  builtinList,
+		"tuple": builtinTuple,
+		"set":   builtinSet,
+		"Set":   builtinSet,
+		"dict":  builtinDict,
+		"Map":   builtinDict,
+		"Queue": builtinQueue,
+		"deque": builtinQueue,
+		"Stack": builtinStack,
+		"PriorityQueue": func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			pq := &PriorityQueue{}
+			if len(args) == 1 {
+				err := in.iterate(args[0], func(v Value) error { pq.push(v, v); return in.alloc(1) })
+				return pq, err
+			}
+			return pq, in.arity("PriorityQueue", args, 0, 0)
+		},
+		"bin": func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity("bin", args, 1, 1); err != nil {
+				return nil, err
+			}
+			n, ok := toInt(args[0])
+			if !ok {
+				return nil, in.errorf("bin needs an integer, not %s", typeName(args[0]))
+			}
+			if n < 0 {
+				return "-0b" + strconv.FormatUint(uint64(-n), 2), nil
+			}
+			return "0b" + strconv.FormatInt(n, 2), nil
+		},
+		"hash": func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity("hash", args, 1, 1); err != nil {
+				return nil, err
+			}
+			h := fnv.New64a()
+			h.Write([]byte(hashKey(args[0])))
+			return int64(h.Sum64() >> 1), nil
+		},
+		"str":   builtinStr,
+		"int":   builtinInt,
+		"float": builtinFloat,
+		"bool": func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			return len(args) > 0 && truthy(args[0]), in.arity("bool", args, 0, 1)
+		},
+		"print":     builtinPrint,
+		"process":   builtinPrint,
+		"neighbors": builtinNeighbors,
+		"swap": func(in *Interp, _ []Value, _ map[string]Value) (Value, error) {
+			return nil, in.errorf("swap needs two assignable arguments")
+		},
+		"heappush": heapPush,
+		"heappop":  heapPop,
+		"heapify":  heapify,
+	}
+	builtins = make(map[string]Value, len(funcs)+8)
+	for name, fn := range funcs {
+		builtins[name] = &Builtin{name: name, fn: fn}
+	}
+	builtins["infinity"] = math.Inf(1)
+	builtins["inf"] = math.Inf(1)
+	builtins["INF"] = math.Inf(1)
+	builtins["INFINITY"] = math.Inf(1)
+	builtins["heapq"] = &Module{name: "heapq", attrs: map[string]Value{
+		"heappush": builtins["heappush"],
+		"heappop":  builtins["heappop"],
+		"heapify":  builtins["heapify"],
+	}}
+	builtins["math"] = &Module{name: "math", attrs: map[string]Value{
+		"inf":   math.Inf(1),
+		"sqrt":  &Builtin{name: "sqrt", fn: mathFunc("sqrt", math.Sqrt)},
+		"floor": &Builtin{name: "floor", fn: mathFunc("floor", math.Floor)},
+		"ceil":  &Builtin{name: "ceil", fn: mathFunc("ceil", math.Ceil)},
+		"log2":  &Builtin{name: "log2", fn: mathFunc("log2", math.Log2)},
+	}}
+}
+
+// arity fails unless there are between lo and hi arguments
+func (in *Interp) arity(name string, args []Value, lo, hi int) error {
+	switch {
+	case len(args) < lo || len(args) > hi:
+		if lo == hi {
+			return in.errorf("%s takes %d argument(s), got %d", name, lo, len(args))
+		}
+		return in.errorf("%s takes %d to %d arguments, got %d", name, lo, hi, len(args))
+	}
+	return nil
+}
+
+func builtinLen(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+	if err := in.arity("len", args, 1, 1); err != nil {
+		return nil, err
+	}
+	n, ok := length(args[0])
+	if !ok {
+		return nil, in.errorf("%s has no length", typeName(args[0]))
+	}
+	return int64(n), nil
+}
+
+func builtinRange(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+	if err := in.arity("range", args, 1, 3); err != nil {
+		return nil, err
+	}
+	bounds := make([]int64, len(args))
+	for i, a := range args {
+		n, ok := toInt(a)
+		if !ok {
+			return nil, in.errorf("range needs integers, not %s", typeName(a))
+		}
+		bounds[i] = n
+	}
+	r := &Range{step: 1}
+	switch len(bounds) {
+	case 1:
+		r.stop = bounds[0]
+	case 2:
+		r.start, r.stop = bounds[0], bounds[1]
+	case 3:
+		r.start, r.stop, r.step = bounds[0], bounds[1], bounds[2]
+	}
+	if r.step == 0 {
+		return nil, in.errorf("range step can't be zero")
+	}
+	return r, nil
+}
+
+// extreme is min or max, over arguments or one iterable, with key and
+// default
+func (in *Interp) extreme(name string, sign int, args []Value, kwargs map[string]Value) (Value, error) {
+	items := args
+	if len(args) == 1 {
+		var err error
+		if items, err = in.toSlice(args[0]); err != nil {
+			return nil, err
+		}
+	}
+	if len(items) == 0 {
+		if def, ok := kwargs["default"]; ok {
+			return def, nil
+		}
+		return nil, in.errorf("%s of an empty sequence", name)
+	}
+	key := kwargs["key"]
+	var best, bestKey Value
+	for i, item := range items {
+		k := item
+		if key != nil {
+			var err error
+			if k, err = in.call(key, []Value{item}, nil); err != nil {
+				return nil, err
+			}
+		}
+		if i > 0 {
+			c, err := compare(k, bestKey)
+			if err != nil {
+				return nil, in.errorf("%v", err)
+			}
+			if c*sign <= 0 {
+				continue
+			}
+		}
+		best, bestKey = item, k
+	}
+	return best, nil
+}
+
+func builtinAbs(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+	if err := in.arity("abs", args, 1, 1); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case int64:
+		if v < 0 {
+			return in.unary("-", v)
+		}
+		return v, nil
+	case float64:
+		return math.Abs(v), nil
+	}
+	return nil, in.errorf("bad operand type for abs: %s", typeName(args[0]))
+}
+
+func builtinSum(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+	if err := in.arity("sum", args, 1, 2); err != nil {
+		return nil, err
+	}
+	var total Value = int64(0)
+	if len(args) == 2 {
+		total = args[1]
+	}
+	err := in.iterate(args[0], func(v Value) error {
+		var err error
+		total, err = in.binary("+", total, v)
+		return err
+	})
+	return total, err
+}
+
+func builtinSorted(in *Interp, args []Value, kwargs map[string]Value) (Value, error) {
+	if err := in.arity("sorted", args, 1, 1); err != nil {
+		return nil, err
+	}
+	items, err := in.toSlice(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if err := in.alloc(len(items)); err != nil {
+		return nil, err
+	}
+	l := &List{append([]Value{}, items...)}
+	return l, in.sortValues(l.items, kwargs)
+}
+
+// sortValues sorts stably, taking key and reverse like Python's sort
+func (in *Interp) sortValues(items []Value, kwargs map[string]Value) error {
+	keys := items
+	if key := kwargs["key"]; key != nil {
+		keys = make([]Value, len(items))
+		for i, item := range items {
+			k, err := in.call(key, []Value{item}, nil)
+			if err != nil {
+				return err
+			}
+			keys[i] = k
+		}
+	}
+	reverse := truthy(kwargs["reverse"])
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	var cmpErr error
+	sort.SliceStable(order, func(a, b int) bool {
+		c, err := compare(keys[order[a]], keys[order[b]])
+		if err != nil && cmpErr == nil {
+			cmpErr = err
+		}
+		if reverse {
+			return c > 0
+		}
+		return c < 0
+	})
+	if cmpErr != nil {
+		return in.errorf("%v", cmpErr)
+	}
+	sorted := make([]Value, len(items))
+	for i, j := range order {
+		sorted[i] = items[j]
+	}
+	copy(items, sorted)
+	return nil
+}
+
+func builtinReversed(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+	if err := in.arity("reversed", args, 1, 1); err != nil {
+		return nil, err
+	}
+	items, err := in.toSlice(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if err := in.alloc(len(items)); err != nil {
+		return nil, err
+	}
+	out := make([]Value, len(items))
+	for i, v := range items {
+		out[len(items)-1-i] = v
+	}
+	return &List{out}, nil
+}
+
+func builtinEnumerate(in *Interp, args []Value, kwargs map[string]Value) (Value, error) {
+	if err := in.arity("enumerate", args, 1, 2); err != nil {
+		return nil, err
+	}
+	start := int64(0)
+	if len(args) == 2 {
+		start, _ = toInt(args[1])
+	} else if s, ok := toInt(kwargs["start"]); ok {
+		start = s
+	}
+	items, err := in.toSlice(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if err := in.alloc(2 * len(items)); err != nil {
+		return nil, err
+	}
+	out := make([]Value, len(items))
+	for i, v := range items {
+		out[i] = Tuple{start + int64(i), v}
+	}
+	return &List{out}, nil
+}
+
+func builtinZip(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+	lists := make([][]Value, len(args))
+	shortest := -1
+	for i, a := range args {
+		items, err := in.toSlice(a)
+		if err != nil {
+			return nil, err
+		}
+		lists[i] = items
+		if shortest < 0 || len(items) < shortest {
+			shortest = len(items)
+		}
+	}
+	shortest = max(shortest, 0)
+	if err := in.alloc(shortest * (len(args) + 1)); err != nil {
+		return nil, err
+	}
+	out := make([]Value, shortest)
+	for i := range out {
+		t := make(Tuple, len(lists))
+		for j, l := range lists {
+			t[j] = l[i]
+		}
+		out[i] = t
+	}
+	return &List{out}, nil
+}
+
+func (in *Interp) anyAll(name string, args []Value, want bool) (Value, error) {
+	if err := in.arity(name, args, 1, 1); err != nil {
+		return nil, err
+	}
+	found := false
+	err := in.iterate(args[0], func(v Value) error {
+		if truthy(v) == want {
+			found = true
+			return errStopIteration
+		}
+		return nil
+	})
+	if err != nil && err != errStopIteration {
+		return nil, err
+	}
+	return found == want, nil
+}
+
+// collect copies an optional iterable argument
+func (in *Interp) collect(name string, args []Value) ([]Value, error) {
+	if err := in.arity(name, args, 0, 1); err != nil || len(args) == 0 {
+		return nil, err
+	}
+	items, err := in.toSlice(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return append([]Value{}, items...), in.alloc(len(items))
+}
+
+func builtinList(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+	items, err := in.collect("list", args)
+	if items == nil {
+		items = []Value{}
+	}
+	return &List{items}, err
+}
+
+func builtinTuple(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+	items, err := in.collect("tuple", args)
+	return Tuple(items), err
+}
+
+func builtinSet(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+	items, err := in.collect("set", args)
+	s := newSet()
+	for _, v := range items {
+		s.add(v)
+	}
+	return s, err
+}
+
+func builtinDict(in *Interp, args []Value, kwargs map[string]Value) (Value, error) {
+	if err := in.arity("dict", args, 0, 1); err != nil {
+		return nil, err
+	}
+	d := newDict()
+	if len(args) == 1 {
+		if src, ok := args[0].(*Dict); ok {
+			for i, k := range src.keys {
+				d.set(k, src.values[i])
+			}
+			return d, in.alloc(d.len())
+		}
+		err := in.iterate(args[0], func(pair Value) error {
+			kv, err := in.toSlice(pair)
+			if err != nil || len(kv) != 2 {
+				return in.errorf("dict needs key, value pairs")
+			}
+			d.set(kv[0], kv[1])
+			return in.alloc(1)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for k, v := range kwargs {
+		d.set(k, v)
+	}
+	return d, nil
+}
+
+func builtinQueue(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+	items, err := in.collect("Queue", args)
+	return &Queue{items}, err
+}
+
+func builtinStack(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+	items, err := in.collect("Stack", args)
+	return &Stack{items}, err
+}
+
+func builtinStr(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+	if err := in.arity("str", args, 0, 1); err != nil || len(args) == 0 {
+		return "", err
+	}
+	s := str(args[0])
+	return s, in.alloc(len(s))
+}
+
+func builtinInt(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+	if err := in.arity("int", args, 0, 1); err != nil || len(args) == 0 {
+		return int64(0), err
+	}
+	switch v := args[0].(type) {
+	case int64:
+		return v, nil
+	case bool:
+		n, _ := toInt(v)
+		return n, nil
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) || math.Abs(v) >= math.MaxInt64 {
+			return nil, in.errorf("can't convert %s to int", formatFloat(v))
+		}
+		return int64(v), nil
+	case string:
+		n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			return nil, in.errorf("invalid integer %s", strconv.Quote(v))
+		}
+		return n, nil
+	}
+	return nil, in.errorf("can't convert %s to int", typeName(args[0]))
+}
+
+func builtinFloat(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+	if err := in.arity("float", args, 0, 1); err != nil || len(args) == 0 {
+		return 0.0, err
+	}
+	if f, ok := toFloat(args[0]); ok {
+		return f, nil
+	}
+	if s, ok := args[0].(string); ok {
+		switch strings.ToLower(strings.TrimSpace(s)) {
+		case "inf", "infinity", "+inf":
+			return math.Inf(1), nil
+		case "-inf", "-infinity":
+			return math.Inf(-1), nil
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return nil, in.errorf("invalid number %s", strconv.Quote(s))
+		}
+		return f, nil
+	}
+	return nil, in.errorf("can't convert %s to float", typeName(args[0]))
+}
+
+// builtinPrint writes to the run's output, dropping what's over the limit
+func builtinPrint(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		parts[i] = str(a)
+	}
+	line := strings.Join(parts, " ") + "\n"
+	if room := in.limits.MaxOutput - in.out.Len(); room < len(line) {
+		if room > 0 {
+			in.out.WriteString(line[:room])
+		}
+		in.truncated = true
+		return nil, nil
+	}
+	in.out.WriteString(line)
+	return nil, nil
+}
+
+// builtinNeighbors backs "for each x of node". It looks the node up in a
+// global graph (a map of adjacency lists or weight maps), or else treats
+// it as a [row, col] cell of a global grid, where 1 and "#" are walls.
+func builtinNeighbors(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+	if err := in.arity("neighbors", args, 1, 1); err != nil {
+		return nil, err
+	}
+	node := args[0]
+	if o, ok := node.(*Instance); ok {
+		if v, ok := o.get("neighbors"); ok {
+			return v, nil
+		}
+	}
+	if graph, ok := in.globals.vars["graph"]; ok {
+		var adj Value
+		var err error
+		switch g := graph.(type) {
+		case *Dict:
+			if adj, ok = g.get(node); !ok {
+				// JSON object keys are strings; let numeric nodes find them
+				adj, _ = g.get(str(node))
+			}
+		default:
+			adj, err = in.index(graph, node)
+		}
+		if err != nil || adj == nil {
+			return &List{[]Value{}}, err
+		}
+		if d, ok := adj.(*Dict); ok {
+			return &List{append([]Value{}, d.keys...)}, in.alloc(d.len())
+		}
+		items, err := in.toSlice(adj)
+		return &List{append([]Value{}, items...)}, err
+	}
+	if grid, ok := in.globals.vars["grid"]; ok {
+		return in.gridNeighbors(grid, node)
+	}
+	return nil, in.errorf("neighbors needs a global graph or grid")
+}
+
+func (in *Interp) gridNeighbors(grid, node Value) (Value, error) {
+	cell, err := in.toSlice(node)
+	if err != nil || len(cell) != 2 {
+		return nil, in.errorf("a grid node must be a [row, col] pair, not %s", repr(node))
+	}
+	r, rok := toInt(cell[0])
+	c, cok := toInt(cell[1])
+	rows, err := in.toSlice(grid)
+	if err != nil || !rok || !cok {
+		return nil, in.errorf("a grid node must be a [row, col] pair, not %s", repr(node))
+	}
+	out := &List{[]Value{}}
+	for _, d := range [][2]int64{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+		nr, nc := r+d[0], c+d[1]
+		if nr < 0 || nr >= int64(len(rows)) {
+			continue
+		}
+		row := rows[nr]
+		size, ok := length(row)
+		if !ok || nc < 0 || nc >= int64(size) {
+			continue
+		}
+		v, err := in.index(row, nc)
+		if err != nil {
+			return nil, err
+		}
+		if equal(v, int64(1)) || equal(v, "#") {
+			continue
+		}
+		out.items = append(out.items, Tuple{nr, nc})
+	}
+	return out, in.alloc(len(out.items) * 3)
+}
+
+func mathFunc(name string, f func(float64) float64) builtinFunc {
+	return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+		if err := in.arity(name, args, 1, 1); err != nil {
+			return nil, err
+		}
+		x, ok := toFloat(args[0])
+		if !ok {
+			return nil, in.errorf("%s needs a number, not %s", name, typeName(args[0]))
+		}
+		y := f(x)
+		if name != "sqrt" && name != "log2" && !math.IsInf(y, 0) && math.Abs(y) < math.MaxInt64 {
+			return int64(y), nil
+		}
+		return y, nil
+	}
+}
+
+// heapq works on plain lists, as in Python
+
+func heapList(in *Interp, name string, args []Value, n int) (*List, error) {
+	if err := in.arity(name, args, n, n); err != nil {
+		return nil, err
+	}
+	l, ok := args[0].(*List)
+	if !ok {
+		return nil, in.errorf("%s needs a list, not %s", name, typeName(args[0]))
+	}
+	return l, nil
+}
+
+func (in *Interp) heapLess(a, b Value) (bool, error) {
+	c, err := compare(a, b)
+	if err != nil {
+		return false, in.errorf("%v", err)
+	}
+	return c < 0, nil
+}
+
+func (in *Interp) siftUp(items []Value, i int) error {
+	for i > 0 {
+		parent := (i - 1) / 2
+		less, err := in.heapLess(items[i], items[parent])
+		if err != nil || !less {
+			return err
+		}
+		items[i], items[parent] = items[parent], items[i]
+		i = parent
+	}
+	return nil
+}
+
+func (in *Interp) siftDown(items []Value, i int) error {
+	for {
+		smallest := i
+		for _, c := range []int{2*i + 1, 2*i + 2} {
+			if c >= len(items) {
+				continue
+			}
+			less, err := in.heapLess(items[c], items[smallest])
+			if err != nil {
+				return err
+			}
+			if less {
+				smallest = c
+			}
+		}
+		if smallest == i {
+			return nil
+		}
+		items[i], items[smallest] = items[smallest], items[i]
+		i = smallest
+	}
+}
+
+func heapPush(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+	l, err := heapList(in, "heappush", args, 2)
+	if err != nil {
+		return nil, err
+	}
+	if err := in.alloc(1); err != nil {
+		return nil, err
+	}
+	l.items = append(l.items, args[1])
+	return nil, in.siftUp(l.items, len(l.items)-1)
+}
+
+func heapPop(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+	l, err := heapList(in, "heappop", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(l.items) == 0 {
+		return nil, in.errorf("heappop from an empty heap")
+	}
+	top := l.items[0]
+	last := len(l.items) - 1
+	l.items[0] = l.items[last]
+	l.items = l.items[:last]
+	return top, in.siftDown(l.items, 0)
+}
+
+func heapify(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+	l, err := heapList(in, "heapify", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(l.items)/2 - 1; i >= 0; i-- {
+		if err := in.siftDown(l.items, i); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
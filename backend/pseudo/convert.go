@@ -0,0 +1,152 @@
+package pseudo
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Rendering caps for values in the trace and the result: deeper
+// structures and longer sequences are elided
+const (
+	maxRenderDepth = 4
+	maxRenderItems = 50
+	snapshotBudget = 2000 // values rendered per trace step
+	resultBudget   = 20000
+)
+
+// fromJSON converts decoded JSON into values: integral numbers become
+// ints, arrays lists and objects maps
+func (in *Interp) fromJSON(v any) (Value, error) {
+	if err := in.alloc(1); err != nil {
+		return nil, err
+	}
+	switch v := v.(type) {
+	case nil, bool, string:
+		return v, nil
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v), nil
+		}
+		return v, nil
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s", v)
+		}
+		return f, nil
+	case []any:
+		items := make([]Value, len(v))
+		for i, item := range v {
+			converted, err := in.fromJSON(item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = converted
+		}
+		return &List{items}, nil
+	case map[string]any:
+		// Sorted, since decoding loses the order
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		d := newDict()
+		for _, k := range keys {
+			converted, err := in.fromJSON(v[k])
+			if err != nil {
+				return nil, err
+			}
+			d.set(k, converted)
+		}
+		return d, nil
+	}
+	return nil, fmt.Errorf("unsupported JSON value %T", v)
+}
+
+// render converts a value to JSON-friendly data for the response
+func render(v Value) any {
+	budget := resultBudget
+	return renderWithin(v, &budget)
+}
+
+// renderWithin renders with a shared budget of values, so one snapshot of
+// a big structure stays small. Sequences become arrays, maps objects keyed
+// by the key's repr (strings as themselves), and objects their fields.
+func renderWithin(v Value, budget *int) any {
+	return renderValue(v, 0, budget)
+}
+
+func renderValue(v Value, depth int, budget *int) any {
+	*budget--
+	if s, ok := scalarValue(v); ok {
+		return s
+	}
+	if *budget < 0 || depth > maxRenderDepth {
+		return "…"
+	}
+	seq := func(items []Value) any {
+		out := make([]any, 0, min(len(items), maxRenderItems+1))
+		for i, item := range items {
+			if i == maxRenderItems {
+				out = append(out, fmt.Sprintf("… %d more", len(items)-i))
+				break
+			}
+			out = append(out, renderValue(item, depth+1, budget))
+		}
+		return out
+	}
+	switch v := v.(type) {
+	case Tuple:
+		return seq(v)
+	case *List:
+		return seq(v.items)
+	case *Set:
+		return seq(v.items())
+	case *Queue:
+		return seq(v.items)
+	case *Stack:
+		return seq(v.items)
+	case *PriorityQueue:
+		return seq(v.sorted())
+	case *Range:
+		return repr(v)
+	case *Dict:
+		out := make(map[string]any, min(v.len(), maxRenderItems))
+		for i, k := range v.keys {
+			if i == maxRenderItems {
+				out["…"] = fmt.Sprintf("%d more", v.len()-i)
+				break
+			}
+			out[str(k)] = renderValue(v.values[i], depth+1, budget)
+		}
+		return out
+	case *Instance:
+		out := make(map[string]any, len(v.names))
+		for _, name := range v.names {
+			out[name] = renderValue(v.fields[name], depth+1, budget)
+		}
+		return out
+	}
+	return repr(v)
+}
+
+// scalarValue renders plain values; infinities, which JSON can't carry,
+// become strings
+func scalarValue(v Value) (any, bool) {
+	switch v := v.(type) {
+	case nil, bool, int64, string:
+		return v, true
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return formatFloat(v), true
+		}
+		return v, true
+	}
+	return nil, false
+}
@@ -0,0 +1,924 @@
+package pseudo
+
+import (
+	"math"
+	"math/bits"
+	"strings"
+)
+
+func (in *Interp) eval(x Expr) (Value, error) {
+	switch x := x.(type) {
+	case *literalExpr:
+		return x.value, nil
+
+	case *nameExpr:
+		return in.lookup(x.name)
+
+	case *listExpr:
+		items, err := in.evalAll(x.elems)
+		if err != nil {
+			return nil, err
+		}
+		return &List{items}, nil
+
+	case *tupleExpr:
+		items, err := in.evalAll(x.elems)
+		if err != nil {
+			return nil, err
+		}
+		return Tuple(items), nil
+
+	case *setExpr:
+		items, err := in.evalAll(x.elems)
+		if err != nil {
+			return nil, err
+		}
+		s := newSet()
+		for _, item := range items {
+			s.add(item)
+		}
+		return s, nil
+
+	case *dictExpr:
+		d := newDict()
+		if err := in.alloc(len(x.keys)); err != nil {
+			return nil, err
+		}
+		for i := range x.keys {
+			k, err := in.eval(x.keys[i])
+			if err != nil {
+				return nil, err
+			}
+			v, err := in.eval(x.values[i])
+			if err != nil {
+				return nil, err
+			}
+			d.set(k, v)
+		}
+		return d, nil
+
+	case *unaryExpr:
+		v, err := in.eval(x.x)
+		if err != nil {
+			return nil, err
+		}
+		return in.unary(x.op, v)
+
+	case *binaryExpr:
+		l, err := in.eval(x.l)
+		if err != nil {
+			return nil, err
+		}
+		r, err := in.eval(x.r)
+		if err != nil {
+			return nil, err
+		}
+		return in.binary(x.op, l, r)
+
+	case *compareExpr:
+		l, err := in.eval(x.operands[0])
+		if err != nil {
+			return nil, err
+		}
+		for i, op := range x.ops {
+			r, err := in.eval(x.operands[i+1])
+			if err != nil {
+				return nil, err
+			}
+			ok, err := in.compareOp(op, l, r)
+			if err != nil || !ok {
+				return false, err
+			}
+			l = r
+		}
+		return true, nil
+
+	case *boolExpr:
+		l, err := in.eval(x.l)
+		if err != nil {
+			return nil, err
+		}
+		if truthy(l) == (x.op == "or") {
+			return l, nil
+		}
+		return in.eval(x.r)
+
+	case *condExpr:
+		cond, err := in.eval(x.cond)
+		if err != nil {
+			return nil, err
+		}
+		if truthy(cond) {
+			return in.eval(x.then)
+		}
+		return in.eval(x.els)
+
+	case *callExpr:
+		return in.evalCall(x)
+
+	case *attrExpr:
+		obj, err := in.eval(x.x)
+		if err != nil {
+			return nil, err
+		}
+		return in.getAttr(obj, x.name)
+
+	case *indexExpr:
+		obj, err := in.eval(x.x)
+		if err != nil {
+			return nil, err
+		}
+		index, err := in.eval(x.index)
+		if err != nil {
+			return nil, err
+		}
+		return in.index(obj, index)
+
+	case *sliceExpr:
+		return in.evalSlice(x)
+
+	case *lambdaExpr:
+		return &Function{name: "lambda", params: x.params, expr: x.body, env: in.frame.env}, nil
+
+	case *compExpr:
+		return in.evalComprehension(x)
+	}
+	return nil, in.errorf("unsupported expression")
+}
+
+func (in *Interp) evalAll(xs []Expr) ([]Value, error) {
+	if err := in.alloc(len(xs)); err != nil {
+		return nil, err
+	}
+	values := make([]Value, len(xs))
+	for i, x := range xs {
+		v, err := in.eval(x)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+func (in *Interp) evalCall(x *callExpr) (Value, error) {
+	fn, err := in.eval(x.fn)
+	if err != nil {
+		return nil, err
+	}
+	// swap(a[i], a[j]) exchanges what its arguments refer to
+	if b, ok := fn.(*Builtin); ok && b.name == "swap" {
+		if len(x.args) != 2 {
+			return nil, in.errorf("swap takes 2 arguments, got %d", len(x.args))
+		}
+		a, err := in.eval(x.args[0])
+		if err != nil {
+			return nil, err
+		}
+		b, err := in.eval(x.args[1])
+		if err != nil {
+			return nil, err
+		}
+		if err := in.assign(x.args[0], b); err != nil {
+			return nil, err
+		}
+		return nil, in.assign(x.args[1], a)
+	}
+
+	args := make([]Value, len(x.args))
+	for i, a := range x.args {
+		if args[i], err = in.eval(a); err != nil {
+			return nil, err
+		}
+	}
+	var kwargs map[string]Value
+	if len(x.kwargs) > 0 {
+		kwargs = make(map[string]Value, len(x.kwargs))
+		for _, kw := range x.kwargs {
+			if kwargs[kw.name], err = in.eval(kw.value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	line := in.line
+	v, err := in.call(fn, args, kwargs)
+	in.line = line
+	return v, err
+}
+
+func (in *Interp) evalComprehension(x *compExpr) (Value, error) {
+	f := in.frame
+	outer := f.env
+	f.env = newEnv(outer)
+	defer func() { f.env = outer }()
+
+	var list *List
+	var set *Set
+	var dict *Dict
+	switch x.kind {
+	case '{':
+		set = newSet()
+	case ':':
+		dict = newDict()
+	default:
+		list = &List{}
+	}
+
+	var loop func(depth int) error
+	loop = func(depth int) error {
+		if depth == len(x.clauses) {
+			if err := in.alloc(1); err != nil {
+				return err
+			}
+			v, err := in.eval(x.elem)
+			if err != nil {
+				return err
+			}
+			switch {
+			case set != nil:
+				set.add(v)
+			case dict != nil:
+				k, err := in.eval(x.key)
+				if err != nil {
+					return err
+				}
+				dict.set(k, v)
+			default:
+				list.items = append(list.items, v)
+			}
+			return nil
+		}
+		clause := x.clauses[depth]
+		iter, err := in.eval(clause.iter)
+		if err != nil {
+			return err
+		}
+		return in.iterate(iter, func(item Value) error {
+			if err := in.assign(clause.target, item); err != nil {
+				return err
+			}
+			for _, c := range clause.conds {
+				ok, err := in.eval(c)
+				if err != nil {
+					return err
+				}
+				if !truthy(ok) {
+					return nil
+				}
+			}
+			return loop(depth + 1)
+		})
+	}
+	if err := loop(0); err != nil {
+		return nil, err
+	}
+	switch {
+	case set != nil:
+		return set, nil
+	case dict != nil:
+		return dict, nil
+	}
+	return list, nil
+}
+
+// Operators
+
+func (in *Interp) unary(op string, v Value) (Value, error) {
+	switch op {
+	case "not":
+		return !truthy(v), nil
+	case "empty", "not empty":
+		n, ok := length(v)
+		if !ok {
+			return nil, in.errorf("%s has no size", typeName(v))
+		}
+		return (n == 0) == (op == "empty"), nil
+	case "-":
+		switch v := v.(type) {
+		case int64:
+			if v == math.MinInt64 {
+				return nil, in.errorf("integer overflow")
+			}
+			return -v, nil
+		case float64:
+			return -v, nil
+		case bool:
+			n, _ := toInt(v)
+			return -n, nil
+		}
+	case "+":
+		if isNumber(v) {
+			return v, nil
+		}
+	case "~":
+		if n, ok := toInt(v); ok {
+			return ^n, nil
+		}
+	}
+	return nil, in.errorf("bad operand type for unary %s: %s", op, typeName(v))
+}
+
+func (in *Interp) binary(op string, l, r Value) (Value, error) {
+	li, lInt := toInt(l)
+	ri, rInt := toInt(r)
+	if lInt && rInt {
+		return in.intOp(op, li, ri)
+	}
+	lf, lNum := toFloat(l)
+	rf, rNum := toFloat(r)
+	if lNum && rNum {
+		return in.floatOp(op, lf, rf)
+	}
+
+	switch op {
+	case "+":
+		switch l := l.(type) {
+		case string:
+			if r, ok := r.(string); ok {
+				if err := in.alloc(len(l) + len(r)); err != nil {
+					return nil, err
+				}
+				return l + r, nil
+			}
+		case *List:
+			if r, ok := r.(*List); ok {
+				return in.concat(l.items, r.items, func(items []Value) Value { return &List{items} })
+			}
+		case Tuple:
+			if r, ok := r.(Tuple); ok {
+				return in.concat(l, r, func(items []Value) Value { return Tuple(items) })
+			}
+		}
+	case "*":
+		if rInt {
+			return in.repeat(l, ri)
+		}
+		if lInt {
+			return in.repeat(r, li)
+		}
+	case "-", "|", "&", "^":
+		if a, ok := l.(*Set); ok {
+			if b, ok := r.(*Set); ok {
+				return in.setOp(op, a, b)
+			}
+		}
+	case "%":
+		if s, ok := l.(string); ok {
+			return strings.Replace(s, "%s", str(r), 1), nil
+		}
+	}
+	return nil, in.errorf("unsupported operand types for %s: %s and %s", op, typeName(l), typeName(r))
+}
+
+func (in *Interp) intOp(op string, a, b int64) (Value, error) {
+	overflow := func() (Value, error) { return nil, in.errorf("integer overflow") }
+	switch op {
+	case "+":
+		s := a + b
+		if (s > a) != (b > 0) {
+			return overflow()
+		}
+		return s, nil
+	case "-":
+		d := a - b
+		if (d < a) != (b > 0) {
+			return overflow()
+		}
+		return d, nil
+	case "*":
+		p, ok := mul64(a, b)
+		if !ok {
+			return overflow()
+		}
+		return p, nil
+	case "/":
+		if b == 0 {
+			return nil, in.errorf("division by zero")
+		}
+		return float64(a) / float64(b), nil
+	case "//":
+		if b == 0 {
+			return nil, in.errorf("division by zero")
+		}
+		if a == math.MinInt64 && b == -1 {
+			return overflow()
+		}
+		q := a / b
+		if (a%b != 0) && ((a < 0) != (b < 0)) {
+			q--
+		}
+		return q, nil
+	case "%":
+		if b == 0 {
+			return nil, in.errorf("division by zero")
+		}
+		m := a % b
+		if m != 0 && (m < 0) != (b < 0) {
+			m += b
+		}
+		return m, nil
+	case "**":
+		if b < 0 {
+			return math.Pow(float64(a), float64(b)), nil
+		}
+		result, base, ok := int64(1), a, true
+		for b > 0 && ok {
+			if b&1 == 1 {
+				result, ok = mul64(result, base)
+			}
+			if b >>= 1; b > 0 && ok {
+				base, ok = mul64(base, base)
+			}
+		}
+		if !ok {
+			return overflow()
+		}
+		return result, nil
+	case "&":
+		return a & b, nil
+	case "|":
+		return a | b, nil
+	case "^":
+		return a ^ b, nil
+	case "<<":
+		if b < 0 || b > 62 || bits.Len64(uint64(abs64(a)))+int(b) > 63 {
+			return overflow()
+		}
+		return a << b, nil
+	case ">>":
+		if b < 0 {
+			return nil, in.errorf("negative shift count")
+		}
+		return a >> min(b, 63), nil
+	}
+	return nil, in.errorf("unsupported operand types for %s: int and int", op)
+}
+
+// mul64 multiplies, reporting false on overflow
+func mul64(a, b int64) (int64, bool) {
+	if a == math.MinInt64 || b == math.MinInt64 {
+		return a * b, a == 0 || b == 0 || a == 1 || b == 1
+	}
+	hi, lo := bits.Mul64(uint64(abs64(a)), uint64(abs64(b)))
+	if hi != 0 || lo > math.MaxInt64 {
+		return 0, false
+	}
+	return a * b, true
+}
+
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func (in *Interp) floatOp(op string, a, b float64) (Value, error) {
+	switch op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		if b == 0 {
+			return nil, in.errorf("division by zero")
+		}
+		return a / b, nil
+	case "//":
+		if b == 0 {
+			return nil, in.errorf("division by zero")
+		}
+		return math.Floor(a / b), nil
+	case "%":
+		if b == 0 {
+			return nil, in.errorf("division by zero")
+		}
+		m := math.Mod(a, b)
+		if m != 0 && (m < 0) != (b < 0) {
+			m += b
+		}
+		return m, nil
+	case "**":
+		return math.Pow(a, b), nil
+	}
+	return nil, in.errorf("unsupported operand types for %s: float and float", op)
+}
+
+func (in *Interp) concat(a, b []Value, wrap func([]Value) Value) (Value, error) {
+	if err := in.alloc(len(a) + len(b)); err != nil {
+		return nil, err
+	}
+	items := make([]Value, 0, len(a)+len(b))
+	return wrap(append(append(items, a...), b...)), nil
+}
+
+// repeat is "ab" * 3 or [0] * n
+func (in *Interp) repeat(v Value, n int64) (Value, error) {
+	n = max(n, 0)
+	size, ok := length(v)
+	if !ok {
+		return nil, in.errorf("can't multiply %s", typeName(v))
+	}
+	if size > 0 && n > int64(in.limits.MaxAllocations/size) {
+		return nil, in.errorf("memory limit exceeded")
+	}
+	if err := in.alloc(size * int(n)); err != nil {
+		return nil, err
+	}
+	switch v := v.(type) {
+	case string:
+		return strings.Repeat(v, int(n)), nil
+	case *List:
+		items := make([]Value, 0, size*int(n))
+		for range n {
+			items = append(items, v.items...)
+		}
+		return &List{items}, nil
+	case Tuple:
+		items := make(Tuple, 0, size*int(n))
+		for range n {
+			items = append(items, v...)
+		}
+		return items, nil
+	}
+	return nil, in.errorf("can't multiply %s", typeName(v))
+}
+
+func (in *Interp) setOp(op string, a, b *Set) (Value, error) {
+	out := newSet()
+	keep := func(v Value, inA, inB bool) bool {
+		switch op {
+		case "|":
+			return true
+		case "&":
+			return inA && inB
+		case "-":
+			return inA && !inB
+		}
+		return inA != inB
+	}
+	for _, v := range a.items() {
+		if keep(v, true, b.has(v)) {
+			out.add(v)
+		}
+	}
+	for _, v := range b.items() {
+		if !a.has(v) && keep(v, false, true) {
+			out.add(v)
+		}
+	}
+	return out, in.alloc(out.d.len())
+}
+
+func (in *Interp) compareOp(op string, l, r Value) (bool, error) {
+	switch op {
+	case "==":
+		return equal(l, r), nil
+	case "!=":
+		return !equal(l, r), nil
+	case "is":
+		return same(l, r), nil
+	case "is not":
+		return !same(l, r), nil
+	case "in":
+		return in.contains(r, l)
+	case "not in":
+		ok, err := in.contains(r, l)
+		return !ok, err
+	}
+	c, err := compare(l, r)
+	if err != nil {
+		return false, in.errorf("%v", err)
+	}
+	switch op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	}
+	return c >= 0, nil
+}
+
+// same is identity for objects and equality for plain values
+func same(a, b Value) bool {
+	switch a.(type) {
+	case *List, *Dict, *Set, *Queue, *Stack, *PriorityQueue, *Instance, *Function, *Class:
+		return a == b
+	}
+	return equal(a, b)
+}
+
+func (in *Interp) contains(container, v Value) (bool, error) {
+	switch c := container.(type) {
+	case string:
+		s, ok := v.(string)
+		if !ok {
+			return false, in.errorf("'in <string>' needs a string, not %s", typeName(v))
+		}
+		return strings.Contains(c, s), nil
+	case *Dict:
+		_, ok := c.get(v)
+		return ok, nil
+	case *Set:
+		return c.has(v), nil
+	case *Range:
+		n, ok := toInt(v)
+		if !ok {
+			return false, nil
+		}
+		i := (n - c.start) / c.step
+		return (n-c.start)%c.step == 0 && i >= 0 && i < c.len(), nil
+	case *PriorityQueue:
+		for _, e := range c.entries {
+			if equal(e.value, v) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	found := false
+	err := in.iterate(container, func(item Value) error {
+		if equal(item, v) {
+			found = true
+			return errStopIteration
+		}
+		return nil
+	})
+	if err != nil && err != errStopIteration {
+		return false, err
+	}
+	return found, nil
+}
+
+// Indexing
+
+func (in *Interp) index(obj, index Value) (Value, error) {
+	switch o := obj.(type) {
+	case *Dict:
+		v, ok := o.get(index)
+		if !ok {
+			return nil, in.errorf("key %s not found", repr(index))
+		}
+		return v, nil
+	case *Instance:
+		if name, ok := index.(string); ok {
+			if v, ok := o.get(name); ok {
+				return v, nil
+			}
+		}
+		return nil, in.errorf("%s has no field %s", o.class.name, repr(index))
+	}
+
+	n, size, err := in.position(obj, index)
+	if err != nil {
+		return nil, err
+	}
+	switch o := obj.(type) {
+	case *List:
+		return o.items[n], nil
+	case Tuple:
+		return o[n], nil
+	case string:
+		return string([]rune(o)[n]), nil
+	case *Range:
+		return o.at(int64(n)), nil
+	case *Queue:
+		return o.items[n], nil
+	case *Stack:
+		return o.items[n], nil
+	}
+	return nil, in.errorf("%s can't be indexed (size %d)", typeName(obj), size)
+}
+
+// position resolves a possibly negative index into a sequence
+func (in *Interp) position(obj, index Value) (int, int, error) {
+	switch obj.(type) {
+	case *List, Tuple, string, *Range, *Queue, *Stack:
+	default:
+		return 0, 0, in.errorf("%s can't be indexed", typeName(obj))
+	}
+	size, _ := length(obj)
+	i, ok := toInt(index)
+	if !ok {
+		if f, isFloat := index.(float64); isFloat && f == math.Trunc(f) {
+			i, ok = int64(f), true
+		}
+	}
+	if !ok {
+		return 0, 0, in.errorf("%s indices must be integers, not %s", typeName(obj), typeName(index))
+	}
+	if i < 0 {
+		i += int64(size)
+	}
+	if i < 0 || i >= int64(size) {
+		return 0, 0, in.errorf("index %s out of range for %s of length %d", repr(index), typeName(obj), size)
+	}
+	return int(i), size, nil
+}
+
+func (in *Interp) setIndex(obj, index, v Value) error {
+	switch o := obj.(type) {
+	case *Dict:
+		if o.set(index, v) {
+			return in.alloc(1)
+		}
+		return nil
+	case *List:
+		n, _, err := in.position(obj, index)
+		if err != nil {
+			return err
+		}
+		o.items[n] = v
+		return nil
+	case *Instance:
+		if name, ok := index.(string); ok {
+			o.set(name, v)
+			return nil
+		}
+	}
+	return in.errorf("%s doesn't support item assignment", typeName(obj))
+}
+
+func (in *Interp) evalSlice(x *sliceExpr) (Value, error) {
+	obj, err := in.eval(x.x)
+	if err != nil {
+		return nil, err
+	}
+	bound := func(e Expr) (*int64, error) {
+		if e == nil {
+			return nil, nil
+		}
+		v, err := in.eval(e)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		n, ok := toInt(v)
+		if !ok {
+			return nil, in.errorf("slice indices must be integers, not %s", typeName(v))
+		}
+		return &n, nil
+	}
+	lo, err := bound(x.lo)
+	if err != nil {
+		return nil, err
+	}
+	hi, err := bound(x.hi)
+	if err != nil {
+		return nil, err
+	}
+	stepPtr, err := bound(x.step)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Value
+	switch o := obj.(type) {
+	case *List:
+		items = o.items
+	case Tuple:
+		items = o
+	case string:
+		runes := []rune(o)
+		items = make([]Value, len(runes))
+		for i, r := range runes {
+			items[i] = string(r)
+		}
+	default:
+		return nil, in.errorf("%s can't be sliced", typeName(obj))
+	}
+
+	step := int64(1)
+	if stepPtr != nil {
+		step = *stepPtr
+	}
+	if step == 0 {
+		return nil, in.errorf("slice step can't be zero")
+	}
+	size := int64(len(items))
+	clamp := func(p *int64, def int64) int64 {
+		if p == nil {
+			return def
+		}
+		n := *p
+		if n < 0 {
+			n += size
+		}
+		if step > 0 {
+			return min(max(n, 0), size)
+		}
+		return min(max(n, -1), size-1)
+	}
+	var picked []Value
+	if step > 0 {
+		for i := clamp(lo, 0); i < clamp(hi, size); i += step {
+			picked = append(picked, items[i])
+		}
+	} else {
+		for i := clamp(lo, size-1); i > clamp(hi, -1); i += step {
+			picked = append(picked, items[i])
+		}
+	}
+	if err := in.alloc(len(picked)); err != nil {
+		return nil, err
+	}
+
+	switch obj.(type) {
+	case Tuple:
+		return Tuple(picked), nil
+	case string:
+		var sb strings.Builder
+		for _, s := range picked {
+			sb.WriteString(s.(string))
+		}
+		return sb.String(), nil
+	}
+	if picked == nil {
+		picked = []Value{}
+	}
+	return &List{picked}, nil
+}
+
+// Iteration
+
+// iterate calls fn for each element. Lists are walked live, so items
+// appended during the loop are visited, as in Python.
+func (in *Interp) iterate(v Value, fn func(Value) error) error {
+	each := func(items []Value) error {
+		for _, item := range items {
+			if err := in.tick(); err != nil {
+				return err
+			}
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	switch v := v.(type) {
+	case *List:
+		for i := 0; i < len(v.items); i++ {
+			if err := in.tick(); err != nil {
+				return err
+			}
+			if err := fn(v.items[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case Tuple:
+		return each(v)
+	case string:
+		for _, r := range v {
+			if err := fn(string(r)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *Dict:
+		return each(append([]Value(nil), v.keys...))
+	case *Set:
+		return each(append([]Value(nil), v.items()...))
+	case *Queue:
+		return each(append([]Value(nil), v.items...))
+	case *Stack:
+		return each(append([]Value(nil), v.items...))
+	case *PriorityQueue:
+		return each(v.sorted())
+	case *Range:
+		for i := int64(0); i < v.len(); i++ {
+			if err := in.tick(); err != nil {
+				return err
+			}
+			if err := fn(v.at(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return in.errorf("%s is not iterable", typeName(v))
+}
+
+// toSlice collects an iterable's elements
+func (in *Interp) toSlice(v Value) ([]Value, error) {
+	switch v := v.(type) {
+	case Tuple:
+		return v, nil
+	case *List:
+		return v.items, nil
+	}
+	if n, ok := length(v); ok {
+		if err := in.alloc(n); err != nil {
+			return nil, err
+		}
+	}
+	var items []Value
+	err := in.iterate(v, func(item Value) error {
+		items = append(items, item)
+		return nil
+	})
+	return items, err
+}
@@ -0,0 +1,857 @@
+package pseudo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Errors from Run that are the caller's fault rather than the code's
+var (
+	ErrUnknownFunction  = errors.New("unknown function")
+	ErrBrokenFunction   = errors.New("function does not parse")
+	ErrInvalidArguments = errors.New("invalid arguments")
+)
+
+// Limits sandbox a run
+type Limits struct {
+	MaxSteps       int           // statements executed
+	MaxAllocations int           // container elements and string bytes created
+	MaxTraceSteps  int           // steps recorded in the trace
+	MaxDepth       int           // nested calls
+	MaxOutput      int           // bytes of printed output kept
+	Timeout        time.Duration // wall clock
+}
+
+// Step is the state just before a statement runs
+type Step struct {
+	Line      int            `json:"line"`
+	Function  string         `json:"function,omitempty"` // empty at the top level
+	Depth     int            `json:"depth"`
+	Variables map[string]any `json:"variables"`
+}
+
+// RuntimeError stops a run; the trace up to it is still returned
+type RuntimeError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+func (e *RuntimeError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// Result is the outcome of a run
+type Result struct {
+	Result     any           `json:"result"`
+	Args       []any         `json:"args,omitempty"` // after the call, to show in-place changes
+	Output     string        `json:"output"`
+	Steps      []Step        `json:"steps"`
+	TotalSteps int           `json:"totalSteps"`
+	Truncated  bool          `json:"truncated"`
+	Error      *RuntimeError `json:"error,omitempty"`
+}
+
+// Run executes the program's top-level statements, then calls function
+// with args. An empty function name picks the first function defined,
+// unless there are no args and the code has top-level statements besides
+// definitions: then it is a script, and just runs top to bottom. Globals, decoded from
+// JSON, are defined before anything runs.
+func Run(prog *Program, function string, args []any, globals map[string]any, limits Limits) (*Result, error) {
+	def, err := prog.selectFunction(function, len(args) > 0)
+	if err != nil {
+		return nil, err
+	}
+	if def != nil {
+		if err := checkArity(def, len(args)); err != nil {
+			return nil, err
+		}
+	}
+
+	in := &Interp{
+		limits:   limits,
+		globals:  newEnv(nil),
+		deadline: time.Now().Add(limits.Timeout),
+		out:      &strings.Builder{},
+	}
+	in.frame = &frame{env: in.globals, base: in.globals}
+	res := &Result{Steps: []Step{}}
+
+	var value Value
+	var values []Value
+	err = func() error {
+		for name, v := range globals {
+			converted, err := in.fromJSON(v)
+			if err != nil {
+				return err
+			}
+			in.globals.vars[name] = converted
+		}
+		if _, _, err := in.execBlock(prog.stmts); err != nil {
+			return err
+		}
+		if def == nil {
+			return nil
+		}
+		fn := in.globals.vars[def.name]
+		values = make([]Value, len(args))
+		for i, a := range args {
+			converted, err := in.fromJSON(a)
+			if err != nil {
+				return err
+			}
+			values[i] = converted
+		}
+		v, err := in.call(fn, values, nil)
+		if err == nil {
+			value = v
+		}
+		return err
+	}()
+
+	res.Result = render(value)
+	for _, v := range values {
+		res.Args = append(res.Args, render(v))
+	}
+	res.Output = in.out.String()
+	res.Steps = append(res.Steps, in.trace...)
+	res.TotalSteps = in.steps
+	res.Truncated = in.truncated
+	if err != nil {
+		res.Error = in.asRuntimeError(err)
+	}
+	return res, nil
+}
+
+// Functions lists the top-level functions and classes that parsed
+func (p *Program) Functions() []string {
+	var names []string
+	for _, s := range p.stmts {
+		switch s := s.(type) {
+		case *funcDef:
+			names = append(names, s.name)
+		case *classDef:
+			names = append(names, s.name)
+		}
+	}
+	return names
+}
+
+// selectFunction finds the function to call, matching the name exactly and
+// then ignoring case. Classes count: calling one constructs an object.
+func (p *Program) selectFunction(name string, hasArgs bool) (*funcDef, error) {
+	var defs []*funcDef
+	for _, s := range p.stmts {
+		switch s := s.(type) {
+		case *funcDef:
+			defs = append(defs, s)
+		case *classDef:
+			defs = append(defs, &funcDef{pos: s.pos, name: s.name, params: constructorParams(s)})
+		}
+	}
+	if name == "" {
+		// Code with statements of its own is a script that drives itself,
+		// unless there are arguments to pass
+		for _, s := range p.stmts {
+			switch s.(type) {
+			case *funcDef, *classDef, *passStmt:
+			default:
+				if !hasArgs {
+					return nil, nil
+				}
+			}
+		}
+		// Prefer a function to a class, which is usually a helper type
+		for _, s := range p.stmts {
+			if d, ok := s.(*funcDef); ok {
+				return d, nil
+			}
+		}
+		for _, d := range defs {
+			return d, nil
+		}
+		if len(p.broken) > 0 && len(p.stmts) == 0 {
+			return nil, fmt.Errorf("%w: %v", ErrBrokenFunction, p.Warnings[0])
+		}
+		return nil, nil
+	}
+	for _, match := range []func(string) bool{
+		func(s string) bool { return s == name },
+		func(s string) bool { return strings.EqualFold(s, name) },
+	} {
+		var found *funcDef
+		for _, d := range defs {
+			if match(d.name) {
+				found = d // the last definition wins, as when running
+			}
+		}
+		if found != nil {
+			return found, nil
+		}
+		for broken, err := range p.broken {
+			if match(broken) {
+				return nil, fmt.Errorf("%w: %s: %v", ErrBrokenFunction, broken, err)
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w %q; defined: %s", ErrUnknownFunction, name, strings.Join(p.Functions(), ", "))
+}
+
+// constructorParams are what a class takes when called: its init method's
+// parameters, or else its declared fields, all optional
+func constructorParams(c *classDef) []param {
+	for _, m := range c.methods {
+		if isConstructor(m.name) {
+			return methodParams(m.params)
+		}
+	}
+	params := make([]param, len(c.fields))
+	for i, f := range c.fields {
+		params[i] = param{name: f.name, def: &literalExpr{}}
+	}
+	return params
+}
+
+func isConstructor(name string) bool {
+	return name == "init" || name == "__init__" || name == "constructor"
+}
+
+// methodParams drops an explicit self
+func methodParams(params []param) []param {
+	if len(params) > 0 && params[0].name == "self" {
+		return params[1:]
+	}
+	return params
+}
+
+func checkArity(def *funcDef, n int) error {
+	required := 0
+	names := make([]string, len(def.params))
+	for i, p := range def.params {
+		names[i] = p.name
+		if p.def == nil {
+			required++
+		}
+	}
+	if n < required || n > len(def.params) {
+		return fmt.Errorf("%w: %s takes %d argument(s) (%s), got %d",
+			ErrInvalidArguments, def.name, len(def.params), strings.Join(names, ", "), n)
+	}
+	return nil
+}
+
+// Interp runs a program
+type Interp struct {
+	limits    Limits
+	globals   *env
+	frame     *frame
+	depth     int
+	steps     int
+	ops       int // loop iterations inside builtins, for the deadline
+	allocs    int
+	deadline  time.Time
+	line      int
+	trace     []Step
+	truncated bool
+	out       *strings.Builder
+}
+
+type env struct {
+	vars     map[string]Value
+	parent   *env
+	declared map[string]string // name -> "global" or "nonlocal"
+}
+
+func newEnv(parent *env) *env {
+	return &env{vars: make(map[string]Value), parent: parent}
+}
+
+// frame is a function call in progress
+type frame struct {
+	env  *env // innermost scope, which a comprehension may push
+	base *env // the function's own scope
+	fn   string
+	self *Instance
+}
+
+// Control flow out of a block
+type control int
+
+const (
+	ctlNone control = iota
+	ctlBreak
+	ctlContinue
+	ctlReturn
+)
+
+// errorf makes a runtime error at the current line
+func (in *Interp) errorf(format string, args ...any) error {
+	return &RuntimeError{Line: in.line, Message: fmt.Sprintf(format, args...)}
+}
+
+func (in *Interp) asRuntimeError(err error) *RuntimeError {
+	var re *RuntimeError
+	if errors.As(err, &re) {
+		return re
+	}
+	return &RuntimeError{Line: in.line, Message: err.Error()}
+}
+
+// step runs before every statement: it enforces the step and time limits
+// and records the trace
+func (in *Interp) step(s Stmt) error {
+	in.line = s.stmtLine()
+	if in.steps >= in.limits.MaxSteps {
+		return in.errorf("step limit of %d exceeded", in.limits.MaxSteps)
+	}
+	in.steps++
+	if in.steps%1024 == 0 && time.Now().After(in.deadline) {
+		return in.errorf("time limit of %s exceeded", in.limits.Timeout)
+	}
+	switch s.(type) {
+	case *funcDef, *classDef, *passStmt, *scopeStmt:
+		return nil // nothing worth a snapshot
+	}
+	if len(in.trace) >= in.limits.MaxTraceSteps {
+		in.truncated = true
+		return nil
+	}
+	in.trace = append(in.trace, Step{
+		Line:      in.line,
+		Function:  in.frame.fn,
+		Depth:     in.depth,
+		Variables: in.snapshot(),
+	})
+	return nil
+}
+
+// tick bounds work done inside builtins, which doesn't count as steps
+func (in *Interp) tick() error {
+	in.ops++
+	if in.ops%1024 == 0 && time.Now().After(in.deadline) {
+		return in.errorf("time limit of %s exceeded", in.limits.Timeout)
+	}
+	return nil
+}
+
+// alloc accounts for n new elements or bytes
+func (in *Interp) alloc(n int) error {
+	in.allocs += n
+	if in.allocs > in.limits.MaxAllocations || n < 0 {
+		return in.errorf("memory limit exceeded")
+	}
+	return nil
+}
+
+// snapshot renders the current frame's variables, leaving out functions
+// and classes
+func (in *Interp) snapshot() map[string]any {
+	vars := make(map[string]any)
+	budget := snapshotBudget
+	add := func(name string, v Value) {
+		switch v.(type) {
+		case *Function, *Builtin, *boundMethod, *Class, *Module:
+			return
+		}
+		if _, seen := vars[name]; !seen {
+			vars[name] = renderWithin(v, &budget)
+		}
+	}
+	for e := in.frame.env; e != nil; e = e.parent {
+		for name, v := range e.vars {
+			add(name, v)
+		}
+		if e == in.frame.base {
+			break
+		}
+	}
+	return vars
+}
+
+// Names
+
+func (in *Interp) lookup(name string) (Value, error) {
+	f := in.frame
+	e := f.env
+	for ; e != nil; e = e.parent {
+		if v, ok := e.vars[name]; ok {
+			return v, nil
+		}
+		if e == f.base {
+			e = e.parent
+			break
+		}
+	}
+	// Inside a method, fields and other methods are in scope without self
+	if f.self != nil {
+		if v, ok := f.self.get(name); ok {
+			return v, nil
+		}
+		if m, ok := f.self.class.methods[name]; ok {
+			return &boundMethod{fn: m, self: f.self}, nil
+		}
+	}
+	for ; e != nil; e = e.parent {
+		if v, ok := e.vars[name]; ok {
+			return v, nil
+		}
+	}
+	if v, ok := builtins[name]; ok {
+		return v, nil
+	}
+	if isConstantName(name) {
+		return name, nil // SUCCESS, NOT_FOUND and the like stand for themselves
+	}
+	return nil, in.errorf("%s is not defined", name)
+}
+
+// isConstantName matches ALL_CAPS names of at least two characters
+func isConstantName(name string) bool {
+	letters := 0
+	for _, r := range name {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			letters++
+		case r == '_' || r >= '0' && r <= '9':
+		default:
+			return false
+		}
+	}
+	return letters > 0 && len(name) > 1
+}
+
+func (in *Interp) setName(name string, v Value) error {
+	f := in.frame
+	switch f.base.declared[name] {
+	case "global":
+		in.globals.vars[name] = v
+		return nil
+	case "nonlocal":
+		for e := f.base.parent; e != nil && e != in.globals; e = e.parent {
+			if _, ok := e.vars[name]; ok {
+				e.vars[name] = v
+				return nil
+			}
+		}
+		return in.errorf("no binding for nonlocal %s", name)
+	}
+	if _, local := f.env.vars[name]; !local && f.self != nil && f.env == f.base {
+		if _, field := f.self.get(name); field {
+			f.self.set(name, v)
+			return nil
+		}
+	}
+	f.env.vars[name] = v
+	return nil
+}
+
+// Statements
+
+func (in *Interp) execBlock(stmts []Stmt) (control, Value, error) {
+	for _, s := range stmts {
+		ctl, v, err := in.exec(s)
+		if err != nil || ctl != ctlNone {
+			return ctl, v, err
+		}
+	}
+	return ctlNone, nil, nil
+}
+
+func (in *Interp) exec(s Stmt) (control, Value, error) {
+	if err := in.step(s); err != nil {
+		return ctlNone, nil, err
+	}
+	switch s := s.(type) {
+	case *exprStmt:
+		_, err := in.eval(s.x)
+		return ctlNone, nil, err
+
+	case *assignStmt:
+		v, err := in.eval(s.value)
+		if err != nil {
+			return ctlNone, nil, err
+		}
+		for _, t := range s.targets {
+			if err := in.assign(t, v); err != nil {
+				return ctlNone, nil, err
+			}
+		}
+		return ctlNone, nil, nil
+
+	case *augAssignStmt:
+		return ctlNone, nil, in.augAssign(s)
+
+	case *ifStmt:
+		cond, err := in.eval(s.cond)
+		if err != nil {
+			return ctlNone, nil, err
+		}
+		if truthy(cond) {
+			return in.execBlock(s.body)
+		}
+		return in.execBlock(s.els)
+
+	case *whileStmt:
+		for {
+			cond, err := in.eval(s.cond)
+			if err != nil {
+				return ctlNone, nil, err
+			}
+			if !truthy(cond) {
+				return in.execBlock(s.els)
+			}
+			ctl, v, err := in.execBlock(s.body)
+			if err != nil || ctl == ctlReturn {
+				return ctl, v, err
+			}
+			if ctl == ctlBreak {
+				return ctlNone, nil, nil
+			}
+			in.line = s.line
+		}
+
+	case *forStmt:
+		iter, err := in.eval(s.iter)
+		if err != nil {
+			return ctlNone, nil, err
+		}
+		var ctl control
+		var result Value
+		err = in.iterate(iter, func(item Value) error {
+			if err := in.assign(s.target, item); err != nil {
+				return err
+			}
+			c, v, err := in.execBlock(s.body)
+			if err != nil {
+				return err
+			}
+			if c == ctlBreak || c == ctlReturn {
+				ctl, result = c, v
+				return errStopIteration
+			}
+			in.line = s.line
+			return nil
+		})
+		if err != nil && err != errStopIteration {
+			return ctlNone, nil, err
+		}
+		switch ctl {
+		case ctlReturn:
+			return ctl, result, nil
+		case ctlBreak:
+			return ctlNone, nil, nil
+		}
+		return in.execBlock(s.els)
+
+	case *forRangeStmt:
+		from, err := in.evalInt(s.from)
+		if err != nil {
+			return ctlNone, nil, err
+		}
+		to, err := in.evalInt(s.to)
+		if err != nil {
+			return ctlNone, nil, err
+		}
+		for i := from; i <= to; i++ {
+			if err := in.setName(s.name, i); err != nil {
+				return ctlNone, nil, err
+			}
+			ctl, v, err := in.execBlock(s.body)
+			if err != nil || ctl == ctlReturn {
+				return ctl, v, err
+			}
+			if ctl == ctlBreak {
+				break
+			}
+			in.line = s.line
+		}
+		return ctlNone, nil, nil
+
+	case *funcDef:
+		return ctlNone, nil, in.setName(s.name, &Function{name: s.name, params: s.params, body: s.body, env: in.frame.env})
+
+	case *classDef:
+		class := &Class{name: s.name, fields: s.fields, methods: make(map[string]*Function), env: in.frame.env}
+		for _, m := range s.methods {
+			class.methods[m.name] = &Function{name: s.name + "." + m.name, params: m.params, body: m.body, env: in.frame.env, method: true}
+		}
+		return ctlNone, nil, in.setName(s.name, class)
+
+	case *returnStmt:
+		if s.value == nil {
+			return ctlReturn, nil, nil
+		}
+		v, err := in.eval(s.value)
+		return ctlReturn, v, err
+
+	case *scopeStmt:
+		base := in.frame.base
+		if base.declared == nil {
+			base.declared = make(map[string]string)
+		}
+		for _, name := range s.names {
+			base.declared[name] = s.keyword
+		}
+		return ctlNone, nil, nil
+
+	case *breakStmt:
+		return ctlBreak, nil, nil
+	case *continueStmt:
+		return ctlContinue, nil, nil
+	case *passStmt:
+		return ctlNone, nil, nil
+	}
+	return ctlNone, nil, in.errorf("unsupported statement")
+}
+
+var errStopIteration = errors.New("stop iteration")
+
+func (in *Interp) evalInt(x Expr) (int64, error) {
+	v, err := in.eval(x)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := toInt(v)
+	if !ok {
+		if f, isFloat := v.(float64); isFloat && f == math.Trunc(f) {
+			return int64(f), nil
+		}
+		return 0, in.errorf("expected an integer, got %s", typeName(v))
+	}
+	return n, nil
+}
+
+func (in *Interp) assign(target Expr, v Value) error {
+	switch t := target.(type) {
+	case *nameExpr:
+		return in.setName(t.name, v)
+	case *attrExpr:
+		obj, err := in.eval(t.x)
+		if err != nil {
+			return err
+		}
+		return in.setAttr(obj, t.name, v)
+	case *indexExpr:
+		obj, err := in.eval(t.x)
+		if err != nil {
+			return err
+		}
+		index, err := in.eval(t.index)
+		if err != nil {
+			return err
+		}
+		return in.setIndex(obj, index, v)
+	case *tupleExpr:
+		return in.unpack(t.elems, v)
+	case *listExpr:
+		return in.unpack(t.elems, v)
+	}
+	return in.errorf("can't assign to this expression")
+}
+
+func (in *Interp) unpack(targets []Expr, v Value) error {
+	items, err := in.toSlice(v)
+	if err != nil {
+		return in.errorf("can't unpack %s into %d names", typeName(v), len(targets))
+	}
+	if len(items) != len(targets) {
+		return in.errorf("expected %d values to unpack, got %d", len(targets), len(items))
+	}
+	for i, t := range targets {
+		if err := in.assign(t, items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (in *Interp) augAssign(s *augAssignStmt) error {
+	rhs, err := in.eval(s.value)
+	if err != nil {
+		return err
+	}
+	update := func(cur Value) (Value, error) {
+		// += on a list extends it in place, as in Python
+		if l, ok := cur.(*List); ok && s.op == "+" {
+			items, err := in.toSlice(rhs)
+			if err != nil {
+				return nil, in.errorf("can't extend a list with %s", typeName(rhs))
+			}
+			if err := in.alloc(len(items)); err != nil {
+				return nil, err
+			}
+			l.items = append(l.items, items...)
+			return l, nil
+		}
+		return in.binary(s.op, cur, rhs)
+	}
+
+	switch t := s.target.(type) {
+	case *nameExpr:
+		cur, err := in.lookup(t.name)
+		if err != nil {
+			return err
+		}
+		v, err := update(cur)
+		if err != nil {
+			return err
+		}
+		return in.setName(t.name, v)
+	case *attrExpr:
+		obj, err := in.eval(t.x)
+		if err != nil {
+			return err
+		}
+		cur, err := in.getAttr(obj, t.name)
+		if err != nil {
+			return err
+		}
+		v, err := update(cur)
+		if err != nil {
+			return err
+		}
+		return in.setAttr(obj, t.name, v)
+	case *indexExpr:
+		obj, err := in.eval(t.x)
+		if err != nil {
+			return err
+		}
+		index, err := in.eval(t.index)
+		if err != nil {
+			return err
+		}
+		cur, err := in.index(obj, index)
+		if err != nil {
+			return err
+		}
+		v, err := update(cur)
+		if err != nil {
+			return err
+		}
+		return in.setIndex(obj, index, v)
+	}
+	return in.errorf("can't assign to this expression")
+}
+
+// Calls
+
+func (in *Interp) call(fn Value, args []Value, kwargs map[string]Value) (Value, error) {
+	switch f := fn.(type) {
+	case *Function:
+		return in.callFunction(f, nil, args, kwargs)
+	case *boundMethod:
+		return in.callFunction(f.fn, f.self, args, kwargs)
+	case *Builtin:
+		return f.fn(in, args, kwargs)
+	case *Class:
+		return in.construct(f, args, kwargs)
+	}
+	return nil, in.errorf("%s is not callable", typeName(fn))
+}
+
+func (in *Interp) callFunction(f *Function, self *Instance, args []Value, kwargs map[string]Value) (Value, error) {
+	if in.depth >= in.limits.MaxDepth {
+		return nil, in.errorf("maximum recursion depth of %d exceeded", in.limits.MaxDepth)
+	}
+	scope := newEnv(f.env)
+	params := f.params
+	if f.method {
+		if self == nil {
+			return nil, in.errorf("method %s needs an object", f.name)
+		}
+		scope.vars["self"] = self
+		params = methodParams(params)
+	}
+	if len(args) > len(params) {
+		return nil, in.errorf("%s takes %d argument(s), got %d", f.name, len(params), len(args))
+	}
+	for name := range kwargs {
+		found := false
+		for _, p := range params {
+			found = found || p.name == name
+		}
+		if !found {
+			return nil, in.errorf("%s has no parameter %s", f.name, name)
+		}
+	}
+
+	caller, callerLine := in.frame, in.line
+	in.frame = &frame{env: scope, base: scope, fn: f.name, self: self}
+	in.depth++
+	defer func() {
+		in.frame, in.line = caller, callerLine
+		in.depth--
+	}()
+
+	for i, p := range params {
+		var v Value
+		switch kw, ok := kwargs[p.name]; {
+		case i < len(args):
+			v = args[i]
+		case ok:
+			v = kw
+		case p.def != nil:
+			d, err := in.eval(p.def)
+			if err != nil {
+				return nil, err
+			}
+			v = d
+		default:
+			return nil, &RuntimeError{Line: callerLine, Message: fmt.Sprintf("%s is missing argument %s", f.name, p.name)}
+		}
+		scope.vars[p.name] = v
+	}
+
+	if f.expr != nil {
+		return in.eval(f.expr)
+	}
+	_, v, err := in.execBlock(f.body)
+	return v, err
+}
+
+// construct makes an object. Fields start at their declared values; then
+// an init method runs if there is one, or else arguments fill the fields
+// in order.
+func (in *Interp) construct(c *Class, args []Value, kwargs map[string]Value) (Value, error) {
+	obj := &Instance{class: c, fields: make(map[string]Value)}
+	if err := in.alloc(len(c.fields) + 1); err != nil {
+		return nil, err
+	}
+	caller := in.frame
+	in.frame = &frame{env: c.env, base: c.env, fn: caller.fn, self: obj}
+	for _, f := range c.fields {
+		var v Value
+		if f.def != nil {
+			d, err := in.eval(f.def)
+			if err != nil {
+				in.frame = caller
+				return nil, err
+			}
+			v = d
+		}
+		obj.set(f.name, v)
+	}
+	in.frame = caller
+
+	for name, m := range c.methods {
+		if isConstructor(name) {
+			_, err := in.callFunction(m, obj, args, kwargs)
+			return obj, err
+		}
+	}
+	if len(args) > len(c.fields) {
+		return nil, in.errorf("%s takes %d argument(s), got %d", c.name, len(c.fields), len(args))
+	}
+	for i, v := range args {
+		obj.set(c.fields[i].name, v)
+	}
+	for name, v := range kwargs {
+		if _, ok := obj.get(name); !ok {
+			return nil, in.errorf("%s has no field %s", c.name, name)
+		}
+		obj.set(name, v)
+	}
+	return obj, nil
+}
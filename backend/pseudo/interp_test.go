@@ -0,0 +1,257 @@
+package pseudo
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testLimits match the server's limits for /api/run
+var testLimits = Limits{
+	MaxSteps:       100000,
+	MaxAllocations: 1000000,
+	MaxTraceSteps:  1000,
+	MaxDepth:       200,
+	MaxOutput:      64 << 10,
+	Timeout:        2 * time.Second,
+}
+
+// run runs src as a script, failing the test if Run itself errors
+func run(t *testing.T, src string, limits Limits) *Result {
+	t.Helper()
+	prog := Parse(src)
+	if len(prog.Warnings) > 0 {
+		t.Fatalf("%q: %v", src, prog.Warnings)
+	}
+	res, err := Run(prog, "", nil, nil, limits)
+	if err != nil {
+		t.Fatalf("%q: %v", src, err)
+	}
+	return res
+}
+
+func TestRunLimits(t *testing.T) {
+	deep := testLimits
+	deep.MaxSteps = 1000000
+	slow := testLimits
+	slow.MaxSteps = 1 << 40
+	slow.Timeout = 50 * time.Millisecond
+
+	tests := []struct {
+		name   string
+		src    string
+		limits Limits
+		err    string
+	}{
+		{"steps", "while True:\n    pass", testLimits, "step limit of 100000 exceeded"},
+		{"recursion", "function f(n):\n    return f(n + 1)\nf(0)", testLimits, "maximum recursion depth of 200 exceeded"},
+		{"time", "while True:\n    pass", slow, "time limit of 50ms exceeded"},
+		{"huge string", "s = 'a' * 10 ** 9", testLimits, "memory limit exceeded"},
+		{"huge list", "x = list(range(10 ** 12))", testLimits, "memory limit exceeded"},
+		{"growing list", "x = [0]\nwhile True:\n    x = x + x", deep, "memory limit exceeded"},
+		{"add overflow", "x = 9223372036854775807 + 1", testLimits, "integer overflow"},
+		{"negate overflow", "x = -9223372036854775807 - 1\ny = -x", testLimits, "integer overflow"},
+		{"multiply overflow", "x = 3037000500 * 3037000500", testLimits, "integer overflow"},
+		{"floor divide overflow", "x = -9223372036854775807 - 1\ny = x // -1", testLimits, "integer overflow"},
+		{"power overflow", "x = 2 ** 64", testLimits, "integer overflow"},
+		{"shift overflow", "x = 1 << 63", testLimits, "integer overflow"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			res := run(t, tt.src, tt.limits)
+			if res.Error == nil || res.Error.Message != tt.err {
+				t.Errorf("error %v, want %q", res.Error, tt.err)
+			}
+			if elapsed := time.Since(start); elapsed > 2*tt.limits.Timeout {
+				t.Errorf("took %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestRunTraceLimits(t *testing.T) {
+	limits := testLimits
+	limits.MaxTraceSteps = 10
+	limits.MaxOutput = 100
+	res := run(t, "for i in range(1000):\n    print(i)", limits)
+	if res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	if len(res.Steps) != 10 || !res.Truncated || res.TotalSteps != 1001 {
+		t.Errorf("%d steps of %d, truncated %v", len(res.Steps), res.TotalSteps, res.Truncated)
+	}
+	if len(res.Output) > 100 {
+		t.Errorf("output is %d bytes", len(res.Output))
+	}
+}
+
+func TestRunArithmetic(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"print(7 // 2, -7 // 2, 7 // -2)", "3 -4 -4"},
+		{"print(7 % 3, -7 % 3, 7 % -3)", "1 2 -2"},
+		{"x = -9223372036854775807 - 1\nprint(x % -1)", "0"},
+		{"print(-9223372036854775807 - 1)", "-9223372036854775808"},
+		{"print(range(10 ** 12)[5], len(range(10 ** 12)))", "5 1000000000000"},
+	}
+	for _, tt := range tests {
+		res := run(t, tt.src, testLimits)
+		if res.Error != nil {
+			t.Errorf("%q: %v", tt.src, res.Error)
+			continue
+		}
+		if got := strings.TrimSpace(res.Output); got != tt.want {
+			t.Errorf("%q printed %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+// Structures that contain themselves, or nest deeper than any comparison
+// looks, must neither hang nor overflow the Go stack
+func TestRunCyclicValues(t *testing.T) {
+	limits := testLimits
+	limits.MaxSteps = 1000000
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+		err  string
+	}{
+		{
+			name: "cyclic list",
+			src:  "a = []\na.append(a)\nprint(a == a)\nprint(len(str(a)) < 100)\nprint(len(str((a,))) < 100)",
+			want: "True\nTrue\nTrue",
+		},
+		{
+			name: "cyclic dict",
+			src:  "d = {}\nd['d'] = d\nprint(d == d)\nprint(len(str(d)) < 200)",
+			want: "True\nTrue",
+		},
+		{
+			name: "compare cyclic lists",
+			src:  "a = []\na.append(a)\nb = []\nb.append(b)\nprint(a == b)\nprint(a < b)",
+			want: "False",
+			err:  "values nested too deeply to compare",
+		},
+		{
+			name: "deep lists",
+			src:  "x = []\ny = []\nfor i in range(100000):\n    x = [x]\n    y = [y]\nprint(x == y)\nprint(len(str(x)) < 100)\nprint(x < y)",
+			want: "False\nTrue",
+			err:  "values nested too deeply to compare",
+		},
+		{
+			name: "deep tuples",
+			src:  "x = ()\nfor i in range(100000):\n    x = (x,)\ns = set()\ns.add(x)\ns.add(x)\nprint(len(s), hash(x) == hash(x))",
+			want: "1 True",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := run(t, tt.src, limits)
+			if got := strings.TrimSpace(res.Output); got != tt.want {
+				t.Errorf("printed %q, want %q", got, tt.want)
+			}
+			switch {
+			case tt.err == "" && res.Error != nil:
+				t.Errorf("error %v", res.Error)
+			case tt.err != "" && (res.Error == nil || res.Error.Message != tt.err):
+				t.Errorf("error %v, want %q", res.Error, tt.err)
+			}
+			if _, err := json.Marshal(res); err != nil {
+				t.Errorf("result doesn't encode: %v", err)
+			}
+		})
+	}
+}
+
+func TestRunBinarySearch(t *testing.T) {
+	prog := Parse("function binary_search(arr, target):\n" +
+		"    lo, hi = 0, len(arr) - 1\n" +
+		"    while lo <= hi:\n" +
+		"        mid = lo + (hi - lo) // 2\n" +
+		"        if arr[mid] == target:\n" +
+		"            return mid\n" +
+		"        elif arr[mid] < target:\n" +
+		"            lo = mid + 1\n" +
+		"        else:\n" +
+		"            hi = mid - 1\n" +
+		"    return -1\n")
+	args := []any{[]any{1.0, 3.0, 5.0, 7.0, 9.0}, 7.0}
+	res, err := Run(prog, "binary_search", args, nil, testLimits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Error != nil || res.Result != int64(3) {
+		t.Errorf("result %v, error %v", res.Result, res.Error)
+	}
+
+	if _, err := Run(prog, "missing", nil, nil, testLimits); !errors.Is(err, ErrUnknownFunction) {
+		t.Errorf("unknown function: %v", err)
+	}
+	if _, err := Run(prog, "binary_search", nil, nil, testLimits); !errors.Is(err, ErrInvalidArguments) {
+		t.Errorf("missing arguments: %v", err)
+	}
+}
+
+// Every function in the seed catalog runs within the limits, whatever it
+// is given
+func TestRunSeedPseudoCode(t *testing.T) {
+	data, err := os.ReadFile("../seed_data.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var algos []struct {
+		ID         string `json:"id"`
+		PseudoCode string `json:"pseudoCode"`
+	}
+	if err := json.Unmarshal(data, &algos); err != nil {
+		t.Fatal(err)
+	}
+
+	inputs := []any{[]any{5.0, 1.0, 4.0, 2.0, 3.0}, 3.0, "abc", nil}
+	for _, algo := range algos {
+		t.Run(algo.ID, func(t *testing.T) {
+			if diags := Lint(algo.PseudoCode); hasError(diags) {
+				t.Errorf("lint errors: %v", diags)
+			}
+			prog := Parse(algo.PseudoCode)
+			for _, name := range prog.Functions() {
+				for _, input := range inputs {
+					// Find the arity by trying argument counts in turn
+					for n := 0; n <= 8; n++ {
+						args := make([]any, n)
+						for i := range args {
+							args[i] = input
+						}
+						res, err := Run(prog, name, args, nil, testLimits)
+						if errors.Is(err, ErrInvalidArguments) {
+							continue
+						}
+						if err != nil {
+							t.Errorf("%s: %v", name, err)
+						} else if _, err := json.Marshal(res); err != nil {
+							t.Errorf("%s: result doesn't encode: %v", name, err)
+						}
+						break
+					}
+				}
+			}
+		})
+	}
+}
+
+func hasError(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
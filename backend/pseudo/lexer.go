@@ -0,0 +1,198 @@
+package pseudo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNewline
+	tokIndent
+	tokDedent
+	tokName
+	tokNumber
+	tokString
+	tokOp
+	tokError // text is the message; the parser reports it
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	line int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of input"
+	case tokNewline:
+		return "end of line"
+	case tokIndent:
+		return "indent"
+	case tokDedent:
+		return "dedent"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// SyntaxError reports code that doesn't parse
+type SyntaxError struct {
+	Line int    `json:"line"`
+	Msg  string `json:"message"`
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// Operators, longest first so "//=" wins over "//" and "/"
+var operators = []string{
+	"//=", "**=", "<<=", ">>=", "...",
+	"==", "!=", "<=", ">=", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "//", "**", "<<", ">>", "->",
+	"+", "-", "*", "/", "%", "<", ">", "=", "(", ")", "[", "]", "{", "}", ",", ":", ".", ";", "&", "|", "^", "~",
+}
+
+// tabWidth is how many columns a tab indents
+const tabWidth = 4
+
+// lex splits source into tokens. Indentation becomes indent tokens, whose
+// text is the added width, and dedent tokens; line breaks inside brackets
+// are ignored, as in Python. Problems become error tokens so the parser can
+// report them and carry on with the next statement.
+func lex(src string) []token {
+	var tokens []token
+	indents := []int{0}
+	depth := 0 // bracket nesting
+
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	for n, line := range lines {
+		lineNo := n + 1
+		i := 0
+
+		if depth == 0 {
+			col := 0
+			for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+				if line[i] == '\t' {
+					col += tabWidth - col%tabWidth
+				} else {
+					col++
+				}
+				i++
+			}
+			if rest := strings.TrimSpace(line[i:]); rest == "" || strings.HasPrefix(rest, "#") {
+				continue
+			}
+			switch top := indents[len(indents)-1]; {
+			case col > top:
+				indents = append(indents, col)
+				tokens = append(tokens, token{tokIndent, strconv.Itoa(col - top), lineNo})
+			case col < top:
+				for col < indents[len(indents)-1] {
+					indents = indents[:len(indents)-1]
+					tokens = append(tokens, token{tokDedent, "", lineNo})
+				}
+				if col != indents[len(indents)-1] {
+					tokens = append(tokens, token{tokError, "indentation does not match any outer level", lineNo})
+				}
+			}
+		}
+
+		for i < len(line) {
+			c := rune(line[i])
+			switch {
+			case c == ' ' || c == '\t':
+				i++
+			case c == '#':
+				i = len(line)
+			case c == '"' || c == '\'':
+				j := i + 1
+				var sb strings.Builder
+				for j < len(line) && line[j] != line[i] {
+					if line[j] == '\\' && j+1 < len(line) {
+						j++
+						switch line[j] {
+						case 'n':
+							sb.WriteByte('\n')
+						case 't':
+							sb.WriteByte('\t')
+						default:
+							sb.WriteByte(line[j])
+						}
+					} else {
+						sb.WriteByte(line[j])
+					}
+					j++
+				}
+				if j >= len(line) {
+					tokens = append(tokens, token{tokError, "unterminated string", lineNo})
+					i = len(line)
+					continue
+				}
+				tokens = append(tokens, token{tokString, sb.String(), lineNo})
+				i = j + 1
+			case unicode.IsDigit(c) || (c == '.' && i+1 < len(line) && unicode.IsDigit(rune(line[i+1]))):
+				j := i
+				for j < len(line) && (unicode.IsDigit(rune(line[j])) || line[j] == '.' || line[j] == '_') {
+					if line[j] == '.' && j+1 < len(line) && line[j+1] == '.' {
+						break // "1..n" reads as 1, "..", n
+					}
+					j++
+				}
+				tokens = append(tokens, token{tokNumber, strings.ReplaceAll(line[i:j], "_", ""), lineNo})
+				i = j
+			case c == '_' || c < unicode.MaxASCII && unicode.IsLetter(c):
+				j := i
+				for j < len(line) && (line[j] == '_' || unicode.IsLetter(rune(line[j])) || unicode.IsDigit(rune(line[j]))) {
+					j++
+				}
+				tokens = append(tokens, token{tokName, line[i:j], lineNo})
+				i = j
+			default:
+				op := ""
+				for _, candidate := range operators {
+					if strings.HasPrefix(line[i:], candidate) {
+						op = candidate
+						break
+					}
+				}
+				if op == "" {
+					r, size := utf8.DecodeRuneInString(line[i:])
+					tokens = append(tokens, token{tokError, fmt.Sprintf("unexpected character %q", r), lineNo})
+					i += size
+					continue
+				}
+				switch op {
+				case "(", "[", "{":
+					depth++
+				case ")", "]", "}":
+					if depth > 0 {
+						depth--
+					}
+				}
+				tokens = append(tokens, token{tokOp, op, lineNo})
+				i += len(op)
+			}
+		}
+
+		if depth == 0 {
+			tokens = append(tokens, token{tokNewline, "", lineNo})
+		}
+	}
+
+	last := len(lines)
+	if depth > 0 {
+		tokens = append(tokens, token{tokError, "unclosed bracket", last}, token{tokNewline, "", last})
+	}
+	for len(indents) > 1 {
+		indents = indents[:len(indents)-1]
+		tokens = append(tokens, token{tokDedent, "", last})
+	}
+	return append(tokens, token{tokEOF, "", last})
+}
@@ -0,0 +1,503 @@
+package pseudo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severities of lint diagnostics. Errors leave the structure of the code
+// unclear; warnings are likely mistakes, or lines that read as prose.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Diagnostic is a problem the linter found
+type Diagnostic struct {
+	Line     int    `json:"line"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// structural are parser messages the linter reports itself, as errors
+var structural = map[string]bool{
+	"unexpected indent":                          true,
+	"expected an indented block":                 true,
+	"indentation does not match any outer level": true,
+	"unclosed bracket":                           true,
+}
+
+type linter struct {
+	diags      []Diagnostic
+	errorLines map[int]bool
+	reported   map[*lintScope]map[string]bool
+}
+
+// Lint checks pseudo code in the house style. Inconsistent indentation,
+// unbalanced blocks and unbalanced brackets are errors. Undefined names,
+// unreachable statements and lines that don't parse are warnings, since
+// pseudo code may mix in prose.
+func Lint(src string) []Diagnostic {
+	l := &linter{errorLines: make(map[int]bool), reported: make(map[*lintScope]map[string]bool)}
+	l.checkIndentation(src)
+	toks := lex(src)
+	l.checkTokens(toks)
+	l.checkBlocks(toks)
+
+	prog := parse(toks, true)
+	for _, w := range prog.Warnings {
+		if !l.errorLines[w.Line] && !structural[w.Msg] {
+			l.warnf(w.Line, "can't parse: %s", w.Msg)
+		}
+	}
+	module := &lintScope{names: make(map[string]bool)}
+	for name := range prog.broken {
+		module.names[name] = true
+	}
+	module.declare(prog.stmts, module)
+	l.checkBlock(prog.stmts, module)
+
+	sort.SliceStable(l.diags, func(i, j int) bool { return l.diags[i].Line < l.diags[j].Line })
+	return l.diags
+}
+
+func (l *linter) errorf(line int, format string, args ...any) {
+	l.errorLines[line] = true
+	l.diags = append(l.diags, Diagnostic{line, SeverityError, fmt.Sprintf(format, args...)})
+}
+
+func (l *linter) warnf(line int, format string, args ...any) {
+	l.diags = append(l.diags, Diagnostic{line, SeverityWarning, fmt.Sprintf(format, args...)})
+}
+
+// Structure
+
+// checkIndentation reports lines indented with tabs where others use
+// spaces, or the other way round, since the width of a tab is a guess
+func (l *linter) checkIndentation(src string) {
+	var first byte
+	firstLine := 0
+	kind := func(c byte) string {
+		if c == '\t' {
+			return "tabs"
+		}
+		return "spaces"
+	}
+	for n, line := range strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n") {
+		rest := strings.TrimLeft(line, " \t")
+		indent := line[:len(line)-len(rest)]
+		if indent == "" || rest == "" || strings.HasPrefix(rest, "#") {
+			continue
+		}
+		switch {
+		case strings.Contains(indent, " ") && strings.Contains(indent, "\t"):
+			l.errorf(n+1, "indentation mixes tabs and spaces")
+		case first == 0:
+			first, firstLine = indent[0], n+1
+		case indent[0] != first:
+			l.errorf(n+1, "indented with %s, but line %d uses %s", kind(indent[0]), firstLine, kind(first))
+		}
+	}
+}
+
+// checkTokens reports indentation that returns to no outer level, blocks
+// indented by a different width than the first, and unbalanced brackets
+func (l *linter) checkTokens(toks []token) {
+	closers := map[string]string{"(": ")", "[": "]", "{": "}"}
+	var open []token
+	var width token
+	for _, t := range toks {
+		switch t.kind {
+		case tokError:
+			if t.text == "indentation does not match any outer level" {
+				l.errorf(t.line, "%s", t.text)
+			}
+		case tokIndent:
+			if width.kind != tokIndent {
+				width = t
+			} else if t.text != width.text {
+				l.warnf(t.line, "indented by %s columns, but line %d indents by %s", t.text, width.line, width.text)
+			}
+		case tokOp:
+			switch t.text {
+			case "(", "[", "{":
+				open = append(open, t)
+			case ")", "]", "}":
+				if len(open) == 0 {
+					l.errorf(t.line, "unmatched %q", t.text)
+					continue
+				}
+				top := open[len(open)-1]
+				open = open[:len(open)-1]
+				if closers[top.text] != t.text {
+					l.errorf(t.line, "%q doesn't match %q on line %d", t.text, top.text, top.line)
+				}
+			}
+		}
+	}
+	for _, t := range open {
+		l.errorf(t.line, "%q is never closed", t.text)
+	}
+}
+
+// checkBlocks reports lines ending in ":" with no indented block after
+// them, indented lines with no such line before them, and else or elif
+// with no if to belong to
+func (l *linter) checkBlocks(toks []token) {
+	last := []string{""} // the word starting the previous statement at each level
+	header := 0          // line ending in ":" that still needs its block
+	for i := 0; ; {
+		indented := false
+		for ; toks[i].kind == tokIndent || toks[i].kind == tokDedent; i++ {
+			if toks[i].kind == tokIndent {
+				indented = true
+				last = append(last, "")
+			} else if len(last) > 1 {
+				last = last[:len(last)-1]
+			}
+		}
+		t := toks[i]
+		if t.kind == tokEOF {
+			if header > 0 {
+				l.errorf(header, "expected an indented block after this line")
+			}
+			return
+		}
+		switch {
+		case header > 0 && !indented:
+			l.errorf(t.line, "expected an indented block after line %d", header)
+		case header == 0 && indented:
+			l.errorf(t.line, "unexpected indent")
+		}
+
+		word := ""
+		if t.kind == tokError && t.text == "indentation does not match any outer level" {
+			word = "?" // already reported; any else may follow
+		} else if t.kind == tokName {
+			word = t.text
+			if next := toks[i+1]; word == "else" && next.kind == tokName && next.text == "if" {
+				word = "elif"
+			}
+		}
+		switch prev := last[len(last)-1]; word {
+		case "elif":
+			if prev != "if" && prev != "elif" && prev != "?" {
+				l.errorf(t.line, "%s without a matching if", t.text)
+			}
+		case "else":
+			switch prev {
+			case "if", "elif", "for", "while", "try", "except", "?":
+			default:
+				l.errorf(t.line, "else without a matching if")
+			}
+		}
+		last[len(last)-1] = word
+
+		header = 0
+		var end token
+		for ; toks[i].kind != tokNewline && toks[i].kind != tokEOF; i++ {
+			if toks[i].kind != tokError {
+				end = toks[i]
+			}
+		}
+		if end.kind == tokOp && end.text == ":" {
+			header = end.line
+		}
+		if toks[i].kind == tokNewline {
+			i++
+		}
+	}
+}
+
+// Names
+
+// lintScope holds the names a function, class or the module defines.
+// Assigning anywhere in a function defines the name throughout it.
+type lintScope struct {
+	parent *lintScope
+	names  map[string]bool
+}
+
+func newLintScope(parent *lintScope, params []param) *lintScope {
+	s := &lintScope{parent: parent, names: make(map[string]bool)}
+	for _, p := range params {
+		s.names[p.name] = true
+	}
+	return s
+}
+
+func (s *lintScope) defines(name string) bool {
+	for ; s != nil; s = s.parent {
+		if s.names[name] {
+			return true
+		}
+	}
+	_, ok := builtins[name]
+	return ok || isConstantName(name)
+}
+
+// declare adds the names stmts assign, without entering nested functions
+func (s *lintScope) declare(stmts []Stmt, module *lintScope) {
+	for _, st := range stmts {
+		switch st := st.(type) {
+		case *assignStmt:
+			for _, target := range st.targets {
+				s.declareTarget(target)
+			}
+		case *ifStmt:
+			s.declare(st.body, module)
+			s.declare(st.els, module)
+		case *whileStmt:
+			s.declare(st.body, module)
+			s.declare(st.els, module)
+		case *forStmt:
+			s.declareTarget(st.target)
+			s.declare(st.body, module)
+			s.declare(st.els, module)
+		case *forRangeStmt:
+			s.names[st.name] = true
+			s.declare(st.body, module)
+		case *funcDef:
+			s.names[st.name] = true
+		case *classDef:
+			s.names[st.name] = true
+		case *scopeStmt:
+			for _, name := range st.names {
+				s.names[name] = true
+				if st.keyword == "global" {
+					module.names[name] = true
+				}
+			}
+		case *badStmt:
+			for _, name := range st.names {
+				s.names[name] = true
+			}
+		}
+	}
+}
+
+func (s *lintScope) declareTarget(x Expr) {
+	switch x := x.(type) {
+	case *nameExpr:
+		s.names[x.name] = true
+	case *tupleExpr:
+		for _, e := range x.elems {
+			s.declareTarget(e)
+		}
+	case *listExpr:
+		for _, e := range x.elems {
+			s.declareTarget(e)
+		}
+	}
+}
+
+func (s *lintScope) module() *lintScope {
+	for s.parent != nil {
+		s = s.parent
+	}
+	return s
+}
+
+// checkBlock checks names in stmts and reports the first statement that
+// can't be reached
+func (l *linter) checkBlock(stmts []Stmt, s *lintScope) {
+	var exit Stmt
+	reported := false
+	for _, st := range stmts {
+		if exit != nil && !reported {
+			l.unreachable(st, exit)
+			reported = true
+		}
+		l.checkStmt(st, s)
+		if exit == nil && leaves(st) {
+			exit = st
+		}
+	}
+}
+
+func (l *linter) unreachable(st, exit Stmt) {
+	switch exit := exit.(type) {
+	case *returnStmt:
+		l.warnf(st.stmtLine(), "unreachable code after the return on line %d", exit.line)
+	case *breakStmt:
+		l.warnf(st.stmtLine(), "unreachable code after the break on line %d", exit.line)
+	case *continueStmt:
+		l.warnf(st.stmtLine(), "unreachable code after the continue on line %d", exit.line)
+	default:
+		l.warnf(st.stmtLine(), "unreachable code: every branch of the if on line %d returns or jumps", exit.stmtLine())
+	}
+}
+
+// leaves reports whether control never continues past st
+func leaves(st Stmt) bool {
+	switch st := st.(type) {
+	case *returnStmt, *breakStmt, *continueStmt:
+		return true
+	case *ifStmt:
+		return st.els != nil && blockLeaves(st.body) && blockLeaves(st.els)
+	}
+	return false
+}
+
+func blockLeaves(stmts []Stmt) bool {
+	for _, st := range stmts {
+		if leaves(st) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *linter) checkStmt(st Stmt, s *lintScope) {
+	switch st := st.(type) {
+	case *exprStmt:
+		l.checkExpr(st.x, s)
+	case *assignStmt:
+		l.checkExpr(st.value, s)
+		for _, target := range st.targets {
+			l.checkTarget(target, s)
+		}
+	case *augAssignStmt:
+		l.checkExpr(st.target, s)
+		l.checkExpr(st.value, s)
+	case *ifStmt:
+		l.checkExpr(st.cond, s)
+		l.checkBlock(st.body, s)
+		l.checkBlock(st.els, s)
+	case *whileStmt:
+		l.checkExpr(st.cond, s)
+		l.checkBlock(st.body, s)
+		l.checkBlock(st.els, s)
+	case *forStmt:
+		l.checkExpr(st.iter, s)
+		l.checkTarget(st.target, s)
+		l.checkBlock(st.body, s)
+		l.checkBlock(st.els, s)
+	case *forRangeStmt:
+		l.checkExpr(st.from, s)
+		l.checkExpr(st.to, s)
+		l.checkBlock(st.body, s)
+	case *returnStmt:
+		if st.value != nil {
+			l.checkExpr(st.value, s)
+		}
+	case *funcDef:
+		l.checkFunc(st, s)
+	case *classDef:
+		// Inside methods, fields and methods are in scope without self
+		class := newLintScope(s, st.fields)
+		class.names["self"] = true
+		for _, f := range st.fields {
+			if f.def != nil {
+				l.checkExpr(f.def, s)
+			}
+		}
+		for _, m := range st.methods {
+			class.names[m.name] = true
+		}
+		for _, m := range st.methods {
+			l.checkFunc(m, class)
+		}
+	}
+}
+
+func (l *linter) checkFunc(fn *funcDef, s *lintScope) {
+	for _, p := range fn.params {
+		if p.def != nil {
+			l.checkExpr(p.def, s)
+		}
+	}
+	local := newLintScope(s, fn.params)
+	local.declare(fn.body, s.module())
+	l.checkBlock(fn.body, local)
+}
+
+// checkTarget checks the names an assignment target reads, such as the
+// list in xs[i] = v
+func (l *linter) checkTarget(x Expr, s *lintScope) {
+	switch x := x.(type) {
+	case *nameExpr:
+	case *tupleExpr:
+		for _, e := range x.elems {
+			l.checkTarget(e, s)
+		}
+	case *listExpr:
+		for _, e := range x.elems {
+			l.checkTarget(e, s)
+		}
+	default:
+		l.checkExpr(x, s)
+	}
+}
+
+func (l *linter) checkExpr(x Expr, s *lintScope) {
+	check := func(xs ...Expr) {
+		for _, x := range xs {
+			if x != nil {
+				l.checkExpr(x, s)
+			}
+		}
+	}
+	switch x := x.(type) {
+	case *nameExpr:
+		if !s.defines(x.name) && !l.reported[s][x.name] {
+			if l.reported[s] == nil {
+				l.reported[s] = make(map[string]bool)
+			}
+			l.reported[s][x.name] = true
+			l.warnf(x.line, "%s is not defined", x.name)
+		}
+	case *listExpr:
+		check(x.elems...)
+	case *tupleExpr:
+		check(x.elems...)
+	case *setExpr:
+		check(x.elems...)
+	case *dictExpr:
+		check(x.keys...)
+		check(x.values...)
+	case *unaryExpr:
+		check(x.x)
+	case *binaryExpr:
+		check(x.l, x.r)
+	case *compareExpr:
+		check(x.operands...)
+	case *boolExpr:
+		check(x.l, x.r)
+	case *condExpr:
+		check(x.cond, x.then, x.els)
+	case *callExpr:
+		check(x.fn)
+		check(x.args...)
+		for _, kw := range x.kwargs {
+			check(kw.value)
+		}
+	case *attrExpr:
+		check(x.x)
+	case *indexExpr:
+		check(x.x, x.index)
+	case *sliceExpr:
+		check(x.x, x.lo, x.hi, x.step)
+	case *lambdaExpr:
+		for _, p := range x.params {
+			check(p.def)
+		}
+		l.checkExpr(x.body, newLintScope(s, x.params))
+	case *compExpr:
+		inner := newLintScope(s, nil)
+		for _, c := range x.clauses {
+			inner.declareTarget(c.target)
+		}
+		for _, c := range x.clauses {
+			l.checkExpr(c.iter, inner)
+			for _, cond := range c.conds {
+				l.checkExpr(cond, inner)
+			}
+		}
+		l.checkExpr(x.elem, inner)
+		if x.key != nil {
+			l.checkExpr(x.key, inner)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package pseudo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	errorAt := func(line int, msg string) Diagnostic {
+		return Diagnostic{Line: line, Severity: SeverityError, Message: msg}
+	}
+	warningAt := func(line int, msg string) Diagnostic {
+		return Diagnostic{Line: line, Severity: SeverityWarning, Message: msg}
+	}
+
+	tests := []struct {
+		name string
+		src  string
+		want []Diagnostic
+	}{
+		{
+			name: "clean",
+			src:  "function f(x):\n    if x:\n        return 1\n    return 2",
+			want: []Diagnostic{},
+		},
+		{
+			name: "tabs and spaces",
+			src:  "function f(x):\n\tx = 1\n        return x",
+			want: []Diagnostic{
+				errorAt(3, "indented with spaces, but line 2 uses tabs"),
+				errorAt(3, "unexpected indent"),
+			},
+		},
+		{
+			name: "dedent to no outer level",
+			src:  "function f(x):\n    if x:\n        x = 1\n      return x",
+			want: []Diagnostic{errorAt(4, "indentation does not match any outer level")},
+		},
+		{
+			name: "undefined name",
+			src:  "function f(a):\n    return a + b",
+			want: []Diagnostic{warningAt(2, "b is not defined")},
+		},
+		{
+			name: "missing block",
+			src:  "function f(x):\nreturn x",
+			want: []Diagnostic{errorAt(2, "expected an indented block after line 1")},
+		},
+		{
+			name: "else without if",
+			src:  "function f(x):\n    x = 1\nelse:\n    x = 2",
+			want: []Diagnostic{errorAt(3, "else without a matching if")},
+		},
+		{
+			name: "unclosed bracket",
+			src:  "function f(x):\n    return [x, 2",
+			want: []Diagnostic{errorAt(2, `"[" is never closed`)},
+		},
+		{
+			name: "mismatched bracket",
+			src:  "function f(x):\n    x = (1, 2]\n    return x",
+			want: []Diagnostic{errorAt(2, `"]" doesn't match "(" on line 2`)},
+		},
+		{
+			name: "unreachable return",
+			src:  "function f(x):\n    return 1\n    return 2",
+			want: []Diagnostic{warningAt(3, "unreachable code after the return on line 2")},
+		},
+	}
+	for _, tt := range tests {
+		got := Lint(tt.src)
+		if got == nil {
+			got = []Diagnostic{}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,616 @@
+package pseudo
+
+import (
+	"strings"
+)
+
+// getAttr reads a field, a method, or one of the properties every value
+// has: length, and neighbors for "for neighbor in node.neighbors"
+func (in *Interp) getAttr(obj Value, name string) (Value, error) {
+	switch o := obj.(type) {
+	case *Instance:
+		if v, ok := o.get(name); ok {
+			return v, nil
+		}
+		if m, ok := o.class.methods[name]; ok {
+			return &boundMethod{fn: m, self: o}, nil
+		}
+		return nil, in.errorf("%s has no field or method %s", o.class.name, name)
+	case *Module:
+		if v, ok := o.attrs[name]; ok {
+			return v, nil
+		}
+		return nil, in.errorf("module %s has no %s", o.name, name)
+	}
+
+	if m := in.method(obj, name); m != nil {
+		return &Builtin{name: name, fn: m}, nil
+	}
+	// JSON objects passed as input read like objects
+	if d, ok := obj.(*Dict); ok {
+		if v, ok := d.get(name); ok {
+			return v, nil
+		}
+	}
+	switch name {
+	case "length":
+		if n, ok := length(obj); ok {
+			return int64(n), nil
+		}
+	case "neighbors":
+		return builtinNeighbors(in, []Value{obj}, nil)
+	}
+	return nil, in.errorf("%s has no attribute %s", typeName(obj), name)
+}
+
+func (in *Interp) setAttr(obj Value, name string, v Value) error {
+	switch o := obj.(type) {
+	case *Instance:
+		if _, ok := o.get(name); !ok {
+			if err := in.alloc(1); err != nil {
+				return err
+			}
+		}
+		o.set(name, v)
+		return nil
+	case *Dict:
+		if o.set(name, v) {
+			return in.alloc(1)
+		}
+		return nil
+	}
+	return in.errorf("can't set attribute %s on %s", name, typeName(obj))
+}
+
+// method returns a builtin type's method bound to obj, or nil. The common
+// spellings from different languages all work: a queue can enqueue, push
+// or append.
+func (in *Interp) method(obj Value, name string) builtinFunc {
+	var m builtinFunc
+	switch o := obj.(type) {
+	case *List:
+		m = in.listMethod(o, name)
+	case *Queue:
+		m = in.queueMethod(o, name)
+	case *Stack:
+		m = in.stackMethod(o, name)
+	case *Set:
+		m = in.setMethod(o, name)
+	case *Dict:
+		m = in.dictMethod(o, name)
+	case *PriorityQueue:
+		m = in.pqMethod(o, name)
+	case string:
+		m = in.stringMethod(o, name)
+	case Tuple:
+		m = in.seqMethod(o, name)
+	}
+	if m != nil {
+		return m
+	}
+	if _, ok := length(obj); !ok {
+		return nil
+	}
+	switch name {
+	case "is_empty", "isEmpty", "empty":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			n, _ := length(obj)
+			return n == 0, in.arity(name, args, 0, 0)
+		}
+	case "size", "len", "length":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			n, _ := length(obj)
+			return int64(n), in.arity(name, args, 0, 0)
+		}
+	}
+	return nil
+}
+
+// seqMethod covers the read-only methods of lists and tuples
+func (in *Interp) seqMethod(items []Value, name string) builtinFunc {
+	switch name {
+	case "index":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 1, 1); err != nil {
+				return nil, err
+			}
+			for i, v := range items {
+				if equal(v, args[0]) {
+					return int64(i), nil
+				}
+			}
+			return nil, in.errorf("%s is not in the list", repr(args[0]))
+		}
+	case "count":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 1, 1); err != nil {
+				return nil, err
+			}
+			n := int64(0)
+			for _, v := range items {
+				if equal(v, args[0]) {
+					n++
+				}
+			}
+			return n, nil
+		}
+	}
+	return nil
+}
+
+func (in *Interp) listMethod(l *List, name string) builtinFunc {
+	if m := in.seqMethod(l.items, name); m != nil {
+		return m
+	}
+	popAt := func(i int) Value {
+		v := l.items[i]
+		l.items = append(l.items[:i], l.items[i+1:]...)
+		return v
+	}
+	switch name {
+	case "append", "push", "add", "enqueue", "push_back":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 1, 1); err != nil {
+				return nil, err
+			}
+			l.items = append(l.items, args[0])
+			return nil, in.alloc(1)
+		}
+	case "prepend", "appendleft", "push_front", "unshift":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 1, 1); err != nil {
+				return nil, err
+			}
+			l.items = append([]Value{args[0]}, l.items...)
+			return nil, in.alloc(1)
+		}
+	case "pop":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 0, 1); err != nil {
+				return nil, err
+			}
+			if len(l.items) == 0 {
+				return nil, in.errorf("pop from an empty list")
+			}
+			if len(args) == 0 {
+				return popAt(len(l.items) - 1), nil
+			}
+			i, _, err := in.position(l, args[0])
+			if err != nil {
+				return nil, err
+			}
+			return popAt(i), nil
+		}
+	case "pop_front", "popleft", "dequeue", "shift", "poll":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 0, 0); err != nil {
+				return nil, err
+			}
+			if len(l.items) == 0 {
+				return nil, in.errorf("%s from an empty list", name)
+			}
+			return popAt(0), nil
+		}
+	case "extend":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 1, 1); err != nil {
+				return nil, err
+			}
+			items, err := in.toSlice(args[0])
+			if err != nil {
+				return nil, err
+			}
+			l.items = append(l.items, items...)
+			return nil, in.alloc(len(items))
+		}
+	case "insert":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 2, 2); err != nil {
+				return nil, err
+			}
+			i, ok := toInt(args[0])
+			if !ok {
+				return nil, in.errorf("insert needs an integer position")
+			}
+			if i < 0 {
+				i += int64(len(l.items))
+			}
+			i = min(max(i, 0), int64(len(l.items)))
+			l.items = append(l.items[:i], append([]Value{args[1]}, l.items[i:]...)...)
+			return nil, in.alloc(1)
+		}
+	case "remove":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 1, 1); err != nil {
+				return nil, err
+			}
+			for i, v := range l.items {
+				if equal(v, args[0]) {
+					popAt(i)
+					return nil, nil
+				}
+			}
+			return nil, in.errorf("%s is not in the list", repr(args[0]))
+		}
+	case "sort":
+		return func(in *Interp, args []Value, kwargs map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 0, 0); err != nil {
+				return nil, err
+			}
+			return nil, in.sortValues(l.items, kwargs)
+		}
+	case "reverse":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			for i, j := 0, len(l.items)-1; i < j; i, j = i+1, j-1 {
+				l.items[i], l.items[j] = l.items[j], l.items[i]
+			}
+			return nil, in.arity(name, args, 0, 0)
+		}
+	case "copy":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			return &List{append([]Value{}, l.items...)}, in.alloc(len(l.items))
+		}
+	case "peek", "top", "last", "back":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if len(l.items) == 0 {
+				return nil, in.errorf("%s of an empty list", name)
+			}
+			return l.items[len(l.items)-1], in.arity(name, args, 0, 0)
+		}
+	case "front", "first":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if len(l.items) == 0 {
+				return nil, in.errorf("%s of an empty list", name)
+			}
+			return l.items[0], in.arity(name, args, 0, 0)
+		}
+	case "clear":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			l.items = l.items[:0]
+			return nil, in.arity(name, args, 0, 0)
+		}
+	}
+	return nil
+}
+
+func (in *Interp) queueMethod(q *Queue, name string) builtinFunc {
+	switch name {
+	case "enqueue", "push", "append", "add", "offer", "put":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 1, 1); err != nil {
+				return nil, err
+			}
+			q.items = append(q.items, args[0])
+			return nil, in.alloc(1)
+		}
+	case "dequeue", "pop", "popleft", "poll", "get", "pop_front":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 0, 0); err != nil {
+				return nil, err
+			}
+			if len(q.items) == 0 {
+				return nil, in.errorf("%s from an empty queue", name)
+			}
+			v := q.items[0]
+			q.items = q.items[1:]
+			return v, nil
+		}
+	case "peek", "front", "first":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if len(q.items) == 0 {
+				return nil, in.errorf("%s of an empty queue", name)
+			}
+			return q.items[0], in.arity(name, args, 0, 0)
+		}
+	}
+	return nil
+}
+
+func (in *Interp) stackMethod(s *Stack, name string) builtinFunc {
+	switch name {
+	case "push", "append", "add":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 1, 1); err != nil {
+				return nil, err
+			}
+			s.items = append(s.items, args[0])
+			return nil, in.alloc(1)
+		}
+	case "pop":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 0, 0); err != nil {
+				return nil, err
+			}
+			if len(s.items) == 0 {
+				return nil, in.errorf("pop from an empty stack")
+			}
+			v := s.items[len(s.items)-1]
+			s.items = s.items[:len(s.items)-1]
+			return v, nil
+		}
+	case "peek", "top":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if len(s.items) == 0 {
+				return nil, in.errorf("%s of an empty stack", name)
+			}
+			return s.items[len(s.items)-1], in.arity(name, args, 0, 0)
+		}
+	}
+	return nil
+}
+
+func (in *Interp) setMethod(s *Set, name string) builtinFunc {
+	switch name {
+	case "add", "insert":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 1, 1); err != nil {
+				return nil, err
+			}
+			if s.add(args[0]) {
+				return nil, in.alloc(1)
+			}
+			return nil, nil
+		}
+	case "remove", "discard", "delete":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 1, 1); err != nil {
+				return nil, err
+			}
+			if _, ok := s.d.remove(args[0]); !ok && name == "remove" {
+				return nil, in.errorf("%s is not in the set", repr(args[0]))
+			}
+			return nil, nil
+		}
+	case "contains", "has", "includes":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 1, 1); err != nil {
+				return nil, err
+			}
+			return s.has(args[0]), nil
+		}
+	case "union", "intersection", "difference":
+		op := map[string]string{"union": "|", "intersection": "&", "difference": "-"}[name]
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 1, 1); err != nil {
+				return nil, err
+			}
+			other, err := builtinSet(in, args, nil)
+			if err != nil {
+				return nil, err
+			}
+			return in.setOp(op, s, other.(*Set))
+		}
+	case "pop":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if s.d.len() == 0 {
+				return nil, in.errorf("pop from an empty set")
+			}
+			v := s.d.keys[0]
+			s.d.remove(v)
+			return v, in.arity(name, args, 0, 0)
+		}
+	case "copy":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			return builtinSet(in, []Value{s}, nil)
+		}
+	case "clear":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			*s = *newSet()
+			return nil, in.arity(name, args, 0, 0)
+		}
+	}
+	return nil
+}
+
+func (in *Interp) dictMethod(d *Dict, name string) builtinFunc {
+	switch name {
+	case "get", "getOrDefault", "get_or_default":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 1, 2); err != nil {
+				return nil, err
+			}
+			if v, ok := d.get(args[0]); ok {
+				return v, nil
+			}
+			if len(args) == 2 {
+				return args[1], nil
+			}
+			return nil, nil
+		}
+	case "keys", "values", "items":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 0, 0); err != nil {
+				return nil, err
+			}
+			out := make([]Value, d.len())
+			for i, k := range d.keys {
+				switch name {
+				case "keys":
+					out[i] = k
+				case "values":
+					out[i] = d.values[i]
+				default:
+					out[i] = Tuple{k, d.values[i]}
+				}
+			}
+			return &List{out}, in.alloc(len(out))
+		}
+	case "has", "contains", "containsKey", "has_key":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 1, 1); err != nil {
+				return nil, err
+			}
+			_, ok := d.get(args[0])
+			return ok, nil
+		}
+	case "put", "set":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 2, 2); err != nil {
+				return nil, err
+			}
+			return nil, in.setIndex(d, args[0], args[1])
+		}
+	case "pop", "remove", "delete":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 1, 2); err != nil {
+				return nil, err
+			}
+			if v, ok := d.remove(args[0]); ok {
+				return v, nil
+			}
+			if len(args) == 2 {
+				return args[1], nil
+			}
+			return nil, in.errorf("key %s not found", repr(args[0]))
+		}
+	case "setdefault":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 1, 2); err != nil {
+				return nil, err
+			}
+			if v, ok := d.get(args[0]); ok {
+				return v, nil
+			}
+			var def Value
+			if len(args) == 2 {
+				def = args[1]
+			}
+			return def, in.setIndex(d, args[0], def)
+		}
+	case "update":
+		return func(in *Interp, args []Value, kwargs map[string]Value) (Value, error) {
+			src, err := builtinDict(in, args, kwargs)
+			if err != nil {
+				return nil, err
+			}
+			for i, k := range src.(*Dict).keys {
+				if err := in.setIndex(d, k, src.(*Dict).values[i]); err != nil {
+					return nil, err
+				}
+			}
+			return nil, nil
+		}
+	case "copy":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			return builtinDict(in, []Value{d}, nil)
+		}
+	case "clear":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			*d = *newDict()
+			return nil, in.arity(name, args, 0, 0)
+		}
+	}
+	return nil
+}
+
+// pqMethod: push(x) uses x as its own priority, so tuples like
+// (distance, node) order by distance; push(priority, x) keeps them apart
+func (in *Interp) pqMethod(pq *PriorityQueue, name string) builtinFunc {
+	switch name {
+	case "push", "insert", "add", "enqueue", "offer", "put":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 1, 2); err != nil {
+				return nil, err
+			}
+			if len(args) == 1 {
+				pq.push(args[0], args[0])
+			} else {
+				pq.push(args[0], args[1])
+			}
+			return nil, in.alloc(1)
+		}
+	case "pop", "pop_min", "extract_min", "dequeue", "poll", "get":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 0, 0); err != nil {
+				return nil, err
+			}
+			if len(pq.entries) == 0 {
+				return nil, in.errorf("%s from an empty priority queue", name)
+			}
+			return pq.pop(), nil
+		}
+	case "peek", "top", "min":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if len(pq.entries) == 0 {
+				return nil, in.errorf("%s of an empty priority queue", name)
+			}
+			return pq.peek(), in.arity(name, args, 0, 0)
+		}
+	}
+	return nil
+}
+
+func (in *Interp) stringMethod(s, name string) builtinFunc {
+	str1 := func(f func(string) Value) builtinFunc {
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 0, 0); err != nil {
+				return nil, err
+			}
+			return f(s), nil
+		}
+	}
+	withString := func(f func(arg string) Value) builtinFunc {
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 1, 1); err != nil {
+				return nil, err
+			}
+			arg, ok := args[0].(string)
+			if !ok {
+				return nil, in.errorf("%s needs a string, not %s", name, typeName(args[0]))
+			}
+			return f(arg), nil
+		}
+	}
+	switch name {
+	case "upper":
+		return str1(func(s string) Value { return strings.ToUpper(s) })
+	case "lower":
+		return str1(func(s string) Value { return strings.ToLower(s) })
+	case "strip":
+		return str1(func(s string) Value { return strings.TrimSpace(s) })
+	case "startswith":
+		return withString(func(p string) Value { return strings.HasPrefix(s, p) })
+	case "endswith":
+		return withString(func(p string) Value { return strings.HasSuffix(s, p) })
+	case "find":
+		return withString(func(p string) Value { return int64(strings.Index(s, p)) })
+	case "count":
+		return withString(func(p string) Value { return int64(strings.Count(s, p)) })
+	case "split":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 0, 1); err != nil {
+				return nil, err
+			}
+			var parts []string
+			if len(args) == 0 {
+				parts = strings.Fields(s)
+			} else if sep, ok := args[0].(string); ok && sep != "" {
+				parts = strings.Split(s, sep)
+			} else {
+				return nil, in.errorf("split needs a non-empty separator")
+			}
+			out := make([]Value, len(parts))
+			for i, p := range parts {
+				out[i] = p
+			}
+			return &List{out}, in.alloc(len(s) + len(parts))
+		}
+	case "join":
+		return func(in *Interp, args []Value, _ map[string]Value) (Value, error) {
+			if err := in.arity(name, args, 1, 1); err != nil {
+				return nil, err
+			}
+			items, err := in.toSlice(args[0])
+			if err != nil {
+				return nil, err
+			}
+			parts := make([]string, len(items))
+			for i, v := range items {
+				parts[i] = str(v)
+			}
+			joined := strings.Join(parts, s)
+			return joined, in.alloc(len(joined))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,1079 @@
+package pseudo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// keywords can't be used as names. Words like "each", "of", "from", "to"
+// and "empty" only mean something in context and stay usable as names.
+var keywords = map[string]bool{
+	"function": true, "def": true, "class": true, "return": true,
+	"if": true, "elif": true, "else": true, "while": true, "for": true,
+	"in": true, "not": true, "and": true, "or": true, "is": true,
+	"break": true, "continue": true, "pass": true, "new": true, "lambda": true,
+	"global": true, "nonlocal": true,
+	"null": true, "None": true, "True": true, "False": true, "true": true, "false": true,
+}
+
+// maxNesting bounds how deeply expressions and blocks may nest, so a
+// hostile input can't exhaust the parser's stack
+const maxNesting = 100
+
+type parser struct {
+	toks     []token
+	pos      int
+	nesting  int
+	indent   int  // indent tokens consumed minus dedents, for error recovery
+	linting  bool // skip statements that don't parse inside blocks too
+	warnings []*SyntaxError
+}
+
+// parseError aborts the statement being parsed
+type parseError struct{ err *SyntaxError }
+
+// peek returns the next token, failing on a lexer error so that it is
+// reported where it occurs
+func (p *parser) peek() token {
+	t := p.toks[p.pos]
+	if t.kind == tokError {
+		p.fail(t, "%s", t.text)
+	}
+	return t
+}
+
+func (p *parser) peekAt(n int) token {
+	if p.pos+n < len(p.toks) {
+		return p.toks[p.pos+n]
+	}
+	return p.toks[len(p.toks)-1]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	switch t.kind {
+	case tokIndent:
+		p.indent++
+	case tokDedent:
+		p.indent--
+	}
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) fail(t token, format string, args ...any) {
+	panic(parseError{&SyntaxError{Line: t.line, Msg: fmt.Sprintf(format, args...)}})
+}
+
+func (p *parser) isOp(op string) bool {
+	t := p.peek()
+	return t.kind == tokOp && t.text == op
+}
+
+func (p *parser) isName(name string) bool {
+	t := p.peek()
+	return t.kind == tokName && t.text == name
+}
+
+func (p *parser) acceptOp(op string) bool {
+	if p.isOp(op) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) acceptName(name string) bool {
+	if p.isName(name) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectOp(op string) token {
+	if !p.isOp(op) {
+		p.fail(p.peek(), "expected %q, found %s", op, p.peek())
+	}
+	return p.next()
+}
+
+func (p *parser) expectName(name string) {
+	if !p.isName(name) {
+		p.fail(p.peek(), "expected %q, found %s", name, p.peek())
+	}
+	p.next()
+}
+
+// ident reads a name that isn't a keyword
+func (p *parser) ident() string {
+	t := p.peek()
+	if t.kind != tokName || keywords[t.text] {
+		p.fail(t, "expected a name, found %s", t)
+	}
+	p.next()
+	return t.text
+}
+
+func (p *parser) nest() {
+	p.nesting++
+	if p.nesting > maxNesting {
+		p.fail(p.peek(), "nested too deeply")
+	}
+}
+
+func (p *parser) unnest() { p.nesting-- }
+
+// endOfStatement is true where a simple statement must stop
+func (p *parser) endOfStatement() bool {
+	switch p.peek().kind {
+	case tokNewline, tokEOF, tokDedent:
+		return true
+	}
+	return p.isOp(";")
+}
+
+// Statements
+
+// statement parses one statement; simple statements joined by ";" come
+// back together
+func (p *parser) statement() []Stmt {
+	t := p.peek()
+	if t.kind == tokName {
+		switch t.text {
+		case "function", "def":
+			return []Stmt{p.funcDef()}
+		case "class":
+			return []Stmt{p.classDef()}
+		case "if":
+			return []Stmt{p.ifStmt()}
+		case "while":
+			return []Stmt{p.whileStmt()}
+		case "for":
+			return []Stmt{p.forStmt()}
+		case "import":
+			p.skipLine()
+			return nil
+		case "from":
+			// "from x import y", but not an assignment to a variable named from
+			if next := p.peekAt(1); next.kind == tokName && !keywords[next.text] {
+				p.skipLine()
+				return nil
+			}
+		}
+	}
+	stmts := p.simpleStatements()
+	p.endLine()
+	return stmts
+}
+
+func (p *parser) skipLine() {
+	for p.peek().kind != tokNewline && p.peek().kind != tokEOF {
+		p.next()
+	}
+	p.endLine()
+}
+
+func (p *parser) endLine() {
+	switch t := p.peek(); t.kind {
+	case tokNewline:
+		p.next()
+	case tokEOF, tokDedent:
+	default:
+		p.fail(t, "unexpected %s", t)
+	}
+}
+
+func (p *parser) simpleStatements() []Stmt {
+	var stmts []Stmt
+	for {
+		if s := p.simpleStatement(); s != nil {
+			stmts = append(stmts, s)
+		}
+		if !p.acceptOp(";") || p.endOfStatement() {
+			return stmts
+		}
+	}
+}
+
+func (p *parser) simpleStatement() Stmt {
+	t := p.peek()
+	line := pos{t.line}
+	if t.kind == tokName {
+		switch t.text {
+		case "return":
+			p.next()
+			if p.endOfStatement() {
+				return &returnStmt{pos: line}
+			}
+			return &returnStmt{pos: line, value: p.exprList()}
+		case "break":
+			p.next()
+			return &breakStmt{line}
+		case "continue":
+			p.next()
+			return &continueStmt{line}
+		case "pass":
+			p.next()
+			return &passStmt{line}
+		case "global", "nonlocal":
+			p.next()
+			names := []string{p.ident()}
+			for p.acceptOp(",") {
+				names = append(names, p.ident())
+			}
+			return &scopeStmt{pos: line, keyword: t.text, names: names}
+		}
+	}
+	if p.acceptOp("...") {
+		return &passStmt{line}
+	}
+
+	x := p.exprList()
+	if p.isOp("=") {
+		targets := []Expr{x}
+		for p.acceptOp("=") {
+			targets = append(targets, p.exprList())
+		}
+		value := targets[len(targets)-1]
+		targets = targets[:len(targets)-1]
+		// A math-style definition: square(x) = x * x
+		if call, ok := targets[0].(*callExpr); ok && len(targets) == 1 {
+			return p.shortDef(call, value)
+		}
+		for _, target := range targets {
+			p.checkTarget(target)
+		}
+		return &assignStmt{pos: line, targets: targets, value: value}
+	}
+	if op := p.peek(); op.kind == tokOp && len(op.text) >= 2 && strings.HasSuffix(op.text, "=") && !isComparison(op.text) {
+		p.next()
+		p.checkTarget(x)
+		if _, ok := x.(*tupleExpr); ok {
+			p.fail(op, "can't use %s with several targets", op.text)
+		}
+		return &augAssignStmt{pos: line, target: x, op: strings.TrimSuffix(op.text, "="), value: p.exprList()}
+	}
+	// An annotated declaration such as "count: int" or "x: int = 0"
+	if _, ok := x.(*nameExpr); ok && p.acceptOp(":") {
+		p.expr()
+		if p.acceptOp("=") {
+			return &assignStmt{pos: line, targets: []Expr{x}, value: p.exprList()}
+		}
+		return &passStmt{line}
+	}
+	return &exprStmt{pos: line, x: x}
+}
+
+// shortDef turns f(a, b) = expr into a function returning expr
+func (p *parser) shortDef(call *callExpr, value Expr) Stmt {
+	name, ok := call.fn.(*nameExpr)
+	if !ok || len(call.kwargs) > 0 {
+		p.fail(token{line: call.line}, "can't assign to this expression")
+	}
+	fn := &funcDef{pos: call.pos, name: name.name}
+	for _, arg := range call.args {
+		prm, ok := arg.(*nameExpr)
+		if !ok {
+			p.fail(token{line: call.line}, "can't assign to this expression")
+		}
+		fn.params = append(fn.params, param{name: prm.name})
+	}
+	fn.body = []Stmt{&returnStmt{pos: call.pos, value: value}}
+	return fn
+}
+
+func isComparison(op string) bool {
+	switch op {
+	case "==", "!=", "<=", ">=":
+		return true
+	}
+	return false
+}
+
+// checkTarget fails unless x can be assigned to
+func (p *parser) checkTarget(x Expr) {
+	switch x := x.(type) {
+	case *nameExpr, *attrExpr, *indexExpr:
+		return
+	case *tupleExpr:
+		for _, e := range x.elems {
+			p.checkTarget(e)
+		}
+		return
+	case *listExpr:
+		for _, e := range x.elems {
+			p.checkTarget(e)
+		}
+		return
+	}
+	p.fail(token{line: x.exprLine()}, "can't assign to this expression")
+}
+
+// block parses the suite after a ":", indented or on the same line
+func (p *parser) block() []Stmt {
+	p.expectOp(":")
+	if p.peek().kind != tokNewline {
+		stmts := p.simpleStatements()
+		p.endLine()
+		return stmts
+	}
+	p.next()
+	if p.peek().kind != tokIndent {
+		p.fail(p.peek(), "expected an indented block")
+	}
+	p.next()
+	p.nest()
+	defer p.unnest()
+	var stmts []Stmt
+	for p.peek().kind != tokDedent && p.peek().kind != tokEOF {
+		if bad := p.guard(func() { stmts = append(stmts, p.statement()...) }); bad != nil {
+			stmts = append(stmts, bad)
+		}
+	}
+	if p.peek().kind == tokDedent {
+		p.next()
+	}
+	return stmts
+}
+
+// guard runs parse on a statement inside a block. When linting, a
+// statement that doesn't parse is reported and skipped, and a stand-in
+// returned, so the rest of the block is still checked.
+func (p *parser) guard(parse func()) (bad *badStmt) {
+	if !p.linting {
+		parse()
+		return nil
+	}
+	start, indent := p.pos, p.indent
+	defer func() {
+		if r := recover(); r != nil {
+			pe, ok := r.(parseError)
+			if !ok {
+				panic(r)
+			}
+			p.warnings = append(p.warnings, pe.err)
+			bad = p.skipStatement(start, indent)
+		}
+	}()
+	parse()
+	return nil
+}
+
+// skipStatement skips to the end of the statement that began at start,
+// including any block under it
+func (p *parser) skipStatement(start, indent int) *badStmt {
+	bad := &badStmt{pos: pos{p.toks[start].line}}
+	for i := start; i < p.pos; i++ {
+		if t := p.toks[i]; t.kind == tokName && !keywords[t.text] {
+			bad.names = append(bad.names, t.text)
+		}
+	}
+	depth := p.indent - indent
+	for {
+		t := p.toks[p.pos]
+		switch t.kind {
+		case tokEOF:
+			p.indent = indent
+			return bad
+		case tokIndent:
+			depth++
+		case tokDedent:
+			if depth == 0 {
+				p.indent = indent
+				return bad // ends the enclosing block
+			}
+			depth--
+		case tokName:
+			if !keywords[t.text] {
+				bad.names = append(bad.names, t.text)
+			}
+		}
+		p.pos++
+		if depth == 0 && (t.kind == tokNewline || t.kind == tokDedent) && p.toks[p.pos].kind != tokIndent {
+			p.indent = indent
+			return bad
+		}
+	}
+}
+
+func (p *parser) funcDef() *funcDef {
+	t := p.next()
+	fn := &funcDef{pos: pos{t.line}, name: p.ident()}
+	fn.params = p.params()
+	if p.acceptOp("->") {
+		p.expr() // return annotation
+	}
+	fn.body = p.block()
+	return fn
+}
+
+// params parses a parenthesized parameter list, skipping annotations
+func (p *parser) params() []param {
+	p.expectOp("(")
+	var params []param
+	for !p.isOp(")") {
+		p.acceptOp("*")
+		prm := param{name: p.ident()}
+		if p.acceptOp(":") {
+			p.expr()
+		}
+		if p.acceptOp("=") {
+			prm.def = p.expr()
+		}
+		params = append(params, prm)
+		if !p.acceptOp(",") {
+			break
+		}
+	}
+	p.expectOp(")")
+	return params
+}
+
+// classDef reads fields and methods. A field is declared bare ("value,
+// left, right"), annotated ("count: int") or with a starting value.
+func (p *parser) classDef() *classDef {
+	t := p.next()
+	class := &classDef{pos: pos{t.line}, name: p.ident()}
+	if p.acceptOp("(") {
+		for !p.isOp(")") && p.peek().kind != tokNewline {
+			p.next()
+		}
+		p.expectOp(")")
+	}
+	p.expectOp(":")
+	if p.peek().kind != tokNewline {
+		p.simpleStatements()
+		p.endLine()
+		return class
+	}
+	p.next()
+	if p.peek().kind != tokIndent {
+		p.fail(p.peek(), "expected an indented block")
+	}
+	p.next()
+	for p.peek().kind != tokDedent && p.peek().kind != tokEOF {
+		p.guard(func() {
+			switch t := p.peek(); {
+			case t.kind == tokName && (t.text == "function" || t.text == "def"):
+				class.methods = append(class.methods, p.funcDef())
+			case t.kind == tokName && t.text == "pass", t.kind == tokOp && t.text == "...", t.kind == tokString:
+				p.simpleStatements()
+				p.endLine()
+			default:
+				class.fields = append(class.fields, p.fieldLine()...)
+			}
+		})
+	}
+	if p.peek().kind == tokDedent {
+		p.next()
+	}
+	return class
+}
+
+func (p *parser) fieldLine() []param {
+	var fields []param
+	for {
+		f := param{name: p.ident()}
+		if p.acceptOp(":") {
+			p.expr()
+		}
+		if p.acceptOp("=") {
+			f.def = p.expr()
+		}
+		fields = append(fields, f)
+		if !p.acceptOp(",") {
+			break
+		}
+	}
+	p.endLine()
+	return fields
+}
+
+func (p *parser) ifStmt() Stmt {
+	t := p.next() // "if" or "elif"
+	s := &ifStmt{pos: pos{t.line}, cond: p.expr()}
+	s.body = p.block()
+	switch {
+	case p.isName("elif"):
+		s.els = []Stmt{p.ifStmt()}
+	case p.isName("else") && p.peekAt(1).kind == tokName && p.peekAt(1).text == "if":
+		p.next() // "else if"
+		s.els = []Stmt{p.ifStmt()}
+	case p.acceptName("else"):
+		s.els = p.block()
+	}
+	return s
+}
+
+func (p *parser) whileStmt() Stmt {
+	t := p.next()
+	s := &whileStmt{pos: pos{t.line}, cond: p.expr()}
+	s.body = p.block()
+	if p.acceptName("else") {
+		s.els = p.block()
+	}
+	return s
+}
+
+// forStmt handles "for x in xs", "for each x in xs", "for each x of node"
+// (over the node's neighbors) and "for i from a to b"
+func (p *parser) forStmt() Stmt {
+	t := p.next()
+	line := pos{t.line}
+	p.acceptName("each")
+
+	if next := p.peekAt(1); p.peek().kind == tokName &&
+		(next.kind == tokName && next.text == "from" || next.kind == tokOp && next.text == "=") {
+		name := p.ident()
+		p.next()
+		s := &forRangeStmt{pos: line, name: name, from: p.expr()}
+		p.expectName("to")
+		s.to = p.expr()
+		s.body = p.block()
+		return s
+	}
+
+	target := p.targetList()
+	p.checkTarget(target)
+	var iter Expr
+	switch {
+	case p.acceptName("in"):
+		iter = p.exprList()
+	case p.acceptName("of"):
+		of := p.expr()
+		iter = &callExpr{pos: line, fn: &nameExpr{pos: line, name: "neighbors"}, args: []Expr{of}}
+	default:
+		p.fail(p.peek(), "expected \"in\", found %s", p.peek())
+	}
+	s := &forStmt{pos: line, target: target, iter: iter}
+	s.body = p.block()
+	if p.acceptName("else") {
+		s.els = p.block()
+	}
+	return s
+}
+
+// targetList parses loop targets, which stop before "in"
+func (p *parser) targetList() Expr {
+	t := p.peek()
+	first := p.bitOr()
+	if !p.isOp(",") {
+		return first
+	}
+	elems := []Expr{first}
+	for p.acceptOp(",") {
+		if p.isName("in") || p.isName("of") {
+			break
+		}
+		elems = append(elems, p.bitOr())
+	}
+	return &tupleExpr{pos: pos{t.line}, elems: elems}
+}
+
+// Expressions
+
+// exprList parses "a, b, c" as a tuple, or a single expression
+func (p *parser) exprList() Expr {
+	t := p.peek()
+	first := p.expr()
+	if !p.isOp(",") {
+		return first
+	}
+	elems := []Expr{first}
+	for p.acceptOp(",") {
+		if p.endOfStatement() || p.isOp("=") || p.isOp(")") || p.isOp(":") {
+			break
+		}
+		elems = append(elems, p.expr())
+	}
+	return &tupleExpr{pos: pos{t.line}, elems: elems}
+}
+
+func (p *parser) expr() Expr {
+	p.nest()
+	defer p.unnest()
+	if p.isName("lambda") {
+		return p.lambda()
+	}
+	t := p.peek()
+	x := p.or()
+	if p.isName("if") {
+		// Only a conditional expression when an "else" follows on this line
+		for i := p.pos; i < len(p.toks) && p.toks[i].kind != tokNewline && p.toks[i].kind != tokEOF; i++ {
+			if p.toks[i].kind == tokName && p.toks[i].text == "else" {
+				p.next()
+				cond := p.or()
+				p.expectName("else")
+				return &condExpr{pos: pos{t.line}, cond: cond, then: x, els: p.expr()}
+			}
+		}
+	}
+	return x
+}
+
+func (p *parser) lambda() Expr {
+	t := p.next()
+	var params []param
+	for !p.isOp(":") {
+		prm := param{name: p.ident()}
+		if p.acceptOp("=") {
+			prm.def = p.expr()
+		}
+		params = append(params, prm)
+		if !p.acceptOp(",") {
+			break
+		}
+	}
+	p.expectOp(":")
+	return &lambdaExpr{pos: pos{t.line}, params: params, body: p.expr()}
+}
+
+func (p *parser) or() Expr {
+	x := p.and()
+	for p.isName("or") {
+		t := p.next()
+		x = &boolExpr{pos: pos{t.line}, op: "or", l: x, r: p.and()}
+	}
+	return x
+}
+
+func (p *parser) and() Expr {
+	x := p.not()
+	for p.isName("and") {
+		t := p.next()
+		x = &boolExpr{pos: pos{t.line}, op: "and", l: x, r: p.not()}
+	}
+	return x
+}
+
+func (p *parser) not() Expr {
+	if p.isName("not") {
+		t := p.next()
+		p.nest()
+		defer p.unnest()
+		return &unaryExpr{pos: pos{t.line}, op: "not", x: p.not()}
+	}
+	return p.comparison()
+}
+
+// comparison also reads the emptiness checks "x is empty", "x is not
+// empty" and "x not empty"
+func (p *parser) comparison() Expr {
+	t := p.peek()
+	first := p.bitOr()
+	cmp := &compareExpr{pos: pos{t.line}, operands: []Expr{first}}
+	for {
+		op := ""
+		switch next := p.peek(); {
+		case next.kind == tokOp && (isComparison(next.text) || next.text == "<" || next.text == ">"):
+			p.next()
+			op = next.text
+		case p.isName("in"):
+			p.next()
+			op = "in"
+		case p.isName("not") && p.peekAt(1).kind == tokName && p.peekAt(1).text == "empty":
+			p.next()
+			p.next()
+			return &unaryExpr{pos: pos{t.line}, op: "not empty", x: cmp.collapse()}
+		case p.isName("not") && p.peekAt(1).kind == tokName && p.peekAt(1).text == "in":
+			p.next()
+			p.next()
+			op = "not in"
+		case p.isName("is"):
+			p.next()
+			op = "is"
+			if p.acceptName("not") {
+				op = "is not"
+			}
+			if p.acceptName("empty") {
+				u := "empty"
+				if op == "is not" {
+					u = "not empty"
+				}
+				return &unaryExpr{pos: pos{t.line}, op: u, x: cmp.collapse()}
+			}
+		}
+		if op == "" {
+			return cmp.collapse()
+		}
+		cmp.ops = append(cmp.ops, op)
+		cmp.operands = append(cmp.operands, p.bitOr())
+	}
+}
+
+// collapse drops the chain wrapper when there was no comparison
+func (c *compareExpr) collapse() Expr {
+	if len(c.ops) == 0 {
+		return c.operands[0]
+	}
+	return c
+}
+
+// binaryLevel parses a left-associative level of binary operators
+func (p *parser) binaryLevel(ops []string, operand func() Expr) Expr {
+	x := operand()
+	for {
+		t := p.peek()
+		matched := false
+		for _, op := range ops {
+			if t.kind == tokOp && t.text == op {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return x
+		}
+		p.next()
+		x = &binaryExpr{pos: pos{t.line}, op: t.text, l: x, r: operand()}
+	}
+}
+
+func (p *parser) bitOr() Expr  { return p.binaryLevel([]string{"|"}, p.bitXor) }
+func (p *parser) bitXor() Expr { return p.binaryLevel([]string{"^"}, p.bitAnd) }
+func (p *parser) bitAnd() Expr { return p.binaryLevel([]string{"&"}, p.shift) }
+func (p *parser) shift() Expr  { return p.binaryLevel([]string{"<<", ">>"}, p.arith) }
+func (p *parser) arith() Expr  { return p.binaryLevel([]string{"+", "-"}, p.term) }
+func (p *parser) term() Expr   { return p.binaryLevel([]string{"*", "/", "//", "%"}, p.factor) }
+
+func (p *parser) factor() Expr {
+	if t := p.peek(); t.kind == tokOp && (t.text == "-" || t.text == "+" || t.text == "~") {
+		p.next()
+		p.nest()
+		defer p.unnest()
+		return &unaryExpr{pos: pos{t.line}, op: t.text, x: p.factor()}
+	}
+	return p.power()
+}
+
+func (p *parser) power() Expr {
+	x := p.postfix()
+	if t := p.peek(); t.kind == tokOp && t.text == "**" {
+		p.next()
+		p.nest()
+		defer p.unnest()
+		return &binaryExpr{pos: pos{t.line}, op: "**", l: x, r: p.factor()}
+	}
+	return x
+}
+
+func (p *parser) postfix() Expr {
+	x := p.atom()
+	for {
+		t := p.peek()
+		switch {
+		case p.isOp("("):
+			p.next()
+			x = p.call(x, t.line)
+		case p.isOp("["):
+			p.next()
+			x = p.subscript(x, t.line)
+		case p.isOp("."):
+			p.next()
+			name := p.peek()
+			if name.kind != tokName {
+				p.fail(name, "expected an attribute name, found %s", name)
+			}
+			p.next()
+			x = &attrExpr{pos: pos{t.line}, x: x, name: name.text}
+		default:
+			return x
+		}
+	}
+}
+
+func (p *parser) call(fn Expr, line int) Expr {
+	p.nest()
+	defer p.unnest()
+	c := &callExpr{pos: pos{line}, fn: fn}
+	for !p.isOp(")") {
+		if p.peek().kind == tokName && p.peekAt(1).kind == tokOp && p.peekAt(1).text == "=" {
+			name := p.ident()
+			p.next()
+			c.kwargs = append(c.kwargs, keywordArg{name, p.expr()})
+		} else {
+			p.acceptOp("*")
+			arg := p.expr()
+			if p.isName("for") && len(c.args) == 0 && len(c.kwargs) == 0 {
+				arg = p.comprehension('[', arg, nil, line)
+			}
+			c.args = append(c.args, arg)
+		}
+		if !p.acceptOp(",") {
+			break
+		}
+	}
+	p.expectOp(")")
+	return c
+}
+
+func (p *parser) subscript(x Expr, line int) Expr {
+	p.nest()
+	defer p.unnest()
+	var lo Expr
+	if !p.isOp(":") {
+		lo = p.exprList()
+	}
+	if !p.acceptOp(":") {
+		p.expectOp("]")
+		return &indexExpr{pos: pos{line}, x: x, index: lo}
+	}
+	s := &sliceExpr{pos: pos{line}, x: x, lo: lo}
+	if !p.isOp("]") && !p.isOp(":") {
+		s.hi = p.expr()
+	}
+	if p.acceptOp(":") && !p.isOp("]") {
+		s.step = p.expr()
+	}
+	p.expectOp("]")
+	return s
+}
+
+func (p *parser) atom() Expr {
+	t := p.peek()
+	line := pos{t.line}
+	switch t.kind {
+	case tokNumber:
+		p.next()
+		return &literalExpr{pos: line, value: parseNumber(p, t)}
+	case tokString:
+		p.next()
+		s := t.text
+		for p.peek().kind == tokString {
+			s += p.next().text
+		}
+		return &literalExpr{pos: line, value: s}
+	case tokName:
+		switch t.text {
+		case "True", "true":
+			p.next()
+			return &literalExpr{pos: line, value: true}
+		case "False", "false":
+			p.next()
+			return &literalExpr{pos: line, value: false}
+		case "None", "null":
+			p.next()
+			return &literalExpr{pos: line, value: nil}
+		case "new":
+			p.next()
+			x := p.postfix()
+			if _, ok := x.(*callExpr); !ok {
+				x = &callExpr{pos: line, fn: x}
+			}
+			return x
+		case "lambda":
+			return p.lambda()
+		}
+		return &nameExpr{pos: line, name: p.ident()}
+	case tokOp:
+		switch t.text {
+		case "(":
+			return p.paren()
+		case "[":
+			return p.list()
+		case "{":
+			return p.brace()
+		}
+	}
+	p.fail(t, "unexpected %s", t)
+	return nil
+}
+
+func parseNumber(p *parser, t token) Value {
+	if !strings.ContainsAny(t.text, ".eE") {
+		if n, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return n
+		}
+	}
+	f, err := strconv.ParseFloat(t.text, 64)
+	if err != nil {
+		p.fail(t, "invalid number %s", t.text)
+	}
+	return f
+}
+
+func (p *parser) paren() Expr {
+	t := p.next()
+	p.nest()
+	defer p.unnest()
+	line := pos{t.line}
+	if p.acceptOp(")") {
+		return &tupleExpr{pos: line}
+	}
+	first := p.expr()
+	if p.isName("for") {
+		x := p.comprehension('[', first, nil, t.line)
+		p.expectOp(")")
+		return x
+	}
+	if !p.isOp(",") {
+		p.expectOp(")")
+		return first
+	}
+	elems := []Expr{first}
+	for p.acceptOp(",") && !p.isOp(")") {
+		elems = append(elems, p.expr())
+	}
+	p.expectOp(")")
+	return &tupleExpr{pos: line, elems: elems}
+}
+
+func (p *parser) list() Expr {
+	t := p.next()
+	p.nest()
+	defer p.unnest()
+	l := &listExpr{pos: pos{t.line}}
+	if p.acceptOp("]") {
+		return l
+	}
+	first := p.expr()
+	if p.isName("for") {
+		x := p.comprehension('[', first, nil, t.line)
+		p.expectOp("]")
+		return x
+	}
+	l.elems = append(l.elems, first)
+	for p.acceptOp(",") && !p.isOp("]") {
+		l.elems = append(l.elems, p.expr())
+	}
+	p.expectOp("]")
+	return l
+}
+
+// brace parses a dict or set, or a comprehension of either
+func (p *parser) brace() Expr {
+	t := p.next()
+	p.nest()
+	defer p.unnest()
+	line := pos{t.line}
+	if p.acceptOp("}") {
+		return &dictExpr{pos: line}
+	}
+	first := p.expr()
+	if p.acceptOp(":") {
+		value := p.expr()
+		if p.isName("for") {
+			x := p.comprehension(':', value, first, t.line)
+			p.expectOp("}")
+			return x
+		}
+		d := &dictExpr{pos: line, keys: []Expr{first}, values: []Expr{value}}
+		for p.acceptOp(",") && !p.isOp("}") {
+			d.keys = append(d.keys, p.expr())
+			p.expectOp(":")
+			d.values = append(d.values, p.expr())
+		}
+		p.expectOp("}")
+		return d
+	}
+	if p.isName("for") {
+		x := p.comprehension('{', first, nil, t.line)
+		p.expectOp("}")
+		return x
+	}
+	s := &setExpr{pos: line, elems: []Expr{first}}
+	for p.acceptOp(",") && !p.isOp("}") {
+		s.elems = append(s.elems, p.expr())
+	}
+	p.expectOp("}")
+	return s
+}
+
+func (p *parser) comprehension(kind byte, elem, key Expr, line int) Expr {
+	c := &compExpr{pos: pos{line}, kind: kind, elem: elem, key: key}
+	for p.acceptName("for") {
+		var clause compClause
+		clause.target = p.targetList()
+		p.checkTarget(clause.target)
+		p.expectName("in")
+		clause.iter = p.or()
+		for p.acceptName("if") {
+			clause.conds = append(clause.conds, p.or())
+		}
+		c.clauses = append(c.clauses, clause)
+	}
+	return c
+}
+
+// Programs
+
+// Program is parsed pseudo code. Parsing is lenient: a top-level statement
+// that doesn't parse is skipped and reported in Warnings, so the rest of
+// the code still runs.
+type Program struct {
+	stmts    []Stmt
+	Warnings []*SyntaxError
+	broken   map[string]*SyntaxError // functions and classes that failed to parse
+}
+
+// Parse parses pseudo code
+func Parse(src string) *Program {
+	return parse(lex(src), false)
+}
+
+func parse(toks []token, linting bool) *Program {
+	p := &parser{toks: toks, linting: linting}
+	prog := &Program{broken: make(map[string]*SyntaxError)}
+	for p.toks[p.pos].kind != tokEOF {
+		start := p.pos
+		stmts, err := p.topLevel()
+		if err == nil {
+			prog.stmts = append(prog.stmts, stmts...)
+			continue
+		}
+		p.warnings = append(p.warnings, err)
+		if head := p.toks[start]; head.kind == tokName && (head.text == "function" || head.text == "def" || head.text == "class") {
+			if name := p.toks[start+1]; name.kind == tokName {
+				prog.broken[name.text] = err
+			}
+		}
+		p.recover()
+	}
+	prog.Warnings = p.warnings
+	return prog
+}
+
+func (p *parser) topLevel() (stmts []Stmt, err *SyntaxError) {
+	defer func() {
+		if r := recover(); r != nil {
+			pe, ok := r.(parseError)
+			if !ok {
+				panic(r)
+			}
+			err = pe.err
+		}
+	}()
+	p.nesting = 0
+	if p.peek().kind == tokIndent {
+		p.fail(p.peek(), "unexpected indent")
+	}
+	if p.peek().kind == tokDedent {
+		p.next()
+		return nil, nil
+	}
+	return p.statement(), nil
+}
+
+// recover skips to the start of the next top-level statement
+func (p *parser) recover() {
+	for {
+		t := p.toks[p.pos]
+		switch t.kind {
+		case tokEOF:
+			return
+		case tokIndent:
+			p.indent++
+		case tokDedent:
+			p.indent--
+		}
+		p.pos++
+		if p.indent <= 0 && (t.kind == tokNewline || t.kind == tokDedent) && p.toks[p.pos].kind != tokIndent && p.toks[p.pos].kind != tokDedent {
+			p.indent = 0
+			return
+		}
+	}
+}
@@ -0,0 +1,676 @@
+package pseudo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Value is a runtime value: nil, bool, int64, float64, string, Tuple,
+// *List, *Dict, *Set, *Queue, *Stack, *PriorityQueue, *Range, a callable,
+// *Class, *Instance or *Module
+type Value interface{}
+
+// Tuple is an immutable sequence
+type Tuple []Value
+
+// List is a mutable sequence
+type List struct{ items []Value }
+
+// Dict is a map that keeps insertion order. Keys are compared by value,
+// so tuples and lists work as keys.
+type Dict struct {
+	keys   []Value
+	values []Value
+	index  map[string]int
+}
+
+func newDict() *Dict { return &Dict{index: make(map[string]int)} }
+
+func (d *Dict) get(k Value) (Value, bool) {
+	if i, ok := d.index[hashKey(k)]; ok {
+		return d.values[i], true
+	}
+	return nil, false
+}
+
+// set reports whether the key is new
+func (d *Dict) set(k, v Value) bool {
+	h := hashKey(k)
+	if i, ok := d.index[h]; ok {
+		d.values[i] = v
+		return false
+	}
+	d.index[h] = len(d.keys)
+	d.keys = append(d.keys, k)
+	d.values = append(d.values, v)
+	return true
+}
+
+func (d *Dict) remove(k Value) (Value, bool) {
+	h := hashKey(k)
+	i, ok := d.index[h]
+	if !ok {
+		return nil, false
+	}
+	v := d.values[i]
+	delete(d.index, h)
+	d.keys = append(d.keys[:i], d.keys[i+1:]...)
+	d.values = append(d.values[:i], d.values[i+1:]...)
+	for j := i; j < len(d.keys); j++ {
+		d.index[hashKey(d.keys[j])] = j
+	}
+	return v, true
+}
+
+func (d *Dict) len() int { return len(d.keys) }
+
+// Set is an insertion-ordered set
+type Set struct{ d *Dict }
+
+func newSet() *Set { return &Set{newDict()} }
+
+func (s *Set) has(v Value) bool { _, ok := s.d.get(v); return ok }
+func (s *Set) add(v Value) bool { return s.d.set(v, nil) }
+func (s *Set) items() []Value   { return s.d.keys }
+
+// Queue is first in, first out
+type Queue struct{ items []Value }
+
+// Stack is last in, first out; the top is the end of items
+type Stack struct{ items []Value }
+
+// PriorityQueue pops its smallest priority first, and among equal
+// priorities the earliest pushed
+type PriorityQueue struct {
+	entries []pqEntry
+	seq     int
+}
+
+type pqEntry struct {
+	priority, value Value
+	seq             int
+}
+
+// Range is a lazy range of integers
+type Range struct{ start, stop, step int64 }
+
+func (r *Range) len() int64 {
+	switch {
+	case r.step > 0 && r.start < r.stop:
+		return (r.stop - r.start + r.step - 1) / r.step
+	case r.step < 0 && r.start > r.stop:
+		return (r.start - r.stop - r.step - 1) / -r.step
+	}
+	return 0
+}
+
+func (r *Range) at(i int64) int64 { return r.start + i*r.step }
+
+// Function is a user-defined function, method or lambda
+type Function struct {
+	name   string
+	params []param
+	body   []Stmt
+	expr   Expr // a lambda's body
+	env    *env
+	method bool // called with an implicit self
+}
+
+// Builtin is a function implemented in Go
+type Builtin struct {
+	name string
+	fn   func(in *Interp, args []Value, kwargs map[string]Value) (Value, error)
+}
+
+// boundMethod is a user method with its receiver
+type boundMethod struct {
+	fn   *Function
+	self *Instance
+}
+
+// Class is a user-defined class
+type Class struct {
+	name    string
+	fields  []param
+	methods map[string]*Function
+	env     *env
+}
+
+// Instance is an object of a user-defined class
+type Instance struct {
+	class  *Class
+	names  []string // fields in the order they were set
+	fields map[string]Value
+}
+
+func (o *Instance) get(name string) (Value, bool) {
+	v, ok := o.fields[name]
+	return v, ok
+}
+
+func (o *Instance) set(name string, v Value) {
+	if _, ok := o.fields[name]; !ok {
+		o.names = append(o.names, name)
+	}
+	o.fields[name] = v
+}
+
+// Module is a namespace of builtins, such as heapq
+type Module struct {
+	name  string
+	attrs map[string]Value
+}
+
+// typeName names a value's type for error messages
+func typeName(v Value) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case int64:
+		return "int"
+	case float64:
+		return "float"
+	case string:
+		return "string"
+	case Tuple:
+		return "tuple"
+	case *List:
+		return "list"
+	case *Dict:
+		return "map"
+	case *Set:
+		return "set"
+	case *Queue:
+		return "Queue"
+	case *Stack:
+		return "Stack"
+	case *PriorityQueue:
+		return "PriorityQueue"
+	case *Range:
+		return "range"
+	case *Function, *Builtin, *boundMethod:
+		return "function"
+	case *Class:
+		return "class"
+	case *Instance:
+		return v.class.name
+	case *Module:
+		return "module"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// maxVisit bounds the values that one comparison, hash or repr looks at,
+// so that structures containing themselves can't hang or overflow
+const maxVisit = 100_000
+
+// hashKey is a canonical string for a value used as a key. Equal values,
+// including 1 and 1.0, share a key.
+func hashKey(v Value) string {
+	var sb strings.Builder
+	budget := maxVisit
+	writeKey(&sb, v, &budget)
+	return sb.String()
+}
+
+func writeKey(sb *strings.Builder, v Value, budget *int) {
+	if *budget--; *budget < 0 {
+		fmt.Fprintf(sb, "@%p", v)
+		return
+	}
+	switch v := v.(type) {
+	case nil:
+		sb.WriteString("n")
+	case bool:
+		if v {
+			sb.WriteString("i1")
+		} else {
+			sb.WriteString("i0")
+		}
+	case int64:
+		sb.WriteString("i")
+		sb.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<62 {
+			sb.WriteString("i")
+			sb.WriteString(strconv.FormatInt(int64(v), 10))
+		} else {
+			sb.WriteString("f")
+			sb.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+		}
+	case string:
+		sb.WriteString(strconv.Quote(v))
+	case Tuple:
+		writeSeqKey(sb, '(', v, budget)
+	case *List:
+		writeSeqKey(sb, '[', v.items, budget)
+	case *Set:
+		keys := make([]string, len(v.items()))
+		for i, item := range v.items() {
+			var key strings.Builder
+			writeKey(&key, item, budget)
+			keys[i] = key.String()
+		}
+		sort.Strings(keys)
+		sb.WriteString("{" + strings.Join(keys, ",") + "}")
+	default:
+		fmt.Fprintf(sb, "@%p", v)
+	}
+}
+
+func writeSeqKey(sb *strings.Builder, open byte, items []Value, budget *int) {
+	sb.WriteByte(open)
+	for i, item := range items {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		writeKey(sb, item, budget)
+	}
+	sb.WriteByte(')')
+}
+
+// toFloat converts numbers and bools
+func toFloat(v Value) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// toInt converts ints and bools
+func toInt(v Value) (int64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func isNumber(v Value) bool {
+	_, ok := toFloat(v)
+	return ok
+}
+
+// equal compares by value, deeply for containers
+func equal(a, b Value) bool {
+	budget := maxVisit
+	return equalWithin(a, b, &budget)
+}
+
+func equalWithin(a, b Value, budget *int) bool {
+	if *budget--; *budget < 0 {
+		return false
+	}
+	if isNumber(a) && isNumber(b) {
+		x, _ := toFloat(a)
+		y, _ := toFloat(b)
+		if ai, ok := a.(int64); ok {
+			if bi, ok := b.(int64); ok {
+				return ai == bi
+			}
+		}
+		return x == y
+	}
+	switch a := a.(type) {
+	case nil:
+		return b == nil
+	case string:
+		s, ok := b.(string)
+		return ok && a == s
+	case Tuple:
+		t, ok := b.(Tuple)
+		return ok && equalSeq(a, t, budget)
+	case *List:
+		l, ok := b.(*List)
+		return ok && (a == l || equalSeq(a.items, l.items, budget))
+	case *Dict:
+		d, ok := b.(*Dict)
+		if !ok || d.len() != a.len() {
+			return false
+		}
+		if a == d {
+			return true
+		}
+		for i, k := range a.keys {
+			if v, ok := d.get(k); !ok || !equalWithin(a.values[i], v, budget) {
+				return false
+			}
+		}
+		return true
+	case *Set:
+		s, ok := b.(*Set)
+		if !ok || s.d.len() != a.d.len() {
+			return false
+		}
+		for _, k := range a.items() {
+			if !s.has(k) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+func equalSeq(a, b []Value, budget *int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !equalWithin(a[i], b[i], budget) {
+			return false
+		}
+	}
+	return true
+}
+
+// compare orders numbers, strings, and tuples and lists lexicographically
+func compare(a, b Value) (int, error) {
+	budget := maxVisit
+	return compareWithin(a, b, &budget)
+}
+
+func compareWithin(a, b Value, budget *int) (int, error) {
+	if *budget--; *budget < 0 {
+		return 0, fmt.Errorf("values nested too deeply to compare")
+	}
+	if isNumber(a) && isNumber(b) {
+		if ai, ok := toInt(a); ok {
+			if bi, ok := toInt(b); ok {
+				return cmpOrdered(ai, bi), nil
+			}
+		}
+		x, _ := toFloat(a)
+		y, _ := toFloat(b)
+		return cmpOrdered(x, y), nil
+	}
+	switch a := a.(type) {
+	case string:
+		if s, ok := b.(string); ok {
+			return strings.Compare(a, s), nil
+		}
+	case Tuple:
+		if t, ok := b.(Tuple); ok {
+			return compareSeq(a, t, budget)
+		}
+	case *List:
+		if l, ok := b.(*List); ok {
+			return compareSeq(a.items, l.items, budget)
+		}
+	}
+	return 0, fmt.Errorf("can't compare %s with %s", typeName(a), typeName(b))
+}
+
+func cmpOrdered[T int64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareSeq(a, b []Value, budget *int) (int, error) {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		c, err := compareWithin(a[i], b[i], budget)
+		if err != nil || c != 0 {
+			return c, err
+		}
+	}
+	return cmpOrdered(int64(len(a)), int64(len(b))), nil
+}
+
+// truthy follows Python: zero, empty and null are false
+func truthy(v Value) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case int64:
+		return v != 0
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	}
+	if n, ok := length(v); ok {
+		return n > 0
+	}
+	return true
+}
+
+// length is the number of elements in a container
+func length(v Value) (int, bool) {
+	switch v := v.(type) {
+	case string:
+		return len([]rune(v)), true
+	case Tuple:
+		return len(v), true
+	case *List:
+		return len(v.items), true
+	case *Dict:
+		return v.len(), true
+	case *Set:
+		return v.d.len(), true
+	case *Queue:
+		return len(v.items), true
+	case *Stack:
+		return len(v.items), true
+	case *PriorityQueue:
+		return len(v.entries), true
+	case *Range:
+		return int(v.len()), true
+	}
+	return 0, false
+}
+
+// str formats a value for print and str()
+func str(v Value) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return repr(v)
+}
+
+// repr formats a value as it would be written in code
+func repr(v Value) string {
+	var sb strings.Builder
+	writeRepr(&sb, v, 0)
+	return sb.String()
+}
+
+// Caps on repr, against runaway output from structures that contain
+// themselves
+const (
+	maxReprDepth = 8
+	maxReprLen   = 10_000
+)
+
+func writeRepr(sb *strings.Builder, v Value, depth int) {
+	if depth > maxReprDepth || sb.Len() > maxReprLen {
+		sb.WriteString("...")
+		return
+	}
+	seq := func(open, close string, items []Value) {
+		sb.WriteString(open)
+		for i, item := range items {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			writeRepr(sb, item, depth+1)
+		}
+		sb.WriteString(close)
+	}
+	switch v := v.(type) {
+	case nil:
+		sb.WriteString("null")
+	case bool:
+		if v {
+			sb.WriteString("True")
+		} else {
+			sb.WriteString("False")
+		}
+	case int64:
+		sb.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		sb.WriteString(formatFloat(v))
+	case string:
+		sb.WriteString(strconv.Quote(v))
+	case Tuple:
+		if len(v) == 1 {
+			seq("(", ",)", v)
+		} else {
+			seq("(", ")", v)
+		}
+	case *List:
+		seq("[", "]", v.items)
+	case *Dict:
+		sb.WriteString("{")
+		for i, k := range v.keys {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			writeRepr(sb, k, depth+1)
+			sb.WriteString(": ")
+			writeRepr(sb, v.values[i], depth+1)
+		}
+		sb.WriteString("}")
+	case *Set:
+		if v.d.len() == 0 {
+			sb.WriteString("set()")
+		} else {
+			seq("{", "}", v.items())
+		}
+	case *Queue:
+		seq("Queue([", "])", v.items)
+	case *Stack:
+		seq("Stack([", "])", v.items)
+	case *PriorityQueue:
+		seq("PriorityQueue([", "])", v.sorted())
+	case *Range:
+		if v.step == 1 {
+			fmt.Fprintf(sb, "range(%d, %d)", v.start, v.stop)
+		} else {
+			fmt.Fprintf(sb, "range(%d, %d, %d)", v.start, v.stop, v.step)
+		}
+	case *Function:
+		fmt.Fprintf(sb, "<function %s>", v.name)
+	case *Builtin:
+		fmt.Fprintf(sb, "<builtin %s>", v.name)
+	case *boundMethod:
+		fmt.Fprintf(sb, "<method %s.%s>", v.self.class.name, v.fn.name)
+	case *Class:
+		fmt.Fprintf(sb, "<class %s>", v.name)
+	case *Instance:
+		sb.WriteString(v.class.name + "(")
+		for i, name := range v.names {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(name + "=")
+			writeRepr(sb, v.fields[name], depth+1)
+		}
+		sb.WriteString(")")
+	case *Module:
+		fmt.Fprintf(sb, "<module %s>", v.name)
+	default:
+		fmt.Fprintf(sb, "%v", v)
+	}
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "infinity"
+	case math.IsInf(f, -1):
+		return "-infinity"
+	case math.IsNaN(f):
+		return "NaN"
+	case f == math.Trunc(f) && math.Abs(f) < 1e16:
+		return strconv.FormatFloat(f, 'f', 1, 64)
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// sorted returns the queue's values, smallest priority first
+func (pq *PriorityQueue) sorted() []Value {
+	entries := append([]pqEntry(nil), pq.entries...)
+	sort.SliceStable(entries, func(i, j int) bool { return pq.less(entries[i], entries[j]) })
+	values := make([]Value, len(entries))
+	for i, e := range entries {
+		values[i] = e.value
+	}
+	return values
+}
+
+// less orders by priority, then push order. Priorities that can't be
+// compared fall back to push order.
+func (pq *PriorityQueue) less(a, b pqEntry) bool {
+	if c, err := compare(a.priority, b.priority); err == nil && c != 0 {
+		return c < 0
+	}
+	return a.seq < b.seq
+}
+
+func (pq *PriorityQueue) push(priority, value Value) {
+	pq.entries = append(pq.entries, pqEntry{priority, value, pq.seq})
+	pq.seq++
+	// Sift up
+	i := len(pq.entries) - 1
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !pq.less(pq.entries[i], pq.entries[parent]) {
+			break
+		}
+		pq.entries[i], pq.entries[parent] = pq.entries[parent], pq.entries[i]
+		i = parent
+	}
+}
+
+func (pq *PriorityQueue) pop() Value {
+	top := pq.entries[0]
+	last := len(pq.entries) - 1
+	pq.entries[0] = pq.entries[last]
+	pq.entries = pq.entries[:last]
+	// Sift down
+	i := 0
+	for {
+		smallest := i
+		for _, c := range []int{2*i + 1, 2*i + 2} {
+			if c < len(pq.entries) && pq.less(pq.entries[c], pq.entries[smallest]) {
+				smallest = c
+			}
+		}
+		if smallest == i {
+			break
+		}
+		pq.entries[i], pq.entries[smallest] = pq.entries[smallest], pq.entries[i]
+		i = smallest
+	}
+	return top.value
+}
+
+func (pq *PriorityQueue) peek() Value {
+	best := pq.entries[0]
+	return best.value
+}
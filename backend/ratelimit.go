@@ -0,0 +1,279 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimitPolicy allows Limit requests per Window, refilled continuously
+type RateLimitPolicy struct {
+	Limit  int
+	Window time.Duration
+}
+
+func (p RateLimitPolicy) String() string {
+	return fmt.Sprintf("%d;w=%d", p.Limit, int(p.Window.Seconds()))
+}
+
+// spec formats the policy as "limit/window", the form used in configuration
+func (p RateLimitPolicy) spec() string {
+	return fmt.Sprintf("%d/%s", p.Limit, p.Window)
+}
+
+// defaultRateLimitPolicies are used for any limiter not configured
+var defaultRateLimitPolicies = map[string]RateLimitPolicy{
+	"api":    {Limit: 100, Window: time.Minute}, // 100 API requests per minute
+	"submit": {Limit: 5, Window: time.Minute},   // 5 submissions per minute
+	"admin":  {Limit: 10, Window: time.Minute},  // 10 admin attempts per minute
+}
+
+// defaultMaxRateLimitClients caps how many clients each limiter tracks
+const defaultMaxRateLimitClients = 10000
+
+// parseRateLimitPolicies parses "name=limit/window" pairs such as
+// "api=100/1m,submit=5/1m" on top of the defaults
+func parseRateLimitPolicies(value string) (map[string]RateLimitPolicy, error) {
+	policies := make(map[string]RateLimitPolicy, len(defaultRateLimitPolicies))
+	for name, policy := range defaultRateLimitPolicies {
+		policies[name] = policy
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q: expected name=limit/window", entry)
+		}
+		name = strings.TrimSpace(name)
+		if _, known := defaultRateLimitPolicies[name]; !known {
+			return nil, fmt.Errorf("%q: unknown limiter %q", entry, name)
+		}
+		limitStr, windowStr, ok := strings.Cut(spec, "/")
+		if !ok {
+			return nil, fmt.Errorf("%q: expected name=limit/window", entry)
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(limitStr))
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("%q: limit must be a positive integer", entry)
+		}
+		window, err := time.ParseDuration(strings.TrimSpace(windowStr))
+		if err != nil || window < time.Second {
+			return nil, fmt.Errorf("%q: window must be a duration of at least 1s", entry)
+		}
+		policies[name] = RateLimitPolicy{Limit: limit, Window: window}
+	}
+	return policies, nil
+}
+
+// bucket is a token bucket; tokens are refilled lazily on access
+type bucket struct {
+	key      string
+	tokens   float64
+	lastSeen time.Time
+}
+
+// evictionSample is how many of the least recently seen clients are
+// checked for a full bucket to evict, keeping Allow O(1)
+const evictionSample = 8
+
+// RateLimiter is a per-client token bucket limiter. Each client uses O(1)
+// memory and the number of tracked clients is capped. When every tracked
+// client still has a partly drained bucket, new clients share the overflow
+// bucket: evicting a drained client would hand it a fresh quota.
+type RateLimiter struct {
+	mu         sync.Mutex
+	name       string
+	policy     RateLimitPolicy
+	rate       float64                  // tokens per second
+	buckets    map[string]*list.Element // of *bucket, in lru
+	lru        *list.List               // most recently seen first
+	overflow   bucket
+	maxClients int
+}
+
+// RateLimitDecision describes the outcome of a single Allow call
+type RateLimitDecision struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next token, when not allowed
+}
+
+func NewRateLimiter(name string, policy RateLimitPolicy, maxClients int) *RateLimiter {
+	rl := &RateLimiter{
+		name:       name,
+		policy:     policy,
+		rate:       float64(policy.Limit) / policy.Window.Seconds(),
+		buckets:    make(map[string]*list.Element),
+		lru:        list.New(),
+		overflow:   bucket{tokens: float64(policy.Limit), lastSeen: time.Now()},
+		maxClients: maxClients,
+	}
+	return rl
+}
+
+// cleanup drops buckets that have refilled completely; they are
+// indistinguishable from a new client. main runs it periodically.
+func (rl *RateLimiter) cleanup() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.pruneFull(time.Now())
+}
+
+func (rl *RateLimiter) pruneFull(now time.Time) {
+	for el := rl.lru.Back(); el != nil; {
+		prev := el.Prev()
+		if b := el.Value.(*bucket); rl.refill(b, now) >= float64(rl.policy.Limit) {
+			rl.remove(el)
+		}
+		el = prev
+	}
+}
+
+func (rl *RateLimiter) remove(el *list.Element) {
+	rl.lru.Remove(el)
+	delete(rl.buckets, el.Value.(*bucket).key)
+}
+
+// refill returns the bucket's token count at now without mutating it
+func (rl *RateLimiter) refill(b *bucket, now time.Time) float64 {
+	elapsed := now.Sub(b.lastSeen).Seconds()
+	return math.Min(float64(rl.policy.Limit), b.tokens+elapsed*rl.rate)
+}
+
+// evictFull makes room by removing one of the least recently seen clients
+// whose bucket has refilled, reporting whether it found one
+func (rl *RateLimiter) evictFull(now time.Time) bool {
+	el := rl.lru.Back()
+	for i := 0; el != nil && i < evictionSample; i++ {
+		if rl.refill(el.Value.(*bucket), now) >= float64(rl.policy.Limit) {
+			rl.remove(el)
+			return true
+		}
+		el = el.Prev()
+	}
+	return false
+}
+
+// Allow takes a token for key if one is available
+func (rl *RateLimiter) Allow(key string) RateLimitDecision {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	limit := float64(rl.policy.Limit)
+
+	var b *bucket
+	if el, exists := rl.buckets[key]; exists {
+		b = el.Value.(*bucket)
+		rl.lru.MoveToFront(el)
+	} else if len(rl.buckets) < rl.maxClients || rl.evictFull(now) {
+		b = &bucket{key: key, tokens: limit, lastSeen: now}
+		rl.buckets[key] = rl.lru.PushFront(b)
+	} else {
+		b = &rl.overflow
+	}
+
+	b.tokens = rl.refill(b, now)
+	b.lastSeen = now
+
+	decision := RateLimitDecision{Limit: rl.policy.Limit}
+	if b.tokens >= 1 {
+		b.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = rl.secondsUntil(1 - b.tokens)
+	}
+	decision.Remaining = int(b.tokens)
+	decision.Reset = rl.secondsUntil(limit - b.tokens)
+	return decision
+}
+
+// secondsUntil returns how long it takes to refill the given number of tokens
+func (rl *RateLimiter) secondsUntil(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens/rl.rate)) * time.Second
+}
+
+// writeRateLimitHeaders sets the IETF RateLimit-* headers and, for rejected
+// requests, Retry-After
+func writeRateLimitHeaders(w http.ResponseWriter, rl *RateLimiter, d RateLimitDecision) {
+	h := w.Header()
+	h.Set("RateLimit-Limit", strconv.Itoa(d.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(int(d.Reset.Seconds())))
+	h.Set("RateLimit-Policy", rl.policy.String())
+	if !d.Allowed {
+		h.Set("Retry-After", strconv.Itoa(int(d.RetryAfter.Seconds())))
+	}
+}
+
+// hashAPIKeys hashes keys so lookups can compare fixed-length values in
+// constant time
+func hashAPIKeys(keys []string) [][sha256.Size]byte {
+	var hashes [][sha256.Size]byte
+	for _, key := range keys {
+		hashes = append(hashes, sha256.Sum256([]byte(key)))
+	}
+	return hashes
+}
+
+// hasExemptAPIKey reports whether the request carries a configured API key
+func hasExemptAPIKey(r *http.Request) bool {
+	key := r.Header.Get("X-API-Key")
+	exemptKeys := current().exemptKeys
+	if key == "" || len(exemptKeys) == 0 {
+		return false
+	}
+	hash := sha256.Sum256([]byte(key))
+	match := 0
+	for _, expected := range exemptKeys {
+		match |= subtle.ConstantTimeCompare(hash[:], expected[:])
+	}
+	return match == 1
+}
+
+// checkRateLimit applies limiter to the request's client IP, writing the
+// rate limit headers and a 429 with message when the limit is exceeded.
+// It returns false if the request should not proceed.
+func checkRateLimit(w http.ResponseWriter, r *http.Request, limiter *RateLimiter, message string) bool {
+	decision := limiter.Allow(getClientIP(r))
+	writeRateLimitHeaders(w, limiter, decision)
+	if !decision.Allowed {
+		rateLimitRejections.Inc(limiter.name)
+		http.Error(w, message, http.StatusTooManyRequests)
+		return false
+	}
+	return true
+}
+
+// rateLimitMiddleware applies the API limiter to every request without an
+// exempt API key
+func rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !hasExemptAPIKey(r) && !checkRateLimit(w, r, current().apiLimiter, "Rate limit exceeded. Please try again later.") {
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// cleanupLimiters prunes idle clients from the active limiters
+func cleanupLimiters() {
+	s := current()
+	for _, limiter := range []*RateLimiter{s.apiLimiter, s.submitLimiter, s.adminLimiter} {
+		limiter.cleanup()
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	rl := NewRateLimiter("test", RateLimitPolicy{Limit: 3, Window: time.Hour}, 10)
+	for i := 0; i < 3; i++ {
+		if d := rl.Allow("a"); !d.Allowed || d.Remaining != 2-i {
+			t.Fatalf("request %d: %+v", i, d)
+		}
+	}
+	if d := rl.Allow("a"); d.Allowed || d.RetryAfter <= 0 {
+		t.Errorf("request over the limit: %+v", d)
+	}
+	if d := rl.Allow("b"); !d.Allowed {
+		t.Errorf("other client limited: %+v", d)
+	}
+}
+
+// A client cycling through addresses must not push a drained client out
+// and so refill its own quota
+func TestRateLimiterKeepsDrainedClients(t *testing.T) {
+	rl := NewRateLimiter("test", RateLimitPolicy{Limit: 2, Window: time.Hour}, 4)
+	for i := 0; i < 4; i++ {
+		key := strconv.Itoa(i)
+		rl.Allow(key)
+		rl.Allow(key)
+	}
+	for i := 4; i < 1000; i++ {
+		rl.Allow(strconv.Itoa(i))
+	}
+	if len(rl.buckets) != 4 || rl.lru.Len() != 4 {
+		t.Fatalf("tracking %d clients (%d in lru), want 4", len(rl.buckets), rl.lru.Len())
+	}
+	for i := 0; i < 4; i++ {
+		if d := rl.Allow(strconv.Itoa(i)); d.Allowed {
+			t.Errorf("drained client %d got a fresh quota", i)
+		}
+	}
+	// New clients share the overflow bucket, which is long since drained
+	if d := rl.Allow("new"); d.Allowed {
+		t.Errorf("overflow allowed past its limit: %+v", d)
+	}
+}
+
+func TestRateLimiterEvictsRefilledClients(t *testing.T) {
+	rl := NewRateLimiter("test", RateLimitPolicy{Limit: 2, Window: time.Hour}, 2)
+	rl.Allow("a")
+	rl.Allow("b")
+	// Pretend "a" has been idle long enough to refill
+	rl.buckets["a"].Value.(*bucket).lastSeen = time.Now().Add(-2 * time.Hour)
+
+	if d := rl.Allow("c"); !d.Allowed {
+		t.Fatalf("new client rejected: %+v", d)
+	}
+	if _, ok := rl.buckets["a"]; ok {
+		t.Error("refilled client was not evicted")
+	}
+	if _, ok := rl.buckets["b"]; !ok {
+		t.Error("drained client was evicted")
+	}
+}
+
+func TestRateLimiterCleanup(t *testing.T) {
+	rl := NewRateLimiter("test", RateLimitPolicy{Limit: 2, Window: time.Hour}, 10)
+	rl.Allow("a")
+	rl.Allow("b")
+	rl.buckets["a"].Value.(*bucket).lastSeen = time.Now().Add(-2 * time.Hour)
+	rl.cleanup()
+	if _, ok := rl.buckets["a"]; ok || rl.lru.Len() != 1 {
+		t.Errorf("cleanup kept a refilled client; tracking %d", rl.lru.Len())
+	}
+}
+
+// Rotating addresses against a full limiter must stay cheap
+func TestRateLimiterFullIsConstantTime(t *testing.T) {
+	rl := NewRateLimiter("test", RateLimitPolicy{Limit: 1, Window: time.Hour}, 10000)
+	for i := 0; i < 10000; i++ {
+		rl.Allow(strconv.Itoa(i))
+	}
+	start := time.Now()
+	for i := 10000; i < 110000; i++ {
+		rl.Allow(strconv.Itoa(i))
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("100000 new clients against a full limiter took %v", elapsed)
+	}
+}
+
+// An exempt API key lifts the API limit, but not the limit on admin logins
+func TestAPIKeyExemption(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.APIKeys = []string{"exempt-key"}
+	cfg.RateLimit.Policies = map[string]string{"api": "2/1h", "admin": "2/1h"}
+	prevState, prevAudit := current(), auditLog
+	state.Store(newAppState(cfg, nil))
+	auditLog = NewAuditLog(filepath.Join(t.TempDir(), "audit.log"))
+	t.Cleanup(func() {
+		state.Store(prevState)
+		auditLog = prevAudit
+	})
+
+	request := func() *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/api/admin/submissions", nil)
+		r.Header.Set("X-API-Key", "exempt-key")
+		r.SetBasicAuth("admin", "wrong password")
+		return r
+	}
+
+	api := rateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for i := 0; i < 5; i++ {
+		rec := httptest.NewRecorder()
+		api.ServeHTTP(rec, request())
+		if rec.Code != http.StatusOK {
+			t.Fatalf("API request %d: status %d", i, rec.Code)
+		}
+	}
+
+	admin := adminAuth(func(w http.ResponseWriter, r *http.Request) {})
+	for i, want := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+		rec := httptest.NewRecorder()
+		admin(rec, request())
+		if rec.Code != want {
+			t.Errorf("login attempt %d: status %d, want %d", i, rec.Code, want)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Limits for /api/recommend. A full AoC puzzle description is a few KB.
+const (
+	maxRecommendBody       = 64 << 10
+	defaultRecommendations = 5
+	maxRecommendations     = 20
+)
+
+// How much each kind of match counts towards an algorithm's score. Phrase
+// matches are exact enough to count in full; hint and "when to use"
+// sentences count in proportion to how many of their words appear.
+const (
+	weightKeyword     = 3.0
+	weightHintPhrase  = 3.0
+	weightHint        = 2.0
+	weightWhenToUse   = 1.5
+	weightTag         = 1.0
+	minSentenceCover  = 0.5 // share of a sentence's words that must appear
+	minSentenceTokens = 2   // and at least this many of them
+	phraseSlack       = 2   // extra words allowed between a phrase's words
+)
+
+// Where a match came from
+const (
+	matchKeyword   = "keyword"
+	matchHint      = "recognitionHint"
+	matchWhenToUse = "whenToUse"
+	matchTag       = "tag"
+)
+
+// quotedPhrase finds 'quoted' phrases inside recognition hints, such as
+// "Problem asks for 'shortest path'", which are matched like keywords
+var quotedPhrase = regexp.MustCompile(`'([^']+)'`)
+
+// recommendStopwords are ignored when matching sentences; they would
+// otherwise match almost any puzzle
+var recommendStopwords = toSet(strings.Fields(`
+	a an and any are as at be but by can each for from has have how if in
+	into is it its need not of on or so than that the then there these this
+	to too use used using via when where which while with you your
+	all find finding problem problems solution solving get give want
+`))
+
+func toSet(words []string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// RecommendRequest is the body of POST /api/recommend
+type RecommendRequest struct {
+	Text  string `json:"text"`
+	Limit int    `json:"limit"`
+}
+
+// RecommendMatch explains one reason an algorithm was suggested
+type RecommendMatch struct {
+	Source string `json:"source"` // keyword, recognitionHint, whenToUse or tag
+	Text   string `json:"text"`
+}
+
+// Recommendation is a suggested algorithm with the reasons for it
+type Recommendation struct {
+	ID         string           `json:"id"`
+	Name       string           `json:"name"`
+	Category   string           `json:"category"`
+	Difficulty string           `json:"difficulty"`
+	Score      float64          `json:"score"`
+	Matches    []RecommendMatch `json:"matches"`
+}
+
+// tokenize lowercases text and splits it into words, folding simple plurals
+// so "steps" matches "step"
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = stem(w)
+	}
+	return words
+}
+
+func stem(word string) string {
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return word[:len(word)-3] + "y"
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// puzzleText is tokenized puzzle text with a position index for phrases
+type puzzleText struct {
+	words     []string
+	positions map[string][]int
+}
+
+func newPuzzleText(text string) *puzzleText {
+	p := &puzzleText{words: tokenize(text), positions: make(map[string][]int)}
+	for i, w := range p.words {
+		p.positions[w] = append(p.positions[w], i)
+	}
+	return p
+}
+
+func (p *puzzleText) has(word string) bool {
+	return len(p.positions[word]) > 0
+}
+
+// hasPhrase reports whether the phrase's words appear in order, allowing a
+// few words in between: "fewest steps" matches "fewest number of steps"
+func (p *puzzleText) hasPhrase(phrase string) bool {
+	words := tokenize(phrase)
+	if len(words) == 0 {
+		return false
+	}
+	window := len(words) + phraseSlack*(len(words)-1)
+	for _, start := range p.positions[words[0]] {
+		at, ok := start, true
+		for _, w := range words[1:] {
+			// Positions are ascending; find the first one after at
+			positions := p.positions[w]
+			i := sort.SearchInts(positions, at+1)
+			if i == len(positions) || positions[i]-start >= window {
+				ok = false
+				break
+			}
+			at = positions[i]
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sentenceCover returns the share of a sentence's significant words that
+// appear in the text, or 0 if too few do
+func (p *puzzleText) sentenceCover(sentence string) float64 {
+	significant := make(map[string]bool)
+	for _, w := range tokenize(sentence) {
+		if len(w) > 1 && !recommendStopwords[w] {
+			significant[w] = true
+		}
+	}
+	matched := 0
+	for w := range significant {
+		if p.has(w) {
+			matched++
+		}
+	}
+	if matched < minSentenceTokens {
+		return 0
+	}
+	cover := float64(matched) / float64(len(significant))
+	if cover < minSentenceCover {
+		return 0
+	}
+	return cover
+}
+
+// scoreAlgorithm scores algo against the puzzle text, returning the matches
+// that contributed
+func scoreAlgorithm(p *puzzleText, algo Algorithm) (float64, []RecommendMatch) {
+	score := 0.0
+	matches := make([]RecommendMatch, 0)
+
+	for _, keyword := range algo.Keywords {
+		if p.hasPhrase(keyword) {
+			score += weightKeyword
+			matches = append(matches, RecommendMatch{Source: matchKeyword, Text: keyword})
+		}
+	}
+	for _, hint := range algo.RecognitionHints {
+		weight := 0.0
+		for _, quoted := range quotedPhrase.FindAllStringSubmatch(hint, -1) {
+			if p.hasPhrase(quoted[1]) {
+				weight = max(weight, weightHintPhrase)
+			}
+		}
+		weight = max(weight, weightHint*p.sentenceCover(hint))
+		if weight > 0 {
+			score += weight
+			matches = append(matches, RecommendMatch{Source: matchHint, Text: hint})
+		}
+	}
+	for _, use := range algo.WhenToUse {
+		if cover := p.sentenceCover(use); cover > 0 {
+			score += weightWhenToUse * cover
+			matches = append(matches, RecommendMatch{Source: matchWhenToUse, Text: use})
+		}
+	}
+	for _, tag := range algo.Tags {
+		if p.hasPhrase(strings.ReplaceAll(tag, "-", " ")) {
+			score += weightTag
+			matches = append(matches, RecommendMatch{Source: matchTag, Text: tag})
+		}
+	}
+	return score, matches
+}
+
+// recommend ranks algos against text, best first, keeping at most limit
+func recommend(algos []Algorithm, text string, limit int) []Recommendation {
+	p := newPuzzleText(text)
+
+	results := make([]Recommendation, 0)
+	for _, algo := range algos {
+		score, matches := scoreAlgorithm(p, algo)
+		if score == 0 {
+			continue
+		}
+		results = append(results, Recommendation{
+			ID:         algo.ID,
+			Name:       algo.Name,
+			Category:   algo.Category,
+			Difficulty: algo.Difficulty,
+			Score:      math.Round(score*100) / 100,
+			Matches:    matches,
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+func handleRecommend(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRecommendBody)
+
+	var req RecommendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Text) == "" {
+		http.Error(w, "text is required", http.StatusBadRequest)
+		return
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultRecommendations
+	}
+	if limit < 1 || limit > maxRecommendations {
+		http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxRecommendations), http.StatusBadRequest)
+		return
+	}
+
+	respondJSON(w, recommend(db.GetApprovedAlgorithms(), req.Text, limit))
+}
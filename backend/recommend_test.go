@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHasPhrase(t *testing.T) {
+	tests := []struct {
+		text, phrase string
+		want         bool
+	}{
+		{"find the fewest steps to the goal", "fewest steps", true},
+		{"find the fewest number of steps", "fewest steps", true},
+		{"the fewest possible number of steps", "fewest steps", false},
+		{"steps are fewest here", "fewest steps", false},
+		{"fewest moves, then many steps later on and more", "fewest steps", false},
+		{"a fewest b fewest steps", "fewest steps", true},
+		{"the pattern repeats forever", "repeat forever", true},
+		{"nothing relevant", "fewest steps", false},
+		{"anything", "", false},
+	}
+	for _, tt := range tests {
+		if got := newPuzzleText(tt.text).hasPhrase(tt.phrase); got != tt.want {
+			t.Errorf("%q in %q: %v, want %v", tt.phrase, tt.text, got, tt.want)
+		}
+	}
+}
+
+// Text that repeats a phrase's words without ever matching them in order
+// is the worst case for hasPhrase
+func BenchmarkHasPhrase(b *testing.B) {
+	text := strings.Repeat("steps ", maxRecommendBody/12) + strings.Repeat("fewest ", maxRecommendBody/14)
+	p := newPuzzleText(text)
+	for i := 0; i < b.N; i++ {
+		if p.hasPhrase("fewest steps") {
+			b.Fatal("matched words out of order")
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// restartFields are settings only read at startup. A reload reports changes
+// to them, but they keep their running values until the next restart.
+var restartFields = []string{
+	"port",
+	"dataDir",
+	"reseed",
+	"staticDir",
+	"server.readTimeout",
+	"server.writeTimeout",
+	"server.idleTimeout",
+}
+
+// Reloader re-reads the configuration and seed data on SIGHUP or when an
+// admin asks. Reloads are serialized.
+type Reloader struct {
+	mu   sync.Mutex
+	args []string // command-line arguments, re-parsed so flags keep precedence
+}
+
+var reloader *Reloader
+
+// ConfigChange is a single setting that differs after a reload. Secrets are
+// reported as changed but their values stay redacted.
+type ConfigChange struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old"`
+	New   json.RawMessage `json:"new"`
+}
+
+// SeedMergeReport lists the IDs affected by merging seed data
+type SeedMergeReport struct {
+	Added     []string `json:"added"`
+	Updated   []string `json:"updated"`
+	Unchanged int      `json:"unchanged"`
+}
+
+// SeedReport covers the algorithms and, nested, the comparison groups
+type SeedReport struct {
+	SeedMergeReport
+	Comparisons SeedMergeReport `json:"comparisons"`
+}
+
+// ReloadReport describes what a reload changed
+type ReloadReport struct {
+	Config          []ConfigChange `json:"config"`
+	RestartRequired []string       `json:"restartRequired"`
+	Seed            SeedReport     `json:"seed"`
+}
+
+// Reload loads and validates the configuration and seed data, then swaps
+// them in. Nothing is changed if either is invalid.
+func (rl *Reloader) Reload() (*ReloadReport, error) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	report, err := rl.reload()
+	if err != nil {
+		configReloads.Inc("failure")
+	} else {
+		configReloads.Inc("success")
+	}
+	return report, err
+}
+
+func (rl *Reloader) reload() (*ReloadReport, error) {
+	c, err := loadConfig(rl.args, io.Discard)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	seed, err := readSeed()
+	if err != nil {
+		return nil, err
+	}
+
+	prev := current().cfg
+	report := &ReloadReport{}
+	report.Config, report.RestartRequired = diffConfig(prev, c)
+
+	// Keep startup-only settings at their running values so the active
+	// configuration describes the server as it is
+	c.Port, c.DataDir, c.Reseed, c.StaticDir = prev.Port, prev.DataDir, prev.Reseed, prev.StaticDir
+	c.Server.ReadTimeout = prev.Server.ReadTimeout
+	c.Server.WriteTimeout = prev.Server.WriteTimeout
+	c.Server.IdleTimeout = prev.Server.IdleTimeout
+
+	// Merge the seed first: it can still fail, while applying the
+	// validated configuration cannot
+	report.Seed, err = db.MergeSeed(seed)
+	if err != nil {
+		return nil, fmt.Errorf("merging seed data: %w", err)
+	}
+	applyConfig(c)
+	return report, nil
+}
+
+// log summarizes the report
+func (report *ReloadReport) log(logger *slog.Logger) {
+	changed := make([]string, 0, len(report.Config))
+	for _, change := range report.Config {
+		changed = append(changed, change.Field)
+	}
+	logger.Info("Reloaded configuration and seed data",
+		"changed", changed,
+		"seed_added", len(report.Seed.Added),
+		"seed_updated", len(report.Seed.Updated),
+		"comparisons_added", len(report.Seed.Comparisons.Added),
+		"comparisons_updated", len(report.Seed.Comparisons.Updated))
+	if len(report.RestartRequired) > 0 {
+		logger.Warn("Some changed settings only take effect after a restart", "fields", report.RestartRequired)
+	}
+}
+
+// diffConfig compares two configurations field by field, returning every
+// change and the subset that needs a restart
+func diffConfig(old, new *Config) ([]ConfigChange, []string) {
+	oldRaw, newRaw := flattenConfig(old), flattenConfig(new)
+	oldShown, newShown := flattenConfig(old.Redacted()), flattenConfig(new.Redacted())
+
+	fields := make(map[string]bool)
+	for field := range oldRaw {
+		fields[field] = true
+	}
+	for field := range newRaw {
+		fields[field] = true
+	}
+	sorted := make([]string, 0, len(fields))
+	for field := range fields {
+		sorted = append(sorted, field)
+	}
+	sort.Strings(sorted)
+
+	changes := make([]ConfigChange, 0)
+	restart := make([]string, 0)
+	for _, field := range sorted {
+		if oldRaw[field] == newRaw[field] {
+			continue
+		}
+		changes = append(changes, ConfigChange{
+			Field: field,
+			Old:   rawOrNull(oldShown[field]),
+			New:   rawOrNull(newShown[field]),
+		})
+		for _, f := range restartFields {
+			if field == f {
+				restart = append(restart, field)
+			}
+		}
+	}
+	return changes, restart
+}
+
+// flattenConfig maps dotted field paths, as in the config file, to their
+// JSON-encoded values. Arrays are compared as a whole.
+func flattenConfig(c *Config) map[string]string {
+	data, _ := json.Marshal(c)
+	var tree map[string]interface{}
+	json.Unmarshal(data, &tree)
+
+	flat := make(map[string]string)
+	flattenJSON("", tree, flat)
+	return flat
+}
+
+func flattenJSON(prefix string, v interface{}, out map[string]string) {
+	if obj, ok := v.(map[string]interface{}); ok {
+		for key, child := range obj {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			flattenJSON(key, child, out)
+		}
+		return
+	}
+	leaf, _ := json.Marshal(v)
+	out[prefix] = string(leaf)
+}
+
+func rawOrNull(s string) json.RawMessage {
+	if s == "" {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(s)
+}
+
+// MergeSeed adds seed algorithms and comparison groups that are missing and
+// refreshes those whose content changed. Anything not in the seed, such as
+// approved community submissions, is left alone, as are comparison groups
+// an admin has edited or deleted. Nothing changes if the merged catalog
+// would have a prerequisite cycle or cannot be saved.
+func (d *Database) MergeSeed(seed *Seed) (SeedReport, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	report := SeedReport{
+		SeedMergeReport: SeedMergeReport{Added: []string{}, Updated: []string{}},
+		Comparisons:     SeedMergeReport{Added: []string{}, Updated: []string{}},
+	}
+
+	merged := append([]Algorithm(nil), d.Algorithms...)
+	index := make(map[string]int, len(merged))
+	for i, algo := range merged {
+		index[algo.ID] = i
+	}
+
+	now := time.Now()
+	for _, algo := range seed.Algorithms {
+		algo.Approved = true
+
+		i, exists := index[algo.ID]
+		if !exists {
+			algo.CreatedAt = now
+			merged = append(merged, algo)
+			index[algo.ID] = len(merged) - 1
+			report.Added = append(report.Added, algo.ID)
+			continue
+		}
+
+		algo.CreatedAt = merged[i].CreatedAt
+		if sameJSON(merged[i], algo) {
+			report.Unchanged++
+			continue
+		}
+		merged[i] = algo
+		report.Updated = append(report.Updated, algo.ID)
+	}
+
+	groups := append([]ComparisonGroup{}, d.ComparisonGroups...)
+	for _, g := range seed.ComparisonGroups {
+		if containsString(d.EditedComparisons, g.ID) {
+			continue
+		}
+		i := d.comparisonIndexUnlocked(g.ID)
+		switch {
+		case i < 0:
+			groups = append(groups, g)
+			report.Comparisons.Added = append(report.Comparisons.Added, g.ID)
+		case sameJSON(groups[i], g):
+			report.Comparisons.Unchanged++
+		default:
+			groups[i] = g
+			report.Comparisons.Updated = append(report.Comparisons.Updated, g.ID)
+		}
+	}
+
+	if len(report.Added) == 0 && len(report.Updated) == 0 &&
+		len(report.Comparisons.Added) == 0 && len(report.Comparisons.Updated) == 0 {
+		return report, nil
+	}
+
+	catalog := make([]Algorithm, 0, len(merged))
+	for _, algo := range merged {
+		if algo.Approved {
+			catalog = append(catalog, algo)
+		}
+	}
+	if err := checkPrerequisites(catalog); err != nil {
+		return SeedReport{}, err
+	}
+
+	prevAlgorithms, prevGroups := d.Algorithms, d.ComparisonGroups
+	d.Algorithms = merged
+	d.ComparisonGroups = groups
+	if err := d.saveUnlocked(); err != nil {
+		d.Algorithms, d.ComparisonGroups = prevAlgorithms, prevGroups
+		return SeedReport{}, err
+	}
+	d.catalogChanged()
+	return report, nil
+}
+
+func sameJSON(a, b interface{}) bool {
+	aJSON, _ := json.Marshal(a)
+	bJSON, _ := json.Marshal(b)
+	return bytes.Equal(aJSON, bJSON)
+}
+
+// watchSignal reloads on every SIGHUP until ctx is cancelled
+func (rl *Reloader) watchSignal(ctx context.Context, wg *sync.WaitGroup) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				report, err := rl.Reload()
+				if err != nil {
+					slog.Error("Reload failed", "err", err)
+					continue
+				}
+				report.log(slog.Default())
+			}
+		}
+	}()
+}
+
+func handleAdminReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	report, err := reloader.Reload()
+	if err != nil {
+		requestLogger(r).Error("Reload failed", "err", err)
+		http.Error(w, "Reload failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	report.log(requestLogger(r))
+	recordAudit(r, auditReload, "", "")
+
+	respondJSON(w, report)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeSeed(t *testing.T) {
+	d := &Database{
+		Algorithms: []Algorithm{{ID: "community", Approved: true, Prerequisites: []string{"seeded"}}},
+		dataFile:   filepath.Join(t.TempDir(), "data.json"),
+	}
+	report, err := d.MergeSeed(&Seed{Algorithms: []Algorithm{{ID: "seeded"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Added) != 1 || report.Added[0] != "seeded" || len(d.Algorithms) != 2 {
+		t.Errorf("report %+v, algorithms %+v", report, d.Algorithms)
+	}
+	if version, _ := d.CatalogVersion(); version != 1 {
+		t.Errorf("catalog version %d, want 1", version)
+	}
+}
+
+// A merge that fails must leave the database as it was, so a reload can
+// reject it before applying anything
+func TestMergeSeedUnchangedOnFailure(t *testing.T) {
+	community := Algorithm{ID: "community", Approved: true, Prerequisites: []string{"seeded"}}
+
+	t.Run("cycle", func(t *testing.T) {
+		d := &Database{Algorithms: []Algorithm{community}, dataFile: filepath.Join(t.TempDir(), "data.json")}
+		seed := &Seed{Algorithms: []Algorithm{{ID: "seeded", Prerequisites: []string{"community"}}}}
+		if _, err := d.MergeSeed(seed); err == nil {
+			t.Fatal("cycle merged")
+		}
+		if len(d.Algorithms) != 1 {
+			t.Errorf("algorithms changed: %+v", d.Algorithms)
+		}
+	})
+
+	t.Run("save", func(t *testing.T) {
+		d := &Database{Algorithms: []Algorithm{community}, dataFile: filepath.Join(t.TempDir(), "missing", "data.json")}
+		if _, err := d.MergeSeed(&Seed{Algorithms: []Algorithm{{ID: "seeded"}}}); err == nil {
+			t.Fatal("save into a missing directory succeeded")
+		}
+		if len(d.Algorithms) != 1 {
+			t.Errorf("algorithms changed: %+v", d.Algorithms)
+		}
+		if version, _ := d.CatalogVersion(); version != 0 {
+			t.Errorf("catalog version %d, want 0", version)
+		}
+	})
+}
+
+// withSeedDatabase installs a database loaded from the seed files
+func withSeedDatabase(t *testing.T) {
+	t.Helper()
+	prevState, prevDB := current(), db
+	state.Store(newAppState(defaultConfig(), nil))
+	t.Cleanup(func() {
+		state.Store(prevState)
+		db = prevDB
+	})
+
+	seed, err := readSeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range seed.Algorithms {
+		seed.Algorithms[i].Approved = true
+	}
+	db = &Database{
+		Algorithms:       seed.Algorithms,
+		ComparisonGroups: seed.ComparisonGroups,
+		dataFile:         filepath.Join(t.TempDir(), "data.json"),
+	}
+}
+
+// A reload must not undo an admin's edits to the seeded comparison groups
+func TestReloadKeepsEditedComparisons(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("ADMIN_PASS", "secretpass123")
+	withSeedDatabase(t)
+	if len(db.ComparisonGroups) < 2 {
+		t.Fatalf("%d seeded comparison groups", len(db.ComparisonGroups))
+	}
+
+	deleted := db.ComparisonGroups[0].ID
+	edited := db.ComparisonGroups[1]
+	edited.Description = "Edited by an admin"
+	if err := db.DeleteComparisonGroup(deleted); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateComparisonGroup(edited); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := (&Reloader{}).Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Seed.Comparisons.Added) != 0 || len(report.Seed.Comparisons.Updated) != 0 {
+		t.Errorf("comparisons report %+v", report.Seed.Comparisons)
+	}
+	if db.GetComparisonGroup(deleted) != nil {
+		t.Errorf("deleted group %q came back", deleted)
+	}
+	if g := db.GetComparisonGroup(edited.ID); g == nil || g.Description != edited.Description {
+		t.Errorf("edited group %q is now %+v", edited.ID, g)
+	}
+}
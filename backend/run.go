@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/smiggiddy/aoc-algo-buddy/backend/pseudo"
+)
+
+// Limits for /api/run beyond the configured step and allocation counts
+const (
+	maxRunBody       = 256 << 10
+	maxRunTraceSteps = 1000
+	maxRunDepth      = 200
+	maxRunOutput     = 64 << 10
+	runTimeout       = 2 * time.Second
+)
+
+// RunRequest is the body of POST /api/run. Code runs on its own, or after
+// the algorithm's pseudo code when both are given, so it can drive a class.
+type RunRequest struct {
+	AlgorithmID string         `json:"algorithmId,omitempty"`
+	Code        string         `json:"code,omitempty"`
+	Function    string         `json:"function,omitempty"`
+	Args        []any          `json:"args,omitempty"`
+	Globals     map[string]any `json:"globals,omitempty"`
+}
+
+// RunResponse is the trace of a run, with the statements skipped because
+// they don't parse
+type RunResponse struct {
+	*pseudo.Result
+	Warnings []*pseudo.SyntaxError `json:"warnings"`
+}
+
+// programCache memoizes parses of catalog pseudo code, which rarely changes
+var programCache sync.Map // string -> *pseudo.Program
+
+func parsePseudoCached(src string) *pseudo.Program {
+	if cached, ok := programCache.Load(src); ok {
+		return cached.(*pseudo.Program)
+	}
+	prog := pseudo.Parse(src)
+	programCache.Store(src, prog)
+	return prog
+}
+
+// validatePseudoCode fails on the linter's errors and ignores its warnings
+func validatePseudoCode(src string) error {
+	for _, d := range pseudo.Lint(src) {
+		if d.Severity == pseudo.SeverityError {
+			return fmt.Errorf("line %d: %s", d.Line, d.Message)
+		}
+	}
+	return nil
+}
+
+func handleRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRunBody)
+
+	var req RunRequest
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	limits := current().cfg.Limits
+	if len(req.Code) > limits.MaxPseudoCodeLength {
+		http.Error(w, "Code too long", http.StatusBadRequest)
+		return
+	}
+
+	var prog *pseudo.Program
+	switch {
+	case req.AlgorithmID != "":
+		algo := db.GetAlgorithmByID(req.AlgorithmID)
+		if algo == nil {
+			http.Error(w, "Algorithm not found", http.StatusNotFound)
+			return
+		}
+		if algo.PseudoCode == "" {
+			http.Error(w, "Algorithm has no pseudo code", http.StatusUnprocessableEntity)
+			return
+		}
+		if req.Code == "" {
+			prog = parsePseudoCached(algo.PseudoCode)
+		} else {
+			prog = pseudo.Parse(algo.PseudoCode + "\n" + req.Code)
+		}
+	case req.Code != "":
+		prog = pseudo.Parse(req.Code)
+	default:
+		http.Error(w, "algorithmId or code is required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := pseudo.Run(prog, req.Function, req.Args, req.Globals, pseudo.Limits{
+		MaxSteps:       limits.MaxRunSteps,
+		MaxAllocations: limits.MaxRunAllocations,
+		MaxTraceSteps:  maxRunTraceSteps,
+		MaxDepth:       maxRunDepth,
+		MaxOutput:      maxRunOutput,
+		Timeout:        runTimeout,
+	})
+	switch {
+	case errors.Is(err, pseudo.ErrUnknownFunction), errors.Is(err, pseudo.ErrBrokenFunction):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	warnings := prog.Warnings
+	if warnings == nil {
+		warnings = []*pseudo.SyntaxError{}
+	}
+	respondJSON(w, RunResponse{Result: result, Warnings: warnings})
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleRun(t *testing.T) {
+	prevState, prevDB := current(), db
+	state.Store(newAppState(defaultConfig(), nil))
+	db = &Database{Algorithms: []Algorithm{{
+		ID:         "binary-search",
+		Approved:   true,
+		PseudoCode: "function binary_search(arr, target):\n    for i from 0 to len(arr) - 1:\n        if arr[i] == target:\n            return i\n    return -1",
+	}}}
+	t.Cleanup(func() {
+		state.Store(prevState)
+		db = prevDB
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		status int
+		result any
+		output string
+		err    string
+	}{
+		{name: "script", body: `{"code": "print(1 + 2)"}`, status: http.StatusOK, output: "3\n"},
+		{
+			name:   "catalog function",
+			body:   `{"algorithmId": "binary-search", "function": "binary_search", "args": [[1, 3, 5, 7, 9], 7]}`,
+			status: http.StatusOK,
+			result: 3.0,
+		},
+		{
+			name:   "code after catalog",
+			body:   `{"algorithmId": "binary-search", "code": "print(binary_search([4, 2], 2))"}`,
+			status: http.StatusOK,
+			output: "1\n",
+		},
+		{
+			name:   "runtime error",
+			body:   `{"code": "x = 9223372036854775807 + 1"}`,
+			status: http.StatusOK,
+			err:    "integer overflow",
+		},
+		{
+			name:   "step limit",
+			body:   `{"code": "while True:\n    pass"}`,
+			status: http.StatusOK,
+			err:    "step limit of 100000 exceeded",
+		},
+		{name: "method", method: http.MethodGet, status: http.StatusMethodNotAllowed},
+		{name: "invalid body", body: `{`, status: http.StatusBadRequest},
+		{name: "no code", body: `{}`, status: http.StatusBadRequest},
+		{name: "code too long", body: `{"code": "` + strings.Repeat("x", defaultConfig().Limits.MaxPseudoCodeLength+1) + `"}`, status: http.StatusBadRequest},
+		{name: "unknown algorithm", body: `{"algorithmId": "nope"}`, status: http.StatusNotFound},
+		{name: "unknown function", body: `{"algorithmId": "binary-search", "function": "nope"}`, status: http.StatusUnprocessableEntity},
+		{name: "wrong arguments", body: `{"algorithmId": "binary-search", "args": [1]}`, status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			rec := httptest.NewRecorder()
+			handleRun(rec, httptest.NewRequest(method, "/api/run", strings.NewReader(tt.body)))
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Result any    `json:"result"`
+				Output string `json:"output"`
+				Error  *struct {
+					Message string `json:"message"`
+				} `json:"error"`
+				Warnings []any `json:"warnings"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Result != tt.result || resp.Output != tt.output {
+				t.Errorf("result %v, output %q", resp.Result, resp.Output)
+			}
+			switch {
+			case tt.err == "" && resp.Error != nil:
+				t.Errorf("error %q", resp.Error.Message)
+			case tt.err != "" && (resp.Error == nil || resp.Error.Message != tt.err):
+				t.Errorf("error %+v, want %q", resp.Error, tt.err)
+			}
+			if resp.Warnings == nil {
+				t.Error("warnings missing")
+			}
+		})
+	}
+}
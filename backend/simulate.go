@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/smiggiddy/aoc-algo-buddy/backend/pathfind"
+)
+
+// Limits for /api/simulate beyond the configured grid size and step count.
+// maxTraceCells caps the frontier and visited snapshots across all steps,
+// which is what makes a trace large: a few MB of JSON at most.
+const (
+	maxSimulateBody = 256 << 10
+	maxTraceCells   = 300_000
+)
+
+// SimulateRequest is the body of POST /api/simulate
+type SimulateRequest struct {
+	Algorithm string         `json:"algorithm"`
+	Grid      [][]int        `json:"grid"`              // 0 open, 1 wall
+	Weights   [][]int        `json:"weights,omitempty"` // cost to enter each cell
+	Start     pathfind.Cell  `json:"start"`
+	Goal      *pathfind.Cell `json:"goal,omitempty"` // optional for floodfill
+	MaxSteps  int            `json:"maxSteps,omitempty"`
+}
+
+func handleSimulate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxSimulateBody)
+
+	var req SimulateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	limits := current().cfg.Limits
+	maxSteps := limits.MaxSimulationSteps
+	if req.MaxSteps != 0 {
+		if req.MaxSteps < 1 || req.MaxSteps > maxSteps {
+			http.Error(w, fmt.Sprintf("maxSteps must be between 1 and %d", maxSteps), http.StatusBadRequest)
+			return
+		}
+		maxSteps = req.MaxSteps
+	}
+
+	trace, err := pathfind.Run(strings.ToLower(req.Algorithm), pathfind.Problem{
+		Grid:    req.Grid,
+		Weights: req.Weights,
+		Start:   req.Start,
+		Goal:    req.Goal,
+	}, pathfind.Limits{
+		MaxCells:      limits.MaxGridCells,
+		MaxSteps:      maxSteps,
+		MaxTraceCells: maxTraceCells,
+	})
+	switch {
+	case errors.Is(err, pathfind.ErrUnknownAlgorithm):
+		http.Error(w, fmt.Sprintf("algorithm must be one of %s", strings.Join(pathfind.Algorithms, ", ")), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respondJSON(w, trace)
+}
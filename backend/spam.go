@@ -0,0 +1,361 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSpamThreshold = 5.0
+	spamBurstWindow      = 10 * time.Minute
+	spamBurstLimit       = 3 // submissions per IP within spamBurstWindow
+	spamMaxDescLinks     = 3
+	spamMaxResourceLinks = 10
+	spamWordsPerLink     = 20 // fewer words than this per link is link-heavy
+)
+
+var urlPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"')\]]+`)
+
+// SpamInput is what the rules see for a single submission
+type SpamInput struct {
+	Request     *SubmitRequest
+	IPHash      string
+	ContentHash string
+	Settings    SpamSettings
+	Now         time.Time
+}
+
+// SpamRule inspects a submission and reports whether it looks like spam.
+// Each triggered rule adds its weight to the submission's score.
+type SpamRule interface {
+	Name() string
+	DefaultWeight() float64
+	Triggered(in *SpamInput) bool
+}
+
+// spamRules is the scoring pipeline, evaluated in order
+var spamRules = []SpamRule{
+	honeypotRule{},
+	linkDensityRule{},
+	blocklistRule{},
+	burstRule{},
+	duplicateRule{},
+}
+
+// SpamSettings are the admin-tunable parts of the pipeline, persisted with
+// the database
+type SpamSettings struct {
+	Threshold      float64            `json:"threshold"`
+	Weights        map[string]float64 `json:"weights"`
+	BlockedWords   []string           `json:"blockedWords"`
+	BlockedDomains []string           `json:"blockedDomains"`
+}
+
+func defaultSpamSettings() SpamSettings {
+	return SpamSettings{
+		Threshold:      defaultSpamThreshold,
+		Weights:        map[string]float64{},
+		BlockedWords:   []string{},
+		BlockedDomains: []string{},
+	}
+}
+
+// weight returns the configured weight for a rule, or its default
+func (s SpamSettings) weight(rule SpamRule) float64 {
+	if w, ok := s.Weights[rule.Name()]; ok {
+		return w
+	}
+	return rule.DefaultWeight()
+}
+
+// SpamResult is the outcome of running the pipeline
+type SpamResult struct {
+	Score       float64
+	Triggered   []string
+	Quarantined bool
+}
+
+// EvaluateSpam runs every rule against the input
+func EvaluateSpam(in *SpamInput) SpamResult {
+	result := SpamResult{Triggered: []string{}}
+	for _, rule := range spamRules {
+		if rule.Triggered(in) {
+			result.Score += in.Settings.weight(rule)
+			result.Triggered = append(result.Triggered, rule.Name())
+		}
+	}
+	result.Quarantined = result.Score >= in.Settings.Threshold
+	return result
+}
+
+// hashIP lets the burst rule group submissions without storing raw IPs.
+// The hash is keyed, so it can't be reversed by hashing every address.
+func hashIP(ip string) string {
+	mac := hmac.New(sha256.New, current().ipHashKey)
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// ipHashSecretGenerated is set when no secret is configured, so main can warn
+var ipHashSecretGenerated bool
+
+// ipHashSecret returns the configured secret, or a random one if unset
+func ipHashSecret(configured string) []byte {
+	if configured != "" {
+		return []byte(configured)
+	}
+	ipHashSecretGenerated = true
+	return randomSecret()
+}
+
+// contentHash fingerprints the user-supplied content of an algorithm so
+// resubmissions of the same payload can be spotted
+func contentHash(algo Algorithm) string {
+	data, _ := json.Marshal(struct {
+		Name        string
+		Description string
+		PseudoCode  string
+		Resources   []string
+	}{
+		strings.ToLower(strings.TrimSpace(algo.Name)),
+		strings.TrimSpace(algo.Description),
+		strings.TrimSpace(algo.PseudoCode),
+		algo.Resources,
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// honeypotRule fires when the hidden form field, invisible to humans, is filled
+type honeypotRule struct{}
+
+func (honeypotRule) Name() string           { return "honeypot" }
+func (honeypotRule) DefaultWeight() float64 { return 10 }
+func (honeypotRule) Triggered(in *SpamInput) bool {
+	return strings.TrimSpace(in.Request.Website) != ""
+}
+
+// linkDensityRule fires on link-stuffed descriptions or resource lists
+type linkDensityRule struct{}
+
+func (linkDensityRule) Name() string           { return "link_density" }
+func (linkDensityRule) DefaultWeight() float64 { return 3 }
+func (linkDensityRule) Triggered(in *SpamInput) bool {
+	algo := in.Request.Algorithm
+
+	descLinks := len(urlPattern.FindAllString(algo.Description, -1))
+	if descLinks > spamMaxDescLinks {
+		return true
+	}
+	words := len(strings.Fields(algo.Description))
+	if descLinks > 0 && words/descLinks < spamWordsPerLink {
+		return true
+	}
+
+	resourceLinks := 0
+	for _, res := range algo.Resources {
+		resourceLinks += len(urlPattern.FindAllString(res, -1))
+	}
+	return resourceLinks > spamMaxResourceLinks
+}
+
+// blocklistRule fires when text mentions a blocked word or links to a
+// blocked domain (including its subdomains)
+type blocklistRule struct{}
+
+func (blocklistRule) Name() string           { return "blocklist" }
+func (blocklistRule) DefaultWeight() float64 { return 5 }
+func (blocklistRule) Triggered(in *SpamInput) bool {
+	text := strings.ToLower(submissionText(in.Request))
+
+	for _, word := range in.Settings.BlockedWords {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" && strings.Contains(text, word) {
+			return true
+		}
+	}
+
+	if len(in.Settings.BlockedDomains) == 0 {
+		return false
+	}
+	for _, link := range urlPattern.FindAllString(text, -1) {
+		u, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
+		host := u.Hostname()
+		for _, domain := range in.Settings.BlockedDomains {
+			domain = strings.ToLower(strings.TrimSpace(domain))
+			if domain != "" && (host == domain || strings.HasSuffix(host, "."+domain)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// submissionText joins every free-text field of a submission
+func submissionText(req *SubmitRequest) string {
+	algo := req.Algorithm
+	parts := []string{req.SubmittedBy, algo.Name, algo.Description, algo.PseudoCode, algo.KeyInsight}
+	parts = append(parts, algo.Tags...)
+	parts = append(parts, algo.WhenToUse...)
+	for _, ref := range algo.AoCExamples {
+		parts = append(parts, ref.String())
+	}
+	parts = append(parts, algo.AoCNotes...)
+	parts = append(parts, algo.Resources...)
+	for _, impl := range algo.Implementations {
+		parts = append(parts, impl.Author, impl.Code)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// burstRule fires when one IP submits several times in quick succession
+type burstRule struct{}
+
+func (burstRule) Name() string           { return "burst" }
+func (burstRule) DefaultWeight() float64 { return 3 }
+func (burstRule) Triggered(in *SpamInput) bool {
+	return db.CountSubmissions(func(s Submission) bool {
+		return s.IPHash == in.IPHash && in.Now.Sub(s.SubmittedAt) < spamBurstWindow
+	}) >= spamBurstLimit
+}
+
+// duplicateRule fires when the same content has been submitted before
+type duplicateRule struct{}
+
+func (duplicateRule) Name() string           { return "duplicate" }
+func (duplicateRule) DefaultWeight() float64 { return 5 }
+func (duplicateRule) Triggered(in *SpamInput) bool {
+	return db.CountSubmissions(func(s Submission) bool {
+		return s.ContentHash == in.ContentHash
+	}) > 0
+}
+
+// CountSubmissions counts submissions matching fn
+func (d *Database) CountSubmissions(fn func(Submission) bool) int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	count := 0
+	for _, sub := range d.Submissions {
+		if fn(sub) {
+			count++
+		}
+	}
+	return count
+}
+
+// GetSpamSettings returns the current settings, falling back to defaults
+func (d *Database) GetSpamSettings() SpamSettings {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.SpamSettings == nil {
+		return defaultSpamSettings()
+	}
+	return *d.SpamSettings
+}
+
+func (d *Database) UpdateSpamSettings(settings SpamSettings) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.SpamSettings = &settings
+	return d.saveUnlocked()
+}
+
+// spamRuleInfo describes a rule for the admin settings endpoint
+type spamRuleInfo struct {
+	Name          string  `json:"name"`
+	Weight        float64 `json:"weight"`
+	DefaultWeight float64 `json:"defaultWeight"`
+}
+
+func spamSettingsResponse(settings SpamSettings) map[string]interface{} {
+	rules := make([]spamRuleInfo, 0, len(spamRules))
+	for _, rule := range spamRules {
+		rules = append(rules, spamRuleInfo{
+			Name:          rule.Name(),
+			Weight:        settings.weight(rule),
+			DefaultWeight: rule.DefaultWeight(),
+		})
+	}
+	return map[string]interface{}{
+		"threshold":      settings.Threshold,
+		"rules":          rules,
+		"blockedWords":   settings.BlockedWords,
+		"blockedDomains": settings.BlockedDomains,
+	}
+}
+
+// validateSpamSettings rejects unknown rules and nonsensical values
+func validateSpamSettings(settings *SpamSettings) error {
+	if settings.Threshold <= 0 {
+		return fmt.Errorf("threshold must be positive")
+	}
+	known := make(map[string]bool, len(spamRules))
+	for _, rule := range spamRules {
+		known[rule.Name()] = true
+	}
+	for name, weight := range settings.Weights {
+		if !known[name] {
+			return fmt.Errorf("unknown rule %q", name)
+		}
+		if weight < 0 {
+			return fmt.Errorf("weight for %q must not be negative", name)
+		}
+	}
+	maxEntries := current().cfg.Limits.MaxArrayLength * 10
+	if len(settings.BlockedWords) > maxEntries || len(settings.BlockedDomains) > maxEntries {
+		return fmt.Errorf("too many blocklist entries")
+	}
+	if settings.Weights == nil {
+		settings.Weights = map[string]float64{}
+	}
+	if settings.BlockedWords == nil {
+		settings.BlockedWords = []string{}
+	}
+	if settings.BlockedDomains == nil {
+		settings.BlockedDomains = []string{}
+	}
+	return nil
+}
+
+func handleAdminSpam(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		respondJSON(w, spamSettingsResponse(db.GetSpamSettings()))
+
+	case http.MethodPost:
+		r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+
+		var settings SpamSettings
+		if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := validateSpamSettings(&settings); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := db.UpdateSpamSettings(settings); err != nil {
+			requestLogger(r).Error("Failed to save spam settings", "err", err)
+			http.Error(w, "Failed to save settings", http.StatusInternalServerError)
+			return
+		}
+		recordAudit(r, auditSpamSettings, "", "")
+		respondJSON(w, spamSettingsResponse(settings))
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestHashIP(t *testing.T) {
+	hashWith := func(secret, ip string) string {
+		cfg := defaultConfig()
+		cfg.IPHashSecret = secret
+		prev := current()
+		state.Store(newAppState(cfg, nil))
+		defer state.Store(prev)
+		return hashIP(ip)
+	}
+
+	a := hashWith("secret-a", "192.0.2.1")
+	if len(a) != 16 || a != hashWith("secret-a", "192.0.2.1") {
+		t.Errorf("hash %q is not stable", a)
+	}
+	if a == hashWith("secret-a", "192.0.2.2") {
+		t.Error("different IPs share a hash")
+	}
+	if a == hashWith("secret-b", "192.0.2.1") {
+		t.Error("hash doesn't depend on the secret")
+	}
+	unkeyed := sha256.Sum256([]byte("192.0.2.1"))
+	if a == hex.EncodeToString(unkeyed[:8]) {
+		t.Error("hash isn't keyed")
+	}
+
+	// A random key is kept across reloads that don't change the secret
+	first := newAppState(defaultConfig(), nil)
+	if second := newAppState(defaultConfig(), first); string(second.ipHashKey) != string(first.ipHashKey) {
+		t.Error("reload replaced the generated key")
+	}
+}
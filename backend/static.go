@@ -0,0 +1,217 @@
+package main
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The frontend build (vite outputs to backend/static) is compiled into the
+// binary. The directory is kept in git with a placeholder so the embed works
+// before the frontend has been built.
+//
+//go:embed all:static
+var embeddedStatic embed.FS
+
+// Cache policies. Vite fingerprints everything it emits under assets/, so
+// those files never change; anything else (index.html, the service worker,
+// the manifest) must be revalidated, which the ETag makes cheap.
+const (
+	cacheImmutable  = "public, max-age=31536000, immutable"
+	cacheRevalidate = "no-cache"
+)
+
+// staticEncodings are the precompressed variants looked for next to each
+// file, in order of preference
+var staticEncodings = []struct {
+	encoding string
+	suffix   string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// staticVariant is a precompressed copy of an asset
+type staticVariant struct {
+	encoding string
+	name     string
+	etag     string
+}
+
+// staticAsset describes one servable file
+type staticAsset struct {
+	name     string
+	etag     string
+	modTime  time.Time
+	variants []staticVariant
+}
+
+// staticSite serves the frontend. Embedded assets are indexed once at
+// startup; a site served from disk is looked up on every request so
+// rebuilds show up without a restart.
+type staticSite struct {
+	fsys   fs.FS
+	assets map[string]*staticAsset // nil when serving from disk
+}
+
+var site *staticSite
+
+// newStaticSite serves from dir, or from the embedded build when dir is empty
+func newStaticSite(dir string) (*staticSite, error) {
+	if dir != "" {
+		return &staticSite{fsys: os.DirFS(dir)}, nil
+	}
+
+	sub, err := fs.Sub(embeddedStatic, "static")
+	if err != nil {
+		return nil, err
+	}
+	s := &staticSite{fsys: sub, assets: make(map[string]*staticAsset)}
+	err = fs.WalkDir(sub, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return err
+		}
+		asset, err := loadStaticAsset(sub, name)
+		if err != nil {
+			return err
+		}
+		s.assets[name] = asset
+		return nil
+	})
+	return s, err
+}
+
+// loadStaticAsset hashes a file and any precompressed variants beside it
+func loadStaticAsset(fsys fs.FS, name string) (*staticAsset, error) {
+	etag, modTime, err := hashStaticFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	asset := &staticAsset{name: name, etag: etag, modTime: modTime}
+
+	for _, enc := range staticEncodings {
+		variantETag, _, err := hashStaticFile(fsys, name+enc.suffix)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		asset.variants = append(asset.variants, staticVariant{
+			encoding: enc.encoding,
+			name:     name + enc.suffix,
+			etag:     variantETag,
+		})
+	}
+	return asset, nil
+}
+
+// hashStaticFile returns a strong ETag for a regular file
+func hashStaticFile(fsys fs.FS, name string) (string, time.Time, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if info.IsDir() {
+		return "", time.Time{}, fs.ErrNotExist
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", time.Time{}, err
+	}
+	return strconv.Quote(hex.EncodeToString(h.Sum(nil)[:16])), info.ModTime(), nil
+}
+
+func (s *staticSite) lookup(name string) (*staticAsset, bool) {
+	if s.assets != nil {
+		asset, ok := s.assets[name]
+		return asset, ok
+	}
+	asset, err := loadStaticAsset(s.fsys, name)
+	return asset, err == nil
+}
+
+// hasIndex reports whether the frontend has been built
+func (s *staticSite) hasIndex() bool {
+	_, ok := s.lookup("index.html")
+	return ok
+}
+
+func (s *staticSite) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" {
+		name = "index.html"
+	}
+	asset, ok := s.lookup(name)
+	if !ok && path.Ext(name) == "" {
+		// Client-side routes get the app shell
+		asset, ok = s.lookup("index.html")
+		if !ok {
+			http.Error(w, "Frontend not built", http.StatusNotFound)
+			return
+		}
+	}
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	h := w.Header()
+	switch {
+	case s.assets == nil:
+		h.Set("Cache-Control", cacheRevalidate) // files on disk can change under us
+	case strings.HasPrefix(asset.name, "assets/"):
+		h.Set("Cache-Control", cacheImmutable)
+	default:
+		h.Set("Cache-Control", cacheRevalidate)
+	}
+
+	served, etag := asset.name, asset.etag
+	if len(asset.variants) > 0 {
+		h.Add("Vary", "Accept-Encoding")
+		for _, v := range asset.variants {
+			if acceptsEncoding(r, v.encoding) {
+				served, etag = v.name, v.etag
+				h.Set("Content-Encoding", v.encoding)
+				break
+			}
+		}
+	}
+	h.Set("ETag", etag)
+
+	f, err := s.fsys.Open(served)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	// ServeContent answers If-None-Match and Range requests and picks the
+	// Content-Type from the original name, not the compressed one
+	http.ServeContent(w, r, asset.name, asset.modTime, content)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/smiggiddy/aoc-algo-buddy/backend/pseudo"
+)
+
+// Lint findings, errors included, are stored for reviewers and don't stop
+// a submission
+func TestSubmitStoresLint(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.Captcha.Type = captchaArithmetic
+	prevState, prevDB := current(), db
+	state.Store(newAppState(cfg, nil))
+	db = &Database{Submissions: []Submission{}, dataFile: filepath.Join(t.TempDir(), "data.json")}
+	t.Cleanup(func() {
+		state.Store(prevState)
+		db = prevDB
+	})
+
+	captcha := current().captchaSigner.Create(arithmeticCaptcha{})
+	body, _ := json.Marshal(SubmitRequest{
+		CaptchaID:     captcha.ID,
+		CaptchaAnswer: captcha.Answer,
+		SubmittedBy:   "tester",
+		Algorithm: Algorithm{
+			Name:        "Linear Search",
+			Category:    "Searching",
+			Description: "Check each element in turn",
+			PseudoCode:  "function find(xs, target):\n    for each x in xs:\n        if x == target:\n            return True\n     return missing",
+			Complexity:  Complexity{Time: "O(n)", Space: "O(1)"},
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	handleSubmit(rec, httptest.NewRequest(http.MethodPost, "/api/submit", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		SubmissionID string `json:"submissionId"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	subs := db.GetSubmissionsByStatus(statusPending)
+	if len(subs) != 1 || subs[0].ID != resp.SubmissionID {
+		t.Fatalf("pending submissions %+v, want %q", subs, resp.SubmissionID)
+	}
+	want := pseudo.Diagnostic{Line: 5, Severity: pseudo.SeverityError, Message: "indentation does not match any outer level"}
+	if got := subs[0].Lint; len(got) != 1 || got[0] != want {
+		t.Errorf("lint %+v, want %+v", got, want)
+	}
+}
@@ -6,6 +6,7 @@ import { downloadAsMarkdown, exportAsPDF } from '../utils/exportAlgorithm'
 import './AlgorithmDetail.css'
 import SpoilerDialog from './SpoilerDialog'
 import PseudoCodeBlock from './PseudoCodeBlock'
+import ImplementationTabs from './ImplementationTabs'
 
 const API_URL = import.meta.env.VITE_API_URL || ''
 
@@ -230,6 +231,13 @@ function AlgorithmDetail({ onShowHelp }) {
           <PseudoCodeBlock code={algorithm.pseudoCode} algorithmName={algorithm.name} />
         </section>
 
+        {algorithm.implementations?.length > 0 && (
+          <section className="section">
+            <h2 className="section-title">Implementations</h2>
+            <ImplementationTabs implementations={algorithm.implementations} />
+          </section>
+        )}
+
         <section className="section">
           <h2 className="section-title">Complexity</h2>
           <div className="complexity">
@@ -284,15 +292,28 @@ function AlgorithmDetail({ onShowHelp }) {
           </section>
         )}
 
-        {algorithm.aocExamples && algorithm.aocExamples.length > 0 && (
+        {(algorithm.aocExamples?.length > 0 || algorithm.aocNotes?.length > 0) && (
           <section className="section">
             <h2 className="section-title">Advent of Code Examples</h2>
             {spoilerPref === SPOILER_PREFS.HIDE ? (
               <p className="spoiler-hidden-msg">Spoilers are hidden. Change in settings to view.</p>
             ) : showAocExamples || spoilerPref === SPOILER_PREFS.SHOW ? (
               <ul className="aoc-examples">
-                {algorithm.aocExamples.map((example, i) => (
-                  <li key={i}>{example}</li>
+                {(algorithm.aocExamples || []).map((ref, i) => (
+                  <li key={i}>
+                    <a
+                      href={`https://adventofcode.com/${ref.year}/day/${ref.day}`}
+                      target="_blank"
+                      rel="noopener noreferrer"
+                    >
+                      {ref.year} Day {ref.day}{ref.title && ` - ${ref.title}`}
+                    </a>
+                    {ref.part > 0 && ` (part ${ref.part})`}
+                    {ref.notes && ` (${ref.notes})`}
+                  </li>
+                ))}
+                {(algorithm.aocNotes || []).map((note, i) => (
+                  <li key={`note-${i}`}>{note}</li>
                 ))}
               </ul>
             ) : (
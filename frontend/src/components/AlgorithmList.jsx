@@ -30,7 +30,7 @@ function AlgorithmList({ onShowHelp }) {
     const fetchData = async () => {
       try {
         const [algosRes, catsRes, tagsRes] = await Promise.all([
-          fetch(`${API_URL}/api/algorithms`),
+          fetch(`${API_URL}/api/algorithms?view=summary`),
           fetch(`${API_URL}/api/categories`),
           fetch(`${API_URL}/api/tags`)
         ])
@@ -231,7 +231,7 @@ function AlgorithmList({ onShowHelp }) {
       </div>
 
       {!hasActiveFilters && (
-        <AlgorithmOfDay algorithms={algorithms} />
+        <AlgorithmOfDay />
       )}
 
       {!hasActiveFilters && recentlyViewed.length > 0 && (
@@ -1,28 +1,10 @@
-import { useState, useMemo } from 'react'
+import { useState, useEffect } from 'react'
 import { Link } from 'react-router-dom'
 import './AlgorithmOfDay.css'
 
+const API_URL = import.meta.env.VITE_API_URL || ''
 const STORAGE_KEY = 'aoc-aotd-dismissed'
 
-// Deterministic selection based on date - same for all users
-function getDailyAlgorithm(algorithms, date) {
-  if (!algorithms || algorithms.length === 0) return null
-
-  // Use date string as seed for consistent selection
-  const dateStr = date.toISOString().split('T')[0] // "2026-01-14"
-  const seed = dateStr.split('-').join('') // "20260114"
-
-  // Simple hash to get a pseudo-random but deterministic index
-  let hash = 0
-  for (let i = 0; i < seed.length; i++) {
-    hash = ((hash << 5) - hash) + seed.charCodeAt(i)
-    hash = hash & hash // Convert to 32bit integer
-  }
-
-  const index = Math.abs(hash) % algorithms.length
-  return algorithms[index]
-}
-
 function getTodayString() {
   return new Date().toISOString().split('T')[0]
 }
@@ -38,16 +20,20 @@ function getInitialDismissedState() {
   return false
 }
 
-function AlgorithmOfDay({ algorithms }) {
+function AlgorithmOfDay() {
   const [dismissed, setDismissed] = useState(getInitialDismissedState)
+  const [algorithm, setAlgorithm] = useState(null)
 
-  // Get today's algorithm using useMemo
-  const algorithm = useMemo(() => {
-    if (algorithms && algorithms.length > 0) {
-      return getDailyAlgorithm(algorithms, new Date())
-    }
-    return null
-  }, [algorithms])
+  // The server picks the day's algorithm so everyone sees the same one
+  useEffect(() => {
+    if (dismissed) return
+    const controller = new AbortController()
+    fetch(`${API_URL}/api/daily`, { signal: controller.signal })
+      .then(res => (res.ok ? res.json() : null))
+      .then(daily => setAlgorithm(daily?.algorithm ?? null))
+      .catch(() => {}) // Offline or unavailable: just don't show the card
+    return () => controller.abort()
+  }, [dismissed])
 
   const handleDismiss = () => {
     localStorage.setItem(STORAGE_KEY, getTodayString())
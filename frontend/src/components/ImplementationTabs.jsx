@@ -0,0 +1,83 @@
+import { useState } from 'react'
+import './ImplementationTabs.css'
+
+const STORAGE_KEY = 'preferredLanguage'
+
+const LANGUAGE_NAMES = {
+  go: 'Go',
+  python: 'Python',
+  rust: 'Rust',
+  javascript: 'JavaScript',
+  typescript: 'TypeScript',
+  java: 'Java',
+  kotlin: 'Kotlin',
+  c: 'C',
+  cpp: 'C++',
+  csharp: 'C#',
+  haskell: 'Haskell',
+  ocaml: 'OCaml',
+  ruby: 'Ruby',
+  elixir: 'Elixir',
+  zig: 'Zig'
+}
+
+function ImplementationTabs({ implementations }) {
+  // Open on the language picked last time, on any algorithm
+  const [language, setLanguage] = useState(() => {
+    const preferred = localStorage.getItem(STORAGE_KEY)
+    return implementations.some(impl => impl.language === preferred)
+      ? preferred
+      : implementations[0].language
+  })
+  const [copied, setCopied] = useState(false)
+
+  const selected = implementations.find(impl => impl.language === language) || implementations[0]
+
+  const selectLanguage = (lang) => {
+    setLanguage(lang)
+    setCopied(false)
+    localStorage.setItem(STORAGE_KEY, lang)
+  }
+
+  const copyCode = async () => {
+    try {
+      await navigator.clipboard.writeText(selected.code)
+      setCopied(true)
+      setTimeout(() => setCopied(false), 2000)
+    } catch (err) {
+      console.error('Failed to copy:', err)
+    }
+  }
+
+  return (
+    <div className="implementations">
+      <div className="implementation-tabs" role="tablist">
+        {implementations.map(impl => (
+          <button
+            key={impl.language}
+            role="tab"
+            aria-selected={impl.language === selected.language}
+            className={`implementation-tab ${impl.language === selected.language ? 'active' : ''}`}
+            onClick={() => selectLanguage(impl.language)}
+          >
+            {LANGUAGE_NAMES[impl.language] || impl.language}
+          </button>
+        ))}
+      </div>
+      <div className="implementation-meta">
+        {selected.tested
+          ? <span className="implementation-tested">Tested</span>
+          : <span className="implementation-untested">Untested</span>}
+        {selected.author && <span className="implementation-author">by {selected.author}</span>}
+        <button className="implementation-copy" onClick={copyCode}>
+          {copied ? 'Copied!' : 'Copy'}
+        </button>
+      </div>
+      <div className="code-block">
+        <pre>{selected.code}</pre>
+      </div>
+    </div>
+  )
+}
+
+export default ImplementationTabs
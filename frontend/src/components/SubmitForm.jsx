@@ -1,5 +1,6 @@
-import { useState, useEffect } from 'react'
+import { useState, useEffect, useRef } from 'react'
 import { Link } from 'react-router-dom'
+import { canSolveProofOfWork, solveProofOfWork } from '../utils/proofOfWork'
 import './SubmitForm.css'
 
 const API_URL = import.meta.env.VITE_API_URL || ''
@@ -18,10 +19,20 @@ const CATEGORIES = [
 
 const DIFFICULTIES = ['Beginner', 'Intermediate', 'Advanced']
 
+// Reference implementations the form asks for; the API accepts more languages
+const IMPLEMENTATION_LANGUAGES = [
+  { language: 'go', label: 'Go', field: 'goCode' },
+  { language: 'python', label: 'Python', field: 'pythonCode' },
+  { language: 'rust', label: 'Rust', field: 'rustCode' }
+]
+
 function SubmitForm() {
   const [captcha, setCaptcha] = useState(null)
   const [captchaAnswer, setCaptchaAnswer] = useState('')
+  const [captchaSolution, setCaptchaSolution] = useState(null)
+  const powAbortRef = useRef(null)
   const [submittedBy, setSubmittedBy] = useState('')
+  const [website, setWebsite] = useState('') // honeypot, hidden from humans
   const [submitting, setSubmitting] = useState(false)
   const [submitted, setSubmitted] = useState(false)
   const [error, setError] = useState(null)
@@ -37,24 +48,47 @@ function SubmitForm() {
     timeComplexity: '',
     spaceComplexity: '',
     aocExamples: '',
-    resources: ''
+    resources: '',
+    goCode: '',
+    pythonCode: '',
+    rustCode: ''
   })
 
   useEffect(() => {
     fetchCaptcha()
+    return () => powAbortRef.current?.abort()
   }, [])
 
   const fetchCaptcha = async () => {
+    powAbortRef.current?.abort()
+    setCaptchaSolution(null)
+    setCaptchaAnswer('')
+
     try {
+      // The server picks the challenge type
       const res = await fetch(`${API_URL}/api/captcha`)
       const data = await res.json()
       setCaptcha(data)
-      setCaptchaAnswer('')
+
+      if (data.type === 'pow') {
+        if (!canSolveProofOfWork()) {
+          setError('This browser cannot solve the captcha; please use a newer browser')
+          return
+        }
+        const controller = new AbortController()
+        powAbortRef.current = controller
+        const solution = await solveProofOfWork(data.nonce, data.difficulty, controller.signal)
+        setCaptchaSolution(solution)
+      }
     } catch (err) {
-      setError('Failed to load captcha')
+      if (err.name !== 'AbortError') {
+        setError('Failed to load captcha')
+      }
     }
   }
 
+  const isProofOfWork = captcha?.type === 'pow'
+
   const handleChange = (e) => {
     const { name, value } = e.target
     setAlgorithm(prev => ({ ...prev, [name]: value }))
@@ -67,8 +101,11 @@ function SubmitForm() {
 
     const payload = {
       captchaId: captcha?.id,
-      captchaAnswer: parseInt(captchaAnswer, 10),
+      ...(isProofOfWork
+        ? { captchaSolution }
+        : { captchaAnswer: parseInt(captchaAnswer, 10) }),
       submittedBy: submittedBy || 'Anonymous',
+      website,
       algorithm: {
         name: algorithm.name,
         category: algorithm.category,
@@ -82,7 +119,10 @@ function SubmitForm() {
           space: algorithm.spaceComplexity
         },
         aocExamples: algorithm.aocExamples.split('\n').filter(Boolean),
-        resources: algorithm.resources.split('\n').filter(Boolean)
+        resources: algorithm.resources.split('\n').filter(Boolean),
+        implementations: IMPLEMENTATION_LANGUAGES
+          .filter(({ field }) => algorithm[field].trim())
+          .map(({ language, field }) => ({ language, code: algorithm[field] }))
       }
     }
 
@@ -262,6 +302,20 @@ function SubmitForm() {
               />
             </div>
           </div>
+
+          {IMPLEMENTATION_LANGUAGES.map(({ language, label, field }) => (
+            <div className="form-group" key={language}>
+              <label htmlFor={field}>{label} Implementation (optional)</label>
+              <textarea
+                id={field}
+                name={field}
+                value={algorithm[field]}
+                onChange={handleChange}
+                rows={8}
+                className="code-input"
+              />
+            </div>
+          ))}
         </div>
 
         <div className="form-section">
@@ -306,9 +360,30 @@ function SubmitForm() {
             />
           </div>
 
+          {/* Honeypot: hidden from people, but naive bots fill in every field */}
+          <div className="hp-field" aria-hidden="true">
+            <label htmlFor="website">Website</label>
+            <input
+              type="text"
+              id="website"
+              name="website"
+              tabIndex={-1}
+              autoComplete="off"
+              value={website}
+              onChange={(e) => setWebsite(e.target.value)}
+            />
+          </div>
+
           <div className="captcha-section">
             <label>Verify you're human *</label>
-            {captcha && (
+            {isProofOfWork && (
+              <div className="captcha-box">
+                <span className="captcha-question">
+                  {captchaSolution ? 'Verified \u2713' : 'Verifying your browser...'}
+                </span>
+              </div>
+            )}
+            {captcha && !isProofOfWork && (
               <div className="captcha-box">
                 <span className="captcha-question">{captcha.question}</span>
                 <input
@@ -327,7 +402,11 @@ function SubmitForm() {
 
           {error && <div className="error-message">{error}</div>}
 
-          <button type="submit" className="submit-btn" disabled={submitting}>
+          <button
+            type="submit"
+            className="submit-btn"
+            disabled={submitting || (isProofOfWork && !captchaSolution)}
+          >
             {submitting ? 'Submitting...' : 'Submit for Review'}
           </button>
         </div>
@@ -1,6 +1,5 @@
 import { useState, useEffect, useMemo } from 'react'
 import { Link, useParams, useNavigate } from 'react-router-dom'
-import { COMPARISON_GROUPS, getComparisonGroup } from '../data/comparisonGroups'
 import './Compare.css'
 
 const API_URL = import.meta.env.VITE_API_URL || ''
@@ -9,6 +8,9 @@ function Compare() {
   const { ids } = useParams()
   const navigate = useNavigate()
   const [algorithms, setAlgorithms] = useState([])
+  const [groups, setGroups] = useState([])
+  const [details, setDetails] = useState([])
+  const [complexities, setComplexities] = useState([])
   const [loading, setLoading] = useState(true)
   const [error, setError] = useState(null)
   const [selectedIds, setSelectedIds] = useState([])
@@ -21,35 +23,62 @@ function Compare() {
   }, [ids])
 
   useEffect(() => {
-    const fetchAlgorithms = async () => {
+    const fetchCatalog = async () => {
       try {
-        const res = await fetch(`${API_URL}/api/algorithms`)
-        if (!res.ok) throw new Error('Failed to fetch algorithms')
-        const data = await res.json()
-        setAlgorithms(data)
+        const [algoRes, groupRes] = await Promise.all([
+          fetch(`${API_URL}/api/algorithms?view=summary`),
+          fetch(`${API_URL}/api/comparisons`)
+        ])
+        if (!algoRes.ok) throw new Error('Failed to fetch algorithms')
+        if (!groupRes.ok) throw new Error('Failed to fetch comparison groups')
+        setAlgorithms(await algoRes.json())
+        setGroups(await groupRes.json())
         setLoading(false)
       } catch (err) {
         setError(err.message)
         setLoading(false)
       }
     }
-    fetchAlgorithms()
+    fetchCatalog()
   }, [])
 
+  // Full details only for the algorithms being compared
+  useEffect(() => {
+    if (selectedIds.length < 2) return
+    const controller = new AbortController()
+    const fetchDetails = async () => {
+      try {
+        const ids = selectedIds.map(encodeURIComponent).join(',')
+        const [res, complexityRes] = await Promise.all([
+          fetch(`${API_URL}/api/algorithms?ids=${ids}`, { signal: controller.signal }),
+          fetch(`${API_URL}/api/complexity?ids=${ids}`, { signal: controller.signal })
+        ])
+        if (!res.ok) throw new Error('Failed to fetch algorithm details')
+        setDetails(await res.json())
+        // Classes are a nice-to-have; the raw strings are shown without them
+        setComplexities(complexityRes.ok ? (await complexityRes.json()).algorithms : [])
+      } catch (err) {
+        if (err.name !== 'AbortError') setError(err.message)
+      }
+    }
+    fetchDetails()
+    return () => controller.abort()
+  }, [selectedIds])
+
   const selectedAlgorithms = useMemo(() =>
     selectedIds
-      .map(id => algorithms.find(a => a.id === id))
+      .map(id => details.find(a => a.id === id))
       .filter(Boolean),
-    [algorithms, selectedIds]
+    [details, selectedIds]
   )
 
   const preDefinedGroup = useMemo(() => {
     if (selectedIds.length < 2) return null
-    return COMPARISON_GROUPS.find(group =>
+    return groups.find(group =>
       selectedIds.every(id => group.algorithms.includes(id)) &&
       group.algorithms.every(id => selectedIds.includes(id))
     )
-  }, [selectedIds])
+  }, [groups, selectedIds])
 
   const handleAlgorithmToggle = (algoId) => {
     setSelectedIds(prev => {
@@ -100,7 +129,7 @@ function Compare() {
       <section className="quick-compare-section">
         <h2>Quick Comparisons</h2>
         <div className="quick-compare-grid">
-          {COMPARISON_GROUPS.map(group => (
+          {groups.map(group => (
             <button
               key={group.id}
               className={`quick-compare-card ${preDefinedGroup?.id === group.id ? 'active' : ''}`}
@@ -165,7 +194,7 @@ function Compare() {
                     <div className="diff-cell aspect-cell">{diff.aspect}</div>
                     {selectedAlgorithms.map(algo => (
                       <div key={algo.id} className="diff-cell">
-                        {diff[algo.id] || '-'}
+                        {diff.values?.[algo.id] || '-'}
                       </div>
                     ))}
                   </div>
@@ -195,11 +224,19 @@ function Compare() {
             />
             <ComparisonRow
               label="Time Complexity"
-              values={selectedAlgorithms.map(a => a.complexity?.time)}
+              values={selectedAlgorithms.map(a => ({
+                raw: a.complexity?.time,
+                class: complexities.find(c => c.id === a.id)?.time.class
+              }))}
+              type="complexity"
             />
             <ComparisonRow
               label="Space Complexity"
-              values={selectedAlgorithms.map(a => a.complexity?.space)}
+              values={selectedAlgorithms.map(a => ({
+                raw: a.complexity?.space,
+                class: complexities.find(c => c.id === a.id)?.space.class
+              }))}
+              type="complexity"
             />
             <ComparisonRow
               label="Key Insight"
@@ -257,6 +294,16 @@ function ComparisonRow({ label, values, type = 'text' }) {
             {value}
           </span>
         )
+      case 'complexity':
+        if (!value.raw) return <span className="no-value">-</span>
+        return (
+          <>
+            {value.raw}
+            {value.class && value.class !== 'unknown' && (
+              <span className="complexity-class">{value.class}</span>
+            )}
+          </>
+        )
       case 'list':
         if (!Array.isArray(value) || value.length === 0) return <span className="no-value">-</span>
         return (
@@ -20,7 +20,7 @@ function Dashboard() {
   useEffect(() => {
     const fetchAlgorithms = async () => {
       try {
-        const res = await fetch(`${API_URL}/api/algorithms`)
+        const res = await fetch(`${API_URL}/api/algorithms?fields=name,category,difficulty,description,prerequisites`)
         if (!res.ok) throw new Error('Failed to fetch algorithms')
         const data = await res.json()
         setAlgorithms(data)
@@ -0,0 +1,44 @@
+/**
+ * Whether this browser can solve proof-of-work challenges.
+ * crypto.subtle is only available in secure contexts (HTTPS or localhost).
+ */
+export function canSolveProofOfWork() {
+  return typeof window !== 'undefined' && !!window.crypto?.subtle
+}
+
+/**
+ * Count leading zero bits in a hash
+ */
+function leadingZeroBits(bytes) {
+  let count = 0
+  for (const byte of bytes) {
+    if (byte === 0) {
+      count += 8
+      continue
+    }
+    return count + Math.clz32(byte) - 24
+  }
+  return count
+}
+
+/**
+ * Find a solution such that SHA-256(nonce + ":" + solution) starts with
+ * at least `difficulty` zero bits. Yields to the event loop periodically
+ * so the page stays responsive; aborts when `signal` fires.
+ */
+export async function solveProofOfWork(nonce, difficulty, signal) {
+  const encoder = new TextEncoder()
+  for (let counter = 0; ; counter++) {
+    if (signal?.aborted) {
+      throw new DOMException('Aborted', 'AbortError')
+    }
+    const solution = counter.toString(36)
+    const digest = await crypto.subtle.digest('SHA-256', encoder.encode(`${nonce}:${solution}`))
+    if (leadingZeroBits(new Uint8Array(digest)) >= difficulty) {
+      return solution
+    }
+    if (counter % 1000 === 999) {
+      await new Promise(resolve => setTimeout(resolve, 0))
+    }
+  }
+}
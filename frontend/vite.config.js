@@ -1,6 +1,36 @@
 import { defineConfig } from 'vite'
 import react from '@vitejs/plugin-react'
 import { VitePWA } from 'vite-plugin-pwa'
+import { readdirSync, readFileSync, writeFileSync } from 'node:fs'
+import { join } from 'node:path'
+import { brotliCompressSync, gzipSync, constants } from 'node:zlib'
+
+const outDir = '../backend/static'
+
+// Writes .br and .gz copies of text assets next to the originals; the Go
+// server embeds them and serves whichever the client accepts
+function precompress() {
+  const compressible = /\.(js|css|html|svg|json|webmanifest)$/
+  return {
+    name: 'precompress',
+    apply: 'build',
+    closeBundle() {
+      for (const entry of readdirSync(outDir, { recursive: true, withFileTypes: true })) {
+        if (!entry.isFile() || !compressible.test(entry.name)) continue
+        const file = join(entry.parentPath, entry.name)
+        const data = readFileSync(file)
+        if (data.length < 1024) continue
+        const variants = {
+          '.br': brotliCompressSync(data, { params: { [constants.BROTLI_PARAM_QUALITY]: 11 } }),
+          '.gz': gzipSync(data, { level: 9 }),
+        }
+        for (const [suffix, compressed] of Object.entries(variants)) {
+          if (compressed.length < data.length) writeFileSync(file + suffix, compressed)
+        }
+      }
+    }
+  }
+}
 
 export default defineConfig({
   plugins: [
@@ -52,7 +82,8 @@ export default defineConfig({
           }
         ]
       }
-    })
+    }),
+    precompress()
   ],
   server: {
     proxy: {
@@ -63,6 +94,7 @@ export default defineConfig({
     }
   },
   build: {
-    outDir: '../backend/static'
+    outDir,
+    emptyOutDir: false // keeps backend/static/.gitkeep
   }
 })